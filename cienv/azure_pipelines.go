@@ -0,0 +1,50 @@
+package cienv
+
+import (
+	"os"
+	"strings"
+)
+
+// IsInAzurePipelines returns true if reviewdog is running in Azure Pipelines.
+func IsInAzurePipelines() bool {
+	// https://docs.microsoft.com/en-us/azure/devops/pipelines/build/variables#system-variables-devops-services
+	return os.Getenv("TF_BUILD") != ""
+}
+
+// azurePipelinesProvider provides BuildInfo from predefined variables of
+// Azure Pipelines.
+// https://docs.microsoft.com/en-us/azure/devops/pipelines/build/variables
+type azurePipelinesProvider struct {
+	pullRequestProvider
+}
+
+var (
+	// BUILD_SOURCEVERSION is a merge commit for Pull Requests.
+	azureSHAEnvs    = []string{"SYSTEM_PULLREQUEST_SOURCECOMMITID", "BUILD_SOURCEVERSION"}
+	azureBranchEnvs = []string{"SYSTEM_PULLREQUEST_SOURCEBRANCH", "BUILD_SOURCEBRANCH"}
+	// SYSTEM_PULLREQUEST_PULLREQUESTNUMBER is for GitHub repositories and
+	// SYSTEM_PULLREQUEST_PULLREQUESTID is for Azure Repos.
+	azurePullRequestEnvs = []string{"SYSTEM_PULLREQUEST_PULLREQUESTNUMBER", "SYSTEM_PULLREQUEST_PULLREQUESTID"}
+	azureRepoEnvs        = []string{"BUILD_REPOSITORY_PROVIDER", "BUILD_REPOSITORY_NAME", "BUILD_REPOSITORY_URI"}
+)
+
+func (*azurePipelinesProvider) Name() string { return "Azure Pipelines" }
+
+func (*azurePipelinesProvider) Detect() (bool, string) { return envIsSet("TF_BUILD") }
+
+func (*azurePipelinesProvider) Envs() []string {
+	return joinEnvs(azureSHAEnvs, azureBranchEnvs, azurePullRequestEnvs, azureRepoEnvs, overrideEnvs)
+}
+
+func (*azurePipelinesProvider) BuildInfo() (*BuildInfo, error) {
+	info := &BuildInfo{
+		SHA:         getOneEnvValue(azureSHAEnvs),
+		Branch:      strings.TrimPrefix(getOneEnvValue(azureBranchEnvs), "refs/heads/"),
+		PullRequest: getPullRequestNumFrom(azurePullRequestEnvs...),
+	}
+	// BUILD_REPOSITORY_NAME is "owner/repo" for GitHub repositories.
+	if os.Getenv("BUILD_REPOSITORY_PROVIDER") == "GitHub" {
+		info.Owner, info.Repo = getOwnerAndRepoFromSlug([]string{"BUILD_REPOSITORY_NAME"})
+	}
+	return completeBuildInfo("Azure Pipelines", os.Getenv("BUILD_REPOSITORY_URI"), info)
+}
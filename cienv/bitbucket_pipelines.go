@@ -14,3 +14,34 @@ func IsInBitbucketPipe() bool {
 	// this env variables are not really documented, but they are present in the build environment
 	return os.Getenv("BITBUCKET_PIPE_STORAGE_DIR") != "" || os.Getenv("BITBUCKET_PIPE_SHARED_STORAGE_DIR") != ""
 }
+
+// bitbucketPipelinesProvider provides BuildInfo from default variables of
+// Bitbucket Pipelines. BITBUCKET_PR_ID is present only in PR pipelines.
+// https://support.atlassian.com/bitbucket-cloud/docs/variables-and-secrets/#Default-variables
+type bitbucketPipelinesProvider struct {
+	pullRequestProvider
+}
+
+var bitbucketBranchEnvs = []string{"BITBUCKET_PR_DESTINATION_BRANCH", "BITBUCKET_BRANCH"}
+
+func (*bitbucketPipelinesProvider) Name() string { return "Bitbucket Pipelines" }
+
+func (*bitbucketPipelinesProvider) Detect() (bool, string) {
+	return envIsSet("BITBUCKET_PIPELINE_UUID")
+}
+
+func (*bitbucketPipelinesProvider) Envs() []string {
+	return joinEnvs([]string{"BITBUCKET_REPO_FULL_NAME", "BITBUCKET_COMMIT", "BITBUCKET_PR_ID"}, bitbucketBranchEnvs, overrideEnvs)
+}
+
+func (*bitbucketPipelinesProvider) BuildInfo() (*BuildInfo, error) {
+	owner, repo := getOwnerAndRepoFromSlug([]string{"BITBUCKET_REPO_FULL_NAME"})
+	info := &BuildInfo{
+		Owner:       owner,
+		Repo:        repo,
+		SHA:         os.Getenv("BITBUCKET_COMMIT"),
+		Branch:      getOneEnvValue(bitbucketBranchEnvs),
+		PullRequest: getPullRequestNumFrom("BITBUCKET_PR_ID"),
+	}
+	return completeBuildInfo("Bitbucket Pipelines", "", info)
+}
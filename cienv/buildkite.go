@@ -0,0 +1,33 @@
+package cienv
+
+import "os"
+
+// IsInBuildkite returns true if reviewdog is running in Buildkite.
+func IsInBuildkite() bool {
+	// https://buildkite.com/docs/pipelines/environment-variables
+	return os.Getenv("BUILDKITE") == "true"
+}
+
+// buildkiteProvider provides BuildInfo from environment variables of
+// Buildkite. BUILDKITE_PULL_REQUEST is "false" for non Pull Request builds.
+// https://buildkite.com/docs/pipelines/environment-variables
+type buildkiteProvider struct {
+	pullRequestProvider
+}
+
+func (*buildkiteProvider) Name() string { return "Buildkite" }
+
+func (*buildkiteProvider) Detect() (bool, string) { return envEquals("BUILDKITE", "true") }
+
+func (*buildkiteProvider) Envs() []string {
+	return joinEnvs([]string{"BUILDKITE_COMMIT", "BUILDKITE_BRANCH", "BUILDKITE_PULL_REQUEST", "BUILDKITE_REPO"}, overrideEnvs)
+}
+
+func (*buildkiteProvider) BuildInfo() (*BuildInfo, error) {
+	info := &BuildInfo{
+		SHA:         os.Getenv("BUILDKITE_COMMIT"),
+		Branch:      os.Getenv("BUILDKITE_BRANCH"),
+		PullRequest: getPullRequestNumFrom("BUILDKITE_PULL_REQUEST"),
+	}
+	return completeBuildInfo("Buildkite", os.Getenv("BUILDKITE_REPO"), info)
+}
@@ -11,6 +11,11 @@ import (
 
 // BuildInfo represents build information about GitHub or GitLab project.
 type BuildInfo struct {
+	// Optional. Host of the git remote (e.g. github.com). It's set only when
+	// BuildInfo is derived from the local git repository, and reporters use
+	// the API of GitHub Enterprise Server or self-hosted GitLab on the host.
+	Host string
+
 	Owner string
 	Repo  string
 	SHA   string
@@ -26,49 +31,45 @@ type BuildInfo struct {
 	GerritRevisionID string
 }
 
-// GetBuildInfo returns BuildInfo from environment variables.
-//
-// Supported CI services' documents:
-// - Travis CI: https://docs.travis-ci.com/user/environment-variables/
-// - Circle CI: https://circleci.com/docs/environment-variables/
-// - Drone.io: http://docs.drone.io/environment-reference/
-// - GitLab CI: https://docs.gitlab.com/ee/ci/variables/#predefined-variables-environment-variables
-// - GitLab CI doesn't export ID of Merge Request. https://gitlab.com/gitlab-org/gitlab-ce/issues/15280
-func GetBuildInfo() (prInfo *BuildInfo, isPR bool, err error) {
-	if IsInGitHubAction() {
-		return getBuildInfoFromGitHubAction()
+// commonProvider is the fallback provider which reads common environment
+// variables (e.g. CI_REPO_OWNER) and environment variables of Travis CI,
+// Circle CI, drone.io, GitLab CI and Bitbucket Pipelines at once. It's for
+// users who set environment variables manually in unsupported CI services and
+// for backward compatibility. It's detected if any of the variables is set.
+type commonProvider struct {
+	pullRequestProvider
+}
+
+func (*commonProvider) Name() string { return "common" }
+
+func (p *commonProvider) Detect() (bool, string) {
+	for _, env := range p.Envs() {
+		if ok, reason := envIsSet(env); ok {
+			return true, reason
+		}
 	}
-	owner, repo := getOwnerAndRepoFromSlug([]string{
+	return false, ""
+}
+
+var (
+	commonOwnerAndRepoSlugEnvs = []string{
 		"TRAVIS_REPO_SLUG",
 		"DRONE_REPO", // drone<=0.4
 		"BITBUCKET_REPO_FULL_NAME",
-	})
-	if owner == "" {
-		owner = getOneEnvValue([]string{
-			"CI_REPO_OWNER", // common
-			"CIRCLE_PROJECT_USERNAME",
-			"DRONE_REPO_OWNER",
-			"CI_PROJECT_NAMESPACE", // GitLab CI
-		})
-	}
-	if owner == "" {
-		return nil, false, errors.New("cannot get repo owner from environment variable. Set CI_REPO_OWNER?")
 	}
-
-	if repo == "" {
-		repo = getOneEnvValue([]string{
-			"CI_REPO_NAME", // common
-			"CIRCLE_PROJECT_REPONAME",
-			"DRONE_REPO_NAME",
-			"CI_PROJECT_NAME", // GitLab CI
-		})
+	commonOwnerEnvs = []string{
+		"CI_REPO_OWNER", // common
+		"CIRCLE_PROJECT_USERNAME",
+		"DRONE_REPO_OWNER",
+		"CI_PROJECT_NAMESPACE", // GitLab CI
 	}
-
-	if repo == "" {
-		return nil, false, errors.New("cannot get repo name from environment variable. Set CI_REPO_NAME?")
+	commonRepoEnvs = []string{
+		"CI_REPO_NAME", // common
+		"CIRCLE_PROJECT_REPONAME",
+		"DRONE_REPO_NAME",
+		"CI_PROJECT_NAME", // GitLab CI
 	}
-
-	sha := getOneEnvValue([]string{
+	commonSHAEnvs = []string{
 		"CI_COMMIT", // common
 		"TRAVIS_PULL_REQUEST_SHA",
 		"TRAVIS_COMMIT",
@@ -76,12 +77,8 @@ func GetBuildInfo() (prInfo *BuildInfo, isPR bool, err error) {
 		"DRONE_COMMIT",
 		"CI_COMMIT_SHA", // GitLab CI
 		"BITBUCKET_COMMIT",
-	})
-	if sha == "" {
-		return nil, false, errors.New("cannot get commit SHA from environment variable. Set CI_COMMIT?")
 	}
-
-	branch := getOneEnvValue([]string{
+	commonBranchEnvs = []string{
 		"CI_BRANCH", // common
 		"TRAVIS_PULL_REQUEST_BRANCH",
 		"CIRCLE_BRANCH",
@@ -89,21 +86,85 @@ func GetBuildInfo() (prInfo *BuildInfo, isPR bool, err error) {
 		// present only if PR pipeline
 		"BITBUCKET_PR_DESTINATION_BRANCH",
 		"BITBUCKET_BRANCH",
-	})
+	}
+	commonPullRequestEnvs = []string{
+		// Common.
+		"CI_PULL_REQUEST",
+		// Travis CI.
+		"TRAVIS_PULL_REQUEST",
+		// Circle CI.
+		"CIRCLE_PULL_REQUEST", // CircleCI 2.0
+		"CIRCLE_PR_NUMBER",    // For Pull Request by a fork repository
+		// drone.io.
+		"DRONE_PULL_REQUEST",
+		// GitLab CI MergeTrains
+		"CI_MERGE_REQUEST_IID",
+		"BITBUCKET_PR_ID",
+	}
+)
 
-	pr := getPullRequestNum()
+func (*commonProvider) Envs() []string {
+	return joinEnvs(commonOwnerAndRepoSlugEnvs, commonOwnerEnvs, commonRepoEnvs,
+		commonSHAEnvs, commonBranchEnvs, commonPullRequestEnvs)
+}
+
+// BuildInfo returns BuildInfo from environment variables.
+//
+// Supported CI services' documents:
+// - Travis CI: https://docs.travis-ci.com/user/environment-variables/
+// - Circle CI: https://circleci.com/docs/environment-variables/
+// - Drone.io: http://docs.drone.io/environment-reference/
+// - GitLab CI: https://docs.gitlab.com/ee/ci/variables/#predefined-variables-environment-variables
+// - GitLab CI doesn't export ID of Merge Request. https://gitlab.com/gitlab-org/gitlab-ce/issues/15280
+func (*commonProvider) BuildInfo() (*BuildInfo, error) {
+	owner, repo := getOwnerAndRepoFromSlug(commonOwnerAndRepoSlugEnvs)
+	if owner == "" {
+		owner = getOneEnvValue(commonOwnerEnvs)
+	}
+	if owner == "" {
+		return nil, errors.New("cannot get repo owner from environment variable. Set CI_REPO_OWNER?")
+	}
+
+	if repo == "" {
+		repo = getOneEnvValue(commonRepoEnvs)
+	}
+
+	if repo == "" {
+		return nil, errors.New("cannot get repo name from environment variable. Set CI_REPO_NAME?")
+	}
+
+	sha := getOneEnvValue(commonSHAEnvs)
+	if sha == "" {
+		return nil, errors.New("cannot get commit SHA from environment variable. Set CI_COMMIT?")
+	}
 
 	return &BuildInfo{
 		Owner:       owner,
 		Repo:        repo,
-		PullRequest: pr,
+		PullRequest: getPullRequestNumFrom(commonPullRequestEnvs...),
 		SHA:         sha,
-		Branch:      branch,
-	}, pr != 0, nil
+		Branch:      getOneEnvValue(commonBranchEnvs),
+	}, nil
 }
 
-// GetGerritBuildInfo returns Gerrit specific build info
-func GetGerritBuildInfo() (*BuildInfo, error) {
+// gerritProvider provides Gerrit specific build info. It's not registered as
+// it doesn't provide repo owner and name, and it's used by
+// GetGerritBuildInfo.
+type gerritProvider struct{}
+
+func (*gerritProvider) Name() string { return "Gerrit" }
+
+func (*gerritProvider) Detect() (bool, string) { return envIsSet("GERRIT_CHANGE_ID") }
+
+func (*gerritProvider) Envs() []string {
+	return []string{"GERRIT_CHANGE_ID", "GERRIT_REVISION_ID", "GERRIT_BRANCH"}
+}
+
+func (*gerritProvider) IsPR(b *BuildInfo) bool { return b.GerritChangeID != "" }
+
+func (*gerritProvider) ReadOnlyToken() (bool, string) { return false, "" }
+
+func (*gerritProvider) BuildInfo() (*BuildInfo, error) {
 	changeID := os.Getenv("GERRIT_CHANGE_ID")
 	if changeID == "" {
 		return nil, errors.New("cannot get change id from environment variable. Set GERRIT_CHANGE_ID ?")
@@ -126,21 +187,43 @@ func GetGerritBuildInfo() (*BuildInfo, error) {
 	}, nil
 }
 
-func getPullRequestNum() int {
-	envs := []string{
-		// Common.
-		"CI_PULL_REQUEST",
-		// Travis CI.
-		"TRAVIS_PULL_REQUEST",
-		// Circle CI.
-		"CIRCLE_PULL_REQUEST", // CircleCI 2.0
-		"CIRCLE_PR_NUMBER",    // For Pull Request by a fork repository
-		// drone.io.
-		"DRONE_PULL_REQUEST",
-		// GitLab CI MergeTrains
-		"CI_MERGE_REQUEST_IID",
-		"BITBUCKET_PR_ID",
+// GetGerritBuildInfo returns Gerrit specific build info
+func GetGerritBuildInfo() (*BuildInfo, error) {
+	return (&gerritProvider{}).BuildInfo()
+}
+
+// GetJobURL returns URL of the current CI job. It returns empty string if it's
+// not available. REVIEWDOG_TARGET_URL takes precedence over CI services'
+// environment variables.
+func GetJobURL() string {
+	if u := os.Getenv("REVIEWDOG_TARGET_URL"); u != "" {
+		return u
 	}
+	if IsInGitHubAction() {
+		server := os.Getenv("GITHUB_SERVER_URL")
+		repo := os.Getenv("GITHUB_REPOSITORY")
+		runID := os.Getenv("GITHUB_RUN_ID")
+		if server == "" || repo == "" || runID == "" {
+			return ""
+		}
+		return server + "/" + repo + "/actions/runs/" + runID
+	}
+	return getOneEnvValue([]string{
+		"CI_JOB_URL", // GitLab CI
+		"TRAVIS_JOB_WEB_URL",
+		"CIRCLE_BUILD_URL",
+		"DRONE_BUILD_LINK",
+		"BUILD_URL", // Jenkins
+		"BUILDKITE_BUILD_URL",
+		"CODEBUILD_BUILD_URL",
+		"CI_PIPELINE_URL", // Woodpecker CI
+	})
+}
+
+// getPullRequestNumFrom returns Pull Request number from the first
+// environment variable which ends with a number (e.g. "14", "pr/14" or
+// "https://github.com/owner/repo/pull/14").
+func getPullRequestNumFrom(envs ...string) int {
 	// regexp.MustCompile() in func intentionally because this func is called
 	// once for one run.
 	re := regexp.MustCompile(`[1-9]\d*$`)
@@ -37,6 +37,83 @@ func setupEnvs() (cleanup func()) {
 		"GERRIT_CHANGE_ID",
 		"GERRIT_REVISION_ID",
 		"GERRIT_BRANCH",
+		"REVIEWDOG_TARGET_URL",
+		"GITHUB_SERVER_URL",
+		"GITHUB_REPOSITORY",
+		"GITHUB_RUN_ID",
+		"CI_JOB_URL",
+		"TRAVIS_JOB_WEB_URL",
+		"CIRCLE_BUILD_URL",
+		"DRONE_BUILD_LINK",
+		"BUILD_URL",
+		"BUILDKITE_BUILD_URL",
+		"CODEBUILD_BUILD_URL",
+		"CI_PIPELINE_URL",
+		// Jenkins
+		"JENKINS_URL",
+		"GIT_URL",
+		"GIT_URL_1",
+		"GIT_COMMIT",
+		"GIT_LOCAL_BRANCH",
+		"BRANCH_NAME",
+		"CHANGE_ID",
+		"CHANGE_BRANCH",
+		"ghprbActualCommit",
+		"ghprbSourceBranch",
+		"ghprbPullId",
+		// Azure Pipelines
+		"TF_BUILD",
+		"BUILD_REPOSITORY_URI",
+		"BUILD_REPOSITORY_NAME",
+		"BUILD_REPOSITORY_PROVIDER",
+		"BUILD_SOURCEVERSION",
+		"BUILD_SOURCEBRANCH",
+		"SYSTEM_PULLREQUEST_SOURCECOMMITID",
+		"SYSTEM_PULLREQUEST_SOURCEBRANCH",
+		"SYSTEM_PULLREQUEST_PULLREQUESTNUMBER",
+		"SYSTEM_PULLREQUEST_PULLREQUESTID",
+		// Buildkite
+		"BUILDKITE",
+		"BUILDKITE_REPO",
+		"BUILDKITE_COMMIT",
+		"BUILDKITE_BRANCH",
+		"BUILDKITE_PULL_REQUEST",
+		// TeamCity
+		"TEAMCITY_VERSION",
+		"TEAMCITY_VCS_URL",
+		"TEAMCITY_BRANCH",
+		"TEAMCITY_PULL_REQUEST_NUMBER",
+		"BUILD_VCS_NUMBER",
+		// AWS CodeBuild
+		"CODEBUILD_BUILD_ID",
+		"CODEBUILD_SOURCE_REPO_URL",
+		"CODEBUILD_RESOLVED_SOURCE_VERSION",
+		"CODEBUILD_SOURCE_VERSION",
+		"CODEBUILD_WEBHOOK_TRIGGER",
+		"CODEBUILD_WEBHOOK_HEAD_REF",
+		// Woodpecker CI
+		"CI",
+		"CI_REPO_CLONE_URL",
+		"CI_REPO_URL",
+		"CI_REPO_LINK",
+		"CI_COMMIT_SOURCE_BRANCH",
+		"CI_COMMIT_BRANCH",
+		"CI_COMMIT_PULL_REQUEST",
+		// CI services detection
+		"TRAVIS",
+		"CIRCLECI",
+		"CIRCLE_REPOSITORY_URL",
+		"DRONE",
+		"DRONE_GIT_HTTP_URL",
+		"GITLAB_CI",
+		"CI_MERGE_REQUEST_IID",
+		"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME",
+		"BITBUCKET_PIPELINE_UUID",
+		"BITBUCKET_REPO_FULL_NAME",
+		"BITBUCKET_COMMIT",
+		"BITBUCKET_BRANCH",
+		"BITBUCKET_PR_ID",
+		"BITBUCKET_PR_DESTINATION_BRANCH",
 	}
 	saveEnvs := make(map[string]string)
 	for _, key := range cleanEnvs {
@@ -300,3 +377,30 @@ func TestGetGerritBuildInfo(t *testing.T) {
 		t.Error("nil expected but got err")
 	}
 }
+
+func TestGetJobURL(t *testing.T) {
+	cleanup := setupEnvs()
+	defer cleanup()
+
+	if got := GetJobURL(); got != "" {
+		t.Errorf("got %q, want empty", got)
+	}
+
+	os.Setenv("CI_JOB_URL", "https://gitlab.com/o/r/-/jobs/1")
+	if got, want := GetJobURL(), "https://gitlab.com/o/r/-/jobs/1"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	os.Setenv("GITHUB_ACTIONS", "true")
+	os.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	os.Setenv("GITHUB_REPOSITORY", "o/r")
+	os.Setenv("GITHUB_RUN_ID", "14")
+	if got, want := GetJobURL(), "https://github.com/o/r/actions/runs/14"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	os.Setenv("REVIEWDOG_TARGET_URL", "https://example.com")
+	if got, want := GetJobURL(), "https://example.com"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,34 @@
+package cienv
+
+import "os"
+
+// circleCIProvider provides BuildInfo from environment variables of CircleCI.
+// https://circleci.com/docs/variables/#built-in-environment-variables
+type circleCIProvider struct {
+	pullRequestProvider
+}
+
+var circleCIPullRequestEnvs = []string{
+	"CIRCLE_PULL_REQUEST", // CircleCI 2.0
+	"CIRCLE_PR_NUMBER",    // For Pull Request by a fork repository
+}
+
+func (*circleCIProvider) Name() string { return "CircleCI" }
+
+func (*circleCIProvider) Detect() (bool, string) { return envEquals("CIRCLECI", "true") }
+
+func (*circleCIProvider) Envs() []string {
+	return joinEnvs([]string{"CIRCLE_PROJECT_USERNAME", "CIRCLE_PROJECT_REPONAME", "CIRCLE_SHA1", "CIRCLE_BRANCH", "CIRCLE_REPOSITORY_URL"},
+		circleCIPullRequestEnvs, overrideEnvs)
+}
+
+func (*circleCIProvider) BuildInfo() (*BuildInfo, error) {
+	info := &BuildInfo{
+		Owner:       os.Getenv("CIRCLE_PROJECT_USERNAME"),
+		Repo:        os.Getenv("CIRCLE_PROJECT_REPONAME"),
+		SHA:         os.Getenv("CIRCLE_SHA1"),
+		Branch:      os.Getenv("CIRCLE_BRANCH"),
+		PullRequest: getPullRequestNumFrom(circleCIPullRequestEnvs...),
+	}
+	return completeBuildInfo("CircleCI", os.Getenv("CIRCLE_REPOSITORY_URL"), info)
+}
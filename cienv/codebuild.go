@@ -0,0 +1,45 @@
+package cienv
+
+import (
+	"os"
+	"strings"
+)
+
+// IsInCodeBuild returns true if reviewdog is running in AWS CodeBuild.
+func IsInCodeBuild() bool {
+	// https://docs.aws.amazon.com/codebuild/latest/userguide/build-env-ref-env-vars.html
+	return os.Getenv("CODEBUILD_BUILD_ID") != ""
+}
+
+// codeBuildProvider provides BuildInfo from environment variables of AWS
+// CodeBuild. CODEBUILD_WEBHOOK_TRIGGER is "pr/<number>" for Pull Request
+// builds triggered by webhooks.
+// https://docs.aws.amazon.com/codebuild/latest/userguide/build-env-ref-env-vars.html
+type codeBuildProvider struct {
+	pullRequestProvider
+}
+
+var codeBuildPullRequestEnvs = []string{"CODEBUILD_WEBHOOK_TRIGGER", "CODEBUILD_SOURCE_VERSION"}
+
+func (*codeBuildProvider) Name() string { return "AWS CodeBuild" }
+
+func (*codeBuildProvider) Detect() (bool, string) { return envIsSet("CODEBUILD_BUILD_ID") }
+
+func (*codeBuildProvider) Envs() []string {
+	return joinEnvs([]string{"CODEBUILD_RESOLVED_SOURCE_VERSION", "CODEBUILD_WEBHOOK_HEAD_REF", "CODEBUILD_SOURCE_REPO_URL"},
+		codeBuildPullRequestEnvs, overrideEnvs)
+}
+
+func (*codeBuildProvider) BuildInfo() (*BuildInfo, error) {
+	info := &BuildInfo{
+		SHA:    os.Getenv("CODEBUILD_RESOLVED_SOURCE_VERSION"),
+		Branch: strings.TrimPrefix(os.Getenv("CODEBUILD_WEBHOOK_HEAD_REF"), "refs/heads/"),
+	}
+	for _, env := range codeBuildPullRequestEnvs {
+		if strings.HasPrefix(os.Getenv(env), "pr/") {
+			info.PullRequest = getPullRequestNumFrom(env)
+			break
+		}
+	}
+	return completeBuildInfo("AWS CodeBuild", os.Getenv("CODEBUILD_SOURCE_REPO_URL"), info)
+}
@@ -0,0 +1,33 @@
+package cienv
+
+import "os"
+
+// droneProvider provides BuildInfo from environment variables of Drone.
+// https://docs.drone.io/pipeline/environment/reference/
+type droneProvider struct {
+	pullRequestProvider
+}
+
+func (*droneProvider) Name() string { return "Drone" }
+
+func (*droneProvider) Detect() (bool, string) { return envEquals("DRONE", "true") }
+
+func (*droneProvider) Envs() []string {
+	return joinEnvs([]string{"DRONE_REPO", "DRONE_REPO_OWNER", "DRONE_REPO_NAME", "DRONE_COMMIT",
+		"DRONE_COMMIT_BRANCH", "DRONE_PULL_REQUEST", "DRONE_GIT_HTTP_URL"}, overrideEnvs)
+}
+
+func (*droneProvider) BuildInfo() (*BuildInfo, error) {
+	info := &BuildInfo{
+		Owner:       os.Getenv("DRONE_REPO_OWNER"),
+		Repo:        os.Getenv("DRONE_REPO_NAME"),
+		SHA:         os.Getenv("DRONE_COMMIT"),
+		Branch:      os.Getenv("DRONE_COMMIT_BRANCH"),
+		PullRequest: getPullRequestNumFrom("DRONE_PULL_REQUEST"),
+	}
+	// drone<=0.4 exports only DRONE_REPO ("owner/repo").
+	if info.Owner == "" || info.Repo == "" {
+		info.Owner, info.Repo = getOwnerAndRepoFromSlug([]string{"DRONE_REPO"})
+	}
+	return completeBuildInfo("Drone", os.Getenv("DRONE_GIT_HTTP_URL"), info)
+}
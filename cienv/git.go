@@ -0,0 +1,72 @@
+package cienv
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// gitProvider provides BuildInfo from the local git repository. It's checked
+// after all CI services and common environment variables so that users can
+// run reviewdog from their local machine without setting environment
+// variables. Pull Request number is not available, so reporters look it up
+// by branch or commit.
+type gitProvider struct {
+	pullRequestProvider
+}
+
+// gitRemote is the remote to get repository owner and name from.
+const gitRemote = "origin"
+
+// gitOutput runs git command and returns its trimmed output. Stubbed in
+// tests.
+var gitOutput = func(args ...string) (string, error) {
+	b, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func (*gitProvider) Name() string { return "git" }
+
+func (*gitProvider) Detect() (bool, string) {
+	_, err := gitOutput("remote", "get-url", gitRemote)
+	return err == nil, fmt.Sprintf("no CI service is detected and git remote %q is found", gitRemote)
+}
+
+func (*gitProvider) Envs() []string { return nil }
+
+func (*gitProvider) BuildInfo() (*BuildInfo, error) {
+	remote, err := gitOutput("remote", "get-url", gitRemote)
+	if err != nil {
+		return nil, err
+	}
+	host, owner, repo, err := parseRemoteURL(remote)
+	if err != nil {
+		return nil, fmt.Errorf("git remote %q: %w", gitRemote, err)
+	}
+	sha, err := gitOutput("rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	info := &BuildInfo{
+		Host:  host,
+		Owner: owner,
+		Repo:  repo,
+		SHA:   sha,
+	}
+	// It's "HEAD" in detached HEAD state.
+	if branch, err := gitOutput("rev-parse", "--abbrev-ref", "HEAD"); err == nil && branch != "HEAD" {
+		info.Branch = branch
+	}
+	return info, nil
+}
+
+// IsLocal returns true if no CI service is detected and BuildInfo is derived
+// from the local git repository.
+func IsLocal() bool {
+	p, _ := DetectProvider()
+	_, ok := p.(*gitProvider)
+	return ok
+}
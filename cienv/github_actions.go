@@ -114,3 +114,31 @@ func HasReadOnlyPermissionGitHubToken() bool {
 	isForkedRepo := event.PullRequest.Head.Repo.Owner.ID != event.PullRequest.Base.Repo.Owner.ID
 	return isForkedRepo && event.ActionName != "pull_request_target"
 }
+
+// gitHubActionsProvider provides BuildInfo from the event payload of GitHub
+// Actions.
+// https://docs.github.com/en/actions/reference/environment-variables#default-environment-variables
+type gitHubActionsProvider struct{}
+
+func (*gitHubActionsProvider) Name() string { return "GitHub Actions" }
+
+func (*gitHubActionsProvider) Detect() (bool, string) { return envIsSet("GITHUB_ACTIONS") }
+
+func (*gitHubActionsProvider) Envs() []string {
+	return []string{"GITHUB_EVENT_PATH", "GITHUB_EVENT_NAME", "GITHUB_REPOSITORY"}
+}
+
+func (*gitHubActionsProvider) BuildInfo() (*BuildInfo, error) {
+	info, _, err := getBuildInfoFromGitHubAction()
+	return info, err
+}
+
+func (*gitHubActionsProvider) IsPR(b *BuildInfo) bool { return b.PullRequest != 0 }
+
+func (*gitHubActionsProvider) ReadOnlyToken() (bool, string) {
+	if !HasReadOnlyPermissionGitHubToken() {
+		return false, ""
+	}
+	return true, "GITHUB_TOKEN is read-only for Pull Requests from forked repositories. " +
+		"Use pull_request_target event or report results with github-check/github-pr-check reporter via logging commands."
+}
@@ -0,0 +1,32 @@
+package cienv
+
+import "os"
+
+// gitLabCIProvider provides BuildInfo from predefined variables of GitLab CI.
+// CI_MERGE_REQUEST_IID is available only in merge request pipelines.
+// https://docs.gitlab.com/ee/ci/variables/predefined_variables.html
+type gitLabCIProvider struct {
+	pullRequestProvider
+}
+
+var gitLabCIBranchEnvs = []string{"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME", "CI_COMMIT_BRANCH"}
+
+func (*gitLabCIProvider) Name() string { return "GitLab CI" }
+
+func (*gitLabCIProvider) Detect() (bool, string) { return envEquals("GITLAB_CI", "true") }
+
+func (*gitLabCIProvider) Envs() []string {
+	return joinEnvs([]string{"CI_PROJECT_NAMESPACE", "CI_PROJECT_NAME", "CI_COMMIT_SHA", "CI_MERGE_REQUEST_IID"},
+		gitLabCIBranchEnvs, overrideEnvs)
+}
+
+func (*gitLabCIProvider) BuildInfo() (*BuildInfo, error) {
+	info := &BuildInfo{
+		Owner:       os.Getenv("CI_PROJECT_NAMESPACE"),
+		Repo:        os.Getenv("CI_PROJECT_NAME"),
+		SHA:         os.Getenv("CI_COMMIT_SHA"),
+		Branch:      getOneEnvValue(gitLabCIBranchEnvs),
+		PullRequest: getPullRequestNumFrom("CI_MERGE_REQUEST_IID"),
+	}
+	return completeBuildInfo("GitLab CI", "", info)
+}
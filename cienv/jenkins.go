@@ -0,0 +1,44 @@
+package cienv
+
+import "os"
+
+// IsInJenkins returns true if reviewdog is running in Jenkins.
+func IsInJenkins() bool {
+	// https://www.jenkins.io/doc/book/pipeline/jenkinsfile/#using-environment-variables
+	return os.Getenv("JENKINS_URL") != ""
+}
+
+// jenkinsProvider provides BuildInfo from environment variables set by
+// Jenkins Git plugin, GitHub Branch Source plugin (multibranch pipelines) or
+// GitHub Pull Request Builder plugin.
+//
+// - https://plugins.jenkins.io/git/#plugin-content-environment-variables
+// - https://www.jenkins.io/doc/book/pipeline/multibranch/#additional-environment-variables
+// - https://plugins.jenkins.io/ghprb/
+type jenkinsProvider struct {
+	pullRequestProvider
+}
+
+var (
+	jenkinsSHAEnvs         = []string{"ghprbActualCommit", "GIT_COMMIT"}
+	jenkinsBranchEnvs      = []string{"ghprbSourceBranch", "CHANGE_BRANCH", "BRANCH_NAME", "GIT_LOCAL_BRANCH"}
+	jenkinsPullRequestEnvs = []string{"ghprbPullId", "CHANGE_ID"}
+	jenkinsRepoURLEnvs     = []string{"GIT_URL", "GIT_URL_1"}
+)
+
+func (*jenkinsProvider) Name() string { return "Jenkins" }
+
+func (*jenkinsProvider) Detect() (bool, string) { return envIsSet("JENKINS_URL") }
+
+func (*jenkinsProvider) Envs() []string {
+	return joinEnvs(jenkinsSHAEnvs, jenkinsBranchEnvs, jenkinsPullRequestEnvs, jenkinsRepoURLEnvs, overrideEnvs)
+}
+
+func (*jenkinsProvider) BuildInfo() (*BuildInfo, error) {
+	info := &BuildInfo{
+		SHA:         getOneEnvValue(jenkinsSHAEnvs),
+		Branch:      getOneEnvValue(jenkinsBranchEnvs),
+		PullRequest: getPullRequestNumFrom(jenkinsPullRequestEnvs...),
+	}
+	return completeBuildInfo("Jenkins", getOneEnvValue(jenkinsRepoURLEnvs), info)
+}
@@ -0,0 +1,172 @@
+package cienv
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Provider provides BuildInfo from environment variables of a CI service.
+type Provider interface {
+	// Name returns the name of the CI service.
+	Name() string
+
+	// Detect returns true if reviewdog is running in the CI service, with
+	// the reason (e.g. "JENKINS_URL is set").
+	Detect() (detected bool, reason string)
+
+	// BuildInfo returns BuildInfo from environment variables.
+	BuildInfo() (*BuildInfo, error)
+
+	// IsPR returns true if the build is for a Pull Request (Merge Request or
+	// Change for GitLab or Gerrit).
+	IsPR(b *BuildInfo) bool
+
+	// ReadOnlyToken returns true with the reason if the API token provided by
+	// the CI service is known to be read-only for this build. e.g. Pull
+	// Requests from forked repositories in GitHub Actions.
+	ReadOnlyToken() (readOnly bool, reason string)
+
+	// Envs returns environment variables which the provider reads. It's used
+	// to explain which variables affect BuildInfo.
+	Envs() []string
+}
+
+var (
+	muProviders sync.Mutex
+	providers   []Provider
+)
+
+// Register registers a Provider. Providers are checked in registration order
+// and the first detected one is used. Built-in providers are registered
+// in init, and fallback providers which read common environment variables
+// (e.g. CI_REPO_OWNER) and the local git repository are always checked last.
+func Register(p Provider) {
+	muProviders.Lock()
+	providers = append(providers, p)
+	muProviders.Unlock()
+	resetDetection()
+}
+
+// Providers returns registered providers in the order they are checked,
+// including the fallback providers at the end.
+func Providers() []Provider {
+	muProviders.Lock()
+	defer muProviders.Unlock()
+	ps := make([]Provider, 0, len(providers)+2)
+	ps = append(ps, providers...)
+	return append(ps, &commonProvider{}, &gitProvider{})
+}
+
+var (
+	muDetection sync.Mutex
+	// detection is the cached result of DetectProvider for detectionKey.
+	detection *struct {
+		key      string
+		provider Provider
+		reason   string
+	}
+)
+
+// DetectProvider returns the first detected Provider with the reason. It
+// returns the provider which reads common environment variables if no
+// provider is detected, so that it reports which variables to set. The result
+// is cached while environment variables and the working directory are the
+// same because detection may run git commands.
+func DetectProvider() (Provider, string) {
+	key := detectionKey()
+	muDetection.Lock()
+	defer muDetection.Unlock()
+	if detection != nil && detection.key == key {
+		return detection.provider, detection.reason
+	}
+	p, reason := detectProvider()
+	detection = &struct {
+		key      string
+		provider Provider
+		reason   string
+	}{key: key, provider: p, reason: reason}
+	return p, reason
+}
+
+func detectProvider() (Provider, string) {
+	for _, p := range Providers() {
+		if ok, reason := p.Detect(); ok {
+			return p, reason
+		}
+	}
+	return &commonProvider{}, "no CI service is detected. Set common environment variables (e.g. CI_REPO_OWNER)"
+}
+
+func detectionKey() string {
+	wd, _ := os.Getwd()
+	return wd + "\x00" + strings.Join(os.Environ(), "\x00")
+}
+
+// resetDetection clears the cached result of DetectProvider.
+func resetDetection() {
+	muDetection.Lock()
+	defer muDetection.Unlock()
+	detection = nil
+}
+
+// GetBuildInfo returns BuildInfo from environment variables of the detected
+// CI service. See Providers for supported CI services.
+func GetBuildInfo() (prInfo *BuildInfo, isPR bool, err error) {
+	p, _ := DetectProvider()
+	b, err := p.BuildInfo()
+	if err != nil {
+		return nil, false, err
+	}
+	return b, p.IsPR(b), nil
+}
+
+// pullRequestProvider implements IsPR and ReadOnlyToken for providers of CI
+// services which tell Pull Request number and don't have read-only tokens.
+type pullRequestProvider struct{}
+
+func (pullRequestProvider) IsPR(b *BuildInfo) bool {
+	return b.PullRequest != 0
+}
+
+func (pullRequestProvider) ReadOnlyToken() (bool, string) {
+	return false, ""
+}
+
+func init() {
+	for _, p := range []Provider{
+		&gitHubActionsProvider{},
+		&jenkinsProvider{},
+		&azurePipelinesProvider{},
+		&buildkiteProvider{},
+		&teamCityProvider{},
+		&codeBuildProvider{},
+		&woodpeckerProvider{},
+		&travisProvider{},
+		&circleCIProvider{},
+		&droneProvider{},
+		&gitLabCIProvider{},
+		&bitbucketPipelinesProvider{},
+	} {
+		Register(p)
+	}
+}
+
+// envIsSet returns Detect result which checks whether env is set.
+func envIsSet(env string) (bool, string) {
+	return os.Getenv(env) != "", fmt.Sprintf("%s is set", env)
+}
+
+// envEquals returns Detect result which checks whether env is the value.
+func envEquals(env, value string) (bool, string) {
+	return os.Getenv(env) == value, fmt.Sprintf("%s=%s", env, value)
+}
+
+func joinEnvs(envs ...[]string) []string {
+	var result []string
+	for _, es := range envs {
+		result = append(result, es...)
+	}
+	return result
+}
@@ -0,0 +1,207 @@
+package cienv
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDetectProvider(t *testing.T) {
+	tests := []struct {
+		envs       map[string]string
+		wantName   string
+		wantReason string
+	}{
+		{
+			envs:       map[string]string{},
+			wantName:   "common",
+			wantReason: "no CI service is detected. Set common environment variables (e.g. CI_REPO_OWNER)",
+		},
+		{
+			envs:       map[string]string{"CI_REPO_OWNER": "reviewdog"},
+			wantName:   "common",
+			wantReason: "CI_REPO_OWNER is set",
+		},
+		{
+			envs:       map[string]string{"JENKINS_URL": "https://jenkins.example.com/"},
+			wantName:   "Jenkins",
+			wantReason: "JENKINS_URL is set",
+		},
+		{
+			envs:       map[string]string{"TRAVIS": "true"},
+			wantName:   "Travis CI",
+			wantReason: "TRAVIS=true",
+		},
+		{
+			// Providers registered earlier take precedence.
+			envs:       map[string]string{"GITHUB_ACTIONS": "true", "TRAVIS": "true"},
+			wantName:   "GitHub Actions",
+			wantReason: "GITHUB_ACTIONS is set",
+		},
+	}
+	defer stubGit(nil)()
+	for _, tt := range tests {
+		t.Run(tt.wantName, func(t *testing.T) {
+			cleanup := setupEnvs()
+			defer cleanup()
+			for k, v := range tt.envs {
+				os.Setenv(k, v)
+			}
+			p, reason := DetectProvider()
+			if p.Name() != tt.wantName {
+				t.Errorf("DetectProvider() = %q, want %q", p.Name(), tt.wantName)
+			}
+			if reason != tt.wantReason {
+				t.Errorf("reason = %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestGetBuildInfo_ignoreOtherProviderEnvs(t *testing.T) {
+	cleanup := setupEnvs()
+	defer cleanup()
+	for k, v := range map[string]string{
+		"JENKINS_URL": "https://jenkins.example.com/",
+		"GIT_URL":     "https://github.com/reviewdog/reviewdog.git",
+		"GIT_COMMIT":  "sha",
+		// Stale environment variables of other CI services must be ignored.
+		"TRAVIS_PULL_REQUEST":     "14",
+		"TRAVIS_PULL_REQUEST_SHA": "travis-sha",
+	} {
+		os.Setenv(k, v)
+	}
+	got, isPR, err := GetBuildInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &BuildInfo{Owner: "reviewdog", Repo: "reviewdog", SHA: "sha"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("GetBuildInfo() diff: (-got +want)\n%s", diff)
+	}
+	if isPR {
+		t.Error("isPR = true, want false")
+	}
+}
+
+func TestGetBuildInfo_gitlabCI(t *testing.T) {
+	cleanup := setupEnvs()
+	defer cleanup()
+	for k, v := range map[string]string{
+		"GITLAB_CI":                           "true",
+		"CI_PROJECT_NAMESPACE":                "group/subgroup",
+		"CI_PROJECT_NAME":                     "reviewdog",
+		"CI_COMMIT_SHA":                       "sha",
+		"CI_MERGE_REQUEST_IID":                "14",
+		"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "feature",
+	} {
+		os.Setenv(k, v)
+	}
+	got, isPR, err := GetBuildInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &BuildInfo{Owner: "group/subgroup", Repo: "reviewdog", SHA: "sha", PullRequest: 14, Branch: "feature"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("GetBuildInfo() diff: (-got +want)\n%s", diff)
+	}
+	if !isPR {
+		t.Error("isPR = false, want true")
+	}
+}
+
+// stubGit stubs git command with outputs keyed by its arguments. Commands
+// not in outputs fail.
+// The cached result of DetectProvider is cleared as it depends on git.
+func stubGit(outputs map[string]string) (restore func()) {
+	orig := gitOutput
+	gitOutput = func(args ...string) (string, error) {
+		if out, ok := outputs[strings.Join(args, " ")]; ok {
+			return out, nil
+		}
+		return "", errors.New("not a git repository")
+	}
+	resetDetection()
+	return func() {
+		gitOutput = orig
+		resetDetection()
+	}
+}
+
+func TestGetBuildInfo_git(t *testing.T) {
+	cleanup := setupEnvs()
+	defer cleanup()
+
+	tests := []struct {
+		name    string
+		outputs map[string]string
+		want    *BuildInfo
+	}{
+		{
+			name: "branch",
+			outputs: map[string]string{
+				"remote get-url origin":       "git@github.com:reviewdog/reviewdog.git",
+				"rev-parse HEAD":              "sha",
+				"rev-parse --abbrev-ref HEAD": "feature",
+			},
+			want: &BuildInfo{Host: "github.com", Owner: "reviewdog", Repo: "reviewdog", SHA: "sha", Branch: "feature"},
+		},
+		{
+			name: "detached HEAD",
+			outputs: map[string]string{
+				"remote get-url origin":       "https://gitlab.example.com/group/subgroup/repo.git",
+				"rev-parse HEAD":              "sha",
+				"rev-parse --abbrev-ref HEAD": "HEAD",
+			},
+			want: &BuildInfo{Host: "gitlab.example.com", Owner: "group/subgroup", Repo: "repo", SHA: "sha"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer stubGit(tt.outputs)()
+			if !IsLocal() {
+				t.Error("IsLocal() = false, want true")
+			}
+			got, isPR, err := GetBuildInfo()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("GetBuildInfo() diff: (-got +want)\n%s", diff)
+			}
+			if isPR {
+				t.Error("isPR = true, want false")
+			}
+		})
+	}
+}
+
+func TestIsLocal_cached(t *testing.T) {
+	cleanup := setupEnvs()
+	defer cleanup()
+	defer stubGit(map[string]string{"remote get-url origin": "git@github.com:reviewdog/reviewdog.git"})()
+	orig := gitOutput
+	runs := 0
+	gitOutput = func(args ...string) (string, error) {
+		runs++
+		return orig(args...)
+	}
+
+	for i := 0; i < 3; i++ {
+		if !IsLocal() {
+			t.Fatal("IsLocal() = false, want true")
+		}
+	}
+	if runs != 1 {
+		t.Errorf("git ran %d times, want 1", runs)
+	}
+
+	// Detection runs again when environment variables are changed.
+	os.Setenv("JENKINS_URL", "https://jenkins.example.com/")
+	if IsLocal() {
+		t.Error("IsLocal() = true in Jenkins, want false")
+	}
+}
@@ -0,0 +1,111 @@
+package cienv
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// parseRepoURL returns repository owner and name from a git remote URL.
+//
+// Supported formats:
+// - https://github.com/owner/repo(.git)
+// - git@github.com:owner/repo(.git)
+// - ssh://git@github.com:22/owner/repo(.git)
+// - https://gitlab.com/group/subgroup/repo (owner is "group/subgroup")
+// - https://dev.azure.com/org/project/_git/repo (owner is "org/project")
+func parseRepoURL(remote string) (owner, repo string, err error) {
+	_, owner, repo, err = parseRemoteURL(remote)
+	return owner, repo, err
+}
+
+// parseRemoteURL returns host (without port), repository owner and name from
+// a git remote URL. See parseRepoURL for supported formats.
+func parseRemoteURL(remote string) (host, owner, repo string, err error) {
+	host, path, err := splitRepoURL(remote)
+	if err != nil {
+		return "", "", "", err
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if i := strings.Index(path, "/_git/"); i >= 0 {
+		owner, repo = path[:i], path[i+len("/_git/"):]
+	} else if i := strings.LastIndex(path, "/"); i >= 0 {
+		owner, repo = path[:i], path[i+1:]
+	}
+	if owner == "" || repo == "" {
+		return "", "", "", fmt.Errorf("cannot get repo owner and name from %q", remote)
+	}
+	return host, owner, repo, nil
+}
+
+func splitRepoURL(remote string) (host, path string, err error) {
+	if remote == "" {
+		return "", "", fmt.Errorf("remote URL is empty")
+	}
+	if !strings.Contains(remote, "://") {
+		// SCP-like syntax. e.g. git@github.com:owner/repo.git
+		i := strings.Index(remote, ":")
+		if i < 0 {
+			return "", "", fmt.Errorf("invalid remote URL: %q", remote)
+		}
+		host = remote[:i]
+		if j := strings.LastIndex(host, "@"); j >= 0 {
+			host = host[j+1:]
+		}
+		return host, remote[i+1:], nil
+	}
+	u, err := url.Parse(remote)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid remote URL: %q: %w", remote, err)
+	}
+	return u.Hostname(), u.Path, nil
+}
+
+// overrideEnvs are common environment variables which take precedence over
+// CI service specific ones in completeBuildInfo.
+var overrideEnvs = []string{
+	"CI_REPO_OWNER",
+	"CI_REPO_NAME",
+	"CI_COMMIT",
+	"CI_BRANCH",
+	"CI_PULL_REQUEST",
+}
+
+// completeBuildInfo completes the given BuildInfo built from environment
+// variables of a CI service. Common environment variables (overrideEnvs)
+// take precedence over CI service specific ones, and repo owner and name are
+// derived from repoURL if they are still missing.
+func completeBuildInfo(service, repoURL string, info *BuildInfo) (*BuildInfo, error) {
+	if v := os.Getenv("CI_REPO_OWNER"); v != "" {
+		info.Owner = v
+	}
+	if v := os.Getenv("CI_REPO_NAME"); v != "" {
+		info.Repo = v
+	}
+	if v := os.Getenv("CI_COMMIT"); v != "" {
+		info.SHA = v
+	}
+	if v := os.Getenv("CI_BRANCH"); v != "" {
+		info.Branch = v
+	}
+	if pr := getPullRequestNumFrom("CI_PULL_REQUEST"); pr != 0 {
+		info.PullRequest = pr
+	}
+	if info.Owner == "" || info.Repo == "" {
+		owner, repo, err := parseRepoURL(repoURL)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w. Set CI_REPO_OWNER and CI_REPO_NAME?", service, err)
+		}
+		if info.Owner == "" {
+			info.Owner = owner
+		}
+		if info.Repo == "" {
+			info.Repo = repo
+		}
+	}
+	if info.SHA == "" {
+		return nil, fmt.Errorf("%s: cannot get commit SHA from environment variable. Set CI_COMMIT?", service)
+	}
+	return info, nil
+}
@@ -0,0 +1,43 @@
+package cienv
+
+import (
+	"os"
+	"strings"
+)
+
+// IsInTeamCity returns true if reviewdog is running in TeamCity.
+func IsInTeamCity() bool {
+	// https://www.jetbrains.com/help/teamcity/predefined-build-parameters.html#Server+Build+Properties
+	return os.Getenv("TEAMCITY_VERSION") != ""
+}
+
+// teamCityProvider provides BuildInfo from environment variables of
+// TeamCity. TeamCity exports only BUILD_VCS_NUMBER by default, so other
+// parameters need to be exported as environment variables in the build
+// configuration.
+//
+//	env.TEAMCITY_VCS_URL=%vcsroot.url%
+//	env.TEAMCITY_BRANCH=%teamcity.build.branch%
+//	env.TEAMCITY_PULL_REQUEST_NUMBER=%teamcity.pullRequest.number%
+//
+// https://www.jetbrains.com/help/teamcity/predefined-build-parameters.html
+type teamCityProvider struct {
+	pullRequestProvider
+}
+
+func (*teamCityProvider) Name() string { return "TeamCity" }
+
+func (*teamCityProvider) Detect() (bool, string) { return envIsSet("TEAMCITY_VERSION") }
+
+func (*teamCityProvider) Envs() []string {
+	return joinEnvs([]string{"BUILD_VCS_NUMBER", "TEAMCITY_BRANCH", "TEAMCITY_PULL_REQUEST_NUMBER", "TEAMCITY_VCS_URL"}, overrideEnvs)
+}
+
+func (*teamCityProvider) BuildInfo() (*BuildInfo, error) {
+	info := &BuildInfo{
+		SHA:         os.Getenv("BUILD_VCS_NUMBER"),
+		Branch:      strings.TrimPrefix(os.Getenv("TEAMCITY_BRANCH"), "refs/heads/"),
+		PullRequest: getPullRequestNumFrom("TEAMCITY_PULL_REQUEST_NUMBER"),
+	}
+	return completeBuildInfo("TeamCity", os.Getenv("TEAMCITY_VCS_URL"), info)
+}
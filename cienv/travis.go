@@ -0,0 +1,32 @@
+package cienv
+
+import "os"
+
+// travisProvider provides BuildInfo from environment variables of Travis CI.
+// TRAVIS_PULL_REQUEST is "false" for non Pull Request builds.
+// https://docs.travis-ci.com/user/environment-variables/
+type travisProvider struct {
+	pullRequestProvider
+}
+
+var travisSHAEnvs = []string{"TRAVIS_PULL_REQUEST_SHA", "TRAVIS_COMMIT"}
+
+func (*travisProvider) Name() string { return "Travis CI" }
+
+func (*travisProvider) Detect() (bool, string) { return envEquals("TRAVIS", "true") }
+
+func (*travisProvider) Envs() []string {
+	return joinEnvs([]string{"TRAVIS_REPO_SLUG", "TRAVIS_PULL_REQUEST_BRANCH", "TRAVIS_PULL_REQUEST"}, travisSHAEnvs, overrideEnvs)
+}
+
+func (*travisProvider) BuildInfo() (*BuildInfo, error) {
+	owner, repo := getOwnerAndRepoFromSlug([]string{"TRAVIS_REPO_SLUG"})
+	info := &BuildInfo{
+		Owner:       owner,
+		Repo:        repo,
+		SHA:         getOneEnvValue(travisSHAEnvs),
+		Branch:      os.Getenv("TRAVIS_PULL_REQUEST_BRANCH"),
+		PullRequest: getPullRequestNumFrom("TRAVIS_PULL_REQUEST"),
+	}
+	return completeBuildInfo("Travis CI", "", info)
+}
@@ -0,0 +1,38 @@
+package cienv
+
+import "os"
+
+// IsInWoodpecker returns true if reviewdog is running in Woodpecker CI.
+func IsInWoodpecker() bool {
+	// https://woodpecker-ci.org/docs/usage/environment#built-in-environment-variables
+	return os.Getenv("CI") == "woodpecker"
+}
+
+// woodpeckerProvider provides BuildInfo from environment variables of
+// Woodpecker CI.
+// https://woodpecker-ci.org/docs/usage/environment#built-in-environment-variables
+type woodpeckerProvider struct {
+	pullRequestProvider
+}
+
+var (
+	woodpeckerBranchEnvs  = []string{"CI_COMMIT_SOURCE_BRANCH", "CI_COMMIT_BRANCH"}
+	woodpeckerRepoURLEnvs = []string{"CI_REPO_CLONE_URL", "CI_REPO_URL", "CI_REPO_LINK"}
+)
+
+func (*woodpeckerProvider) Name() string { return "Woodpecker CI" }
+
+func (*woodpeckerProvider) Detect() (bool, string) { return envEquals("CI", "woodpecker") }
+
+func (*woodpeckerProvider) Envs() []string {
+	return joinEnvs([]string{"CI_COMMIT_SHA", "CI_COMMIT_PULL_REQUEST"}, woodpeckerBranchEnvs, woodpeckerRepoURLEnvs, overrideEnvs)
+}
+
+func (*woodpeckerProvider) BuildInfo() (*BuildInfo, error) {
+	info := &BuildInfo{
+		SHA:         os.Getenv("CI_COMMIT_SHA"),
+		Branch:      getOneEnvValue(woodpeckerBranchEnvs),
+		PullRequest: getPullRequestNumFrom("CI_COMMIT_PULL_REQUEST"),
+	}
+	return completeBuildInfo("Woodpecker CI", getOneEnvValue(woodpeckerRepoURLEnvs), info)
+}
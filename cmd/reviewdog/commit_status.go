@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/cienv"
+	"github.com/reviewdog/reviewdog/filter"
+	"github.com/reviewdog/reviewdog/service/commitstatus"
+	githubservice "github.com/reviewdog/reviewdog/service/github"
+	gitlabservice "github.com/reviewdog/reviewdog/service/gitlab"
+)
+
+func runGitHubCommitStatus(ctx context.Context, r io.Reader, w io.Writer, opt *option, isProject bool) error {
+	token, err := nonEmptyEnv("REVIEWDOG_GITHUB_API_TOKEN")
+	if err != nil {
+		return err
+	}
+	build, isPR, err := cienv.GetBuildInfo()
+	if err != nil {
+		return err
+	}
+	cli, err := githubClient(ctx, token, build.Host)
+	if err != nil {
+		return err
+	}
+	var ds reviewdog.DiffService
+	if isPR {
+		ds, err = githubservice.NewGitHubPullRequest(cli, build.Owner, build.Repo, build.PullRequest, build.SHA)
+		if err != nil {
+			return err
+		}
+	}
+	s := githubservice.NewGitHubCommitStatus(cli, build.Owner, build.Repo, build.SHA)
+	return runCommitStatus(ctx, r, w, opt, isProject, s, ds)
+}
+
+func runGitLabCommitStatus(ctx context.Context, r io.Reader, w io.Writer, opt *option, isProject bool) error {
+	build, cli, err := gitlabBuildWithClient()
+	if err != nil {
+		return err
+	}
+	var ds reviewdog.DiffService
+	if build.PullRequest != 0 {
+		ds, err = gitlabservice.NewGitLabMergeRequestDiff(cli, build.Owner, build.Repo, build.PullRequest, build.SHA)
+		if err != nil {
+			return err
+		}
+	}
+	s := gitlabservice.NewGitLabCommitStatus(cli, build.Owner, build.Repo, build.SHA)
+	return runCommitStatus(ctx, r, w, opt, isProject, s, ds)
+}
+
+// runCommitStatus sets a commit status per runner based on the level and the
+// number of results. It filters results with the given Pull Request diff if
+// ds is not nil, otherwise with -diff. It doesn't filter results if neither
+// of them is available.
+func runCommitStatus(ctx context.Context, r io.Reader, w io.Writer, opt *option, isProject bool,
+	s commitstatus.Setter, ds reviewdog.DiffService) error {
+	resultSet, err := checkResultSet(ctx, r, opt, isProject)
+	if err != nil {
+		return err
+	}
+	if ds == nil {
+		if opt.diffCmd == "" && opt.diffBase == "" {
+			if opt.filterMode != filter.ModeBlame {
+				opt.filterMode = filter.ModeNoFilter
+			}
+			ds = &reviewdog.EmptyDiff{}
+		} else {
+			ds, err = localDiffService(opt)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if opt.streamDiff {
+		ds = reviewdog.NewStreamingDiff(ds)
+	}
+	filediffs, err := reviewdog.FileDiffs(ctx, ds)
+	if err != nil {
+		return err
+	}
+	filterOpts, err := filterOptions(ctx, opt)
+	if err != nil {
+		return err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	targetURL := cienv.GetJobURL()
+	inDiff := opt.filterMode != filter.ModeNoFilter
+
+	var muWriter sync.Mutex
+	var g errgroup.Group
+	resultSet.Range(func(name string, result *reviewdog.Result) {
+		g.Go(func() error {
+			if err := result.CheckUnexpectedFailure(); err != nil {
+				return err
+			}
+			checks := filter.FilterCheck(result.Diagnostics, filediffs, ds.Strip(), wd, opt.filterMode, filterOpts...)
+			count := 0
+			muWriter.Lock()
+			for _, check := range checks {
+				if !check.ShouldReport {
+					continue
+				}
+				count++
+				// Output original lines.
+				fmt.Fprintln(w, check.Diagnostic.GetOriginalOutput())
+			}
+			muWriter.Unlock()
+			status := commitstatus.New(name, result.Level, count, inDiff, targetURL)
+			if err := s.SetStatus(ctx, status); err != nil {
+				return err
+			}
+			if opt.failOnError && status.State == commitstatus.Failure {
+				return fmt.Errorf("[%s] commit status is %q", name, status.State)
+			}
+			return nil
+		})
+	})
+	return g.Wait()
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/filter"
+	"github.com/reviewdog/reviewdog/project"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+	"github.com/reviewdog/reviewdog/service/commitstatus"
+)
+
+type fakeStatusSetter struct {
+	mu       sync.Mutex
+	statuses []*commitstatus.Status
+}
+
+func (f *fakeStatusSetter) SetStatus(_ context.Context, s *commitstatus.Status) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statuses = append(f.statuses, s)
+	return nil
+}
+
+type fakeDiffService struct {
+	diff []byte
+}
+
+func (f *fakeDiffService) Diff(context.Context) ([]byte, error) { return f.diff, nil }
+func (f *fakeDiffService) Strip() int                           { return 1 }
+
+func TestRunCommitStatus(t *testing.T) {
+	defer func(f func(ctx context.Context, conf *project.Config, runners map[string]bool, level string, tee bool) (*reviewdog.ResultMap, error)) {
+		projectRunAndParse = f
+	}(projectRunAndParse)
+
+	diagnostic := func(path string, line int32) *rdf.Diagnostic {
+		return &rdf.Diagnostic{
+			Location:       &rdf.Location{Path: path, Range: &rdf.Range{Start: &rdf.Position{Line: line}}},
+			Message:        "msg",
+			OriginalOutput: path,
+		}
+	}
+	var results reviewdog.ResultMap
+	results.Store("errors", &reviewdog.Result{Level: "error", Diagnostics: []*rdf.Diagnostic{
+		diagnostic("a.go", 1),
+		diagnostic("a.go", 2),
+		diagnostic("b.go", 1), // outside diff
+	}})
+	results.Store("warnings", &reviewdog.Result{Level: "warning", Diagnostics: []*rdf.Diagnostic{
+		diagnostic("a.go", 1),
+	}})
+	results.Store("clean", &reviewdog.Result{Level: "error"})
+	projectRunAndParse = func(ctx context.Context, conf *project.Config, runners map[string]bool, level string, tee bool) (*reviewdog.ResultMap, error) {
+		return &results, nil
+	}
+
+	tmp, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	ds := &fakeDiffService{diff: []byte(`diff --git a/cmd/reviewdog/a.go b/cmd/reviewdog/a.go
+--- a/cmd/reviewdog/a.go
++++ b/cmd/reviewdog/a.go
+@@ -0,0 +1,2 @@
++line1
++line2
+`)}
+	tests := []struct {
+		failOnError bool
+		wantErr     bool
+	}{
+		{failOnError: false, wantErr: false},
+		{failOnError: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		s := &fakeStatusSetter{}
+		opt := &option{conf: tmp.Name(), filterMode: filter.ModeAdded, failOnError: tt.failOnError}
+		buf := new(bytes.Buffer)
+		err := runCommitStatus(context.Background(), nil, buf, opt, true, s, ds)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("runCommitStatus() error = %v, wantErr %v", err, tt.wantErr)
+		}
+		sort.Slice(s.statuses, func(i, j int) bool { return s.statuses[i].Name < s.statuses[j].Name })
+		want := []*commitstatus.Status{
+			{Name: "clean", State: commitstatus.Success, Description: "No results in diff"},
+			{Name: "errors", State: commitstatus.Failure, Description: "2 errors in diff"},
+			{Name: "warnings", State: commitstatus.Neutral, Description: "1 warning in diff"},
+		}
+		if diff := cmp.Diff(s.statuses, want); diff != "" {
+			t.Errorf("statuses has diff:\n%s", diff)
+		}
+		if got, want := buf.String(), "a.go\na.go\na.go\n"; got != want {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+	}
+}
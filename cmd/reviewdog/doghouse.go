@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"os"
 	"sort"
 
@@ -37,7 +36,19 @@ func runDoghouse(ctx context.Context, r io.Reader, w io.Writer, opt *option, isP
 	if err != nil {
 		return err
 	}
-	cli, err := newDoghouseCli(ctx)
+	if opt.filterMode == filter.ModeBlame || opt.minSeverity != "" {
+		// The doghouse server doesn't know git blame and severity threshold, so
+		// filter results here.
+		if resultSet, err = prefilterResultSet(ctx, resultSet, opt); err != nil {
+			return err
+		}
+		if opt.filterMode == filter.ModeBlame {
+			o := *opt
+			o.filterMode = filter.ModeNoFilter
+			opt = &o
+		}
+	}
+	cli, err := newDoghouseCli(ctx, ghInfo.Host)
 	if err != nil {
 		return err
 	}
@@ -51,7 +62,7 @@ func runDoghouse(ctx context.Context, r io.Reader, w io.Writer, opt *option, isP
 	return nil
 }
 
-func newDoghouseCli(ctx context.Context) (client.DogHouseClientInterface, error) {
+func newDoghouseCli(ctx context.Context, host string) (client.DogHouseClientInterface, error) {
 	// If skipDoghouseServer is true, run doghouse code directly instead of talking to
 	// the doghouse server because provided GitHub API Token has Check API scope.
 	// You can force skipping the doghouse server if you are generating your own application API token.
@@ -61,7 +72,7 @@ func newDoghouseCli(ctx context.Context) (client.DogHouseClientInterface, error)
 		if err != nil {
 			return nil, err
 		}
-		ghcli, err := githubClient(ctx, token)
+		ghcli, err := githubClient(ctx, token, host)
 		if err != nil {
 			return nil, err
 		}
@@ -71,18 +82,49 @@ func newDoghouseCli(ctx context.Context) (client.DogHouseClientInterface, error)
 }
 
 func newDoghouseServerCli(ctx context.Context) *client.DogHouseClient {
-	httpCli := http.DefaultClient
+	httpCli := newHTTPClient()
 	if token := os.Getenv("REVIEWDOG_TOKEN"); token != "" {
 		ts := oauth2.StaticTokenSource(
 			&oauth2.Token{AccessToken: token},
 		)
-		httpCli = oauth2.NewClient(ctx, ts)
+		httpCli = oauth2.NewClient(context.WithValue(ctx, oauth2.HTTPClient, httpCli), ts)
 	}
 	return client.New(httpCli)
 }
 
 var projectRunAndParse = project.RunAndParse
 
+// prefilterResultSet returns results which should be reported by
+// filter.ModeBlame and -min-severity. Results are not filtered by diff unless
+// -filter-mode=blame because the doghouse server filters them.
+func prefilterResultSet(ctx context.Context, resultSet *reviewdog.ResultMap, opt *option) (*reviewdog.ResultMap, error) {
+	filterOpts, err := filterOptions(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	mode := filter.ModeNoFilter
+	if opt.filterMode == filter.ModeBlame {
+		mode = filter.ModeBlame
+	}
+	filtered := new(reviewdog.ResultMap)
+	resultSet.Range(func(name string, result *reviewdog.Result) {
+		var diagnostics []*rdf.Diagnostic
+		for _, check := range filter.FilterCheck(result.Diagnostics, nil, 0, wd, mode, filterOpts...) {
+			if check.ShouldReport {
+				diagnostics = append(diagnostics, check.Diagnostic)
+			}
+		}
+		r := *result
+		r.Diagnostics = diagnostics
+		filtered.Store(name, &r)
+	})
+	return filtered, nil
+}
+
 func checkResultSet(ctx context.Context, r io.Reader, opt *option, isProject bool) (*reviewdog.ResultMap, error) {
 	resultSet := new(reviewdog.ResultMap)
 	if isProject {
@@ -95,7 +137,11 @@ func checkResultSet(ctx context.Context, r io.Reader, opt *option, isProject boo
 			return nil, err
 		}
 	} else {
-		p, err := newParserFromOpt(opt)
+		conf, err := localConfig(opt)
+		if err != nil {
+			return nil, err
+		}
+		p, err := newParserFromOpt(opt, conf)
 		if err != nil {
 			return nil, err
 		}
@@ -47,7 +47,7 @@ func TestNewDoghouseCli_returnGitHubClient(t *testing.T) {
 		"REVIEWDOG_GITHUB_API_TOKEN": "xxx",
 	})
 	defer cleanup()
-	cli, err := newDoghouseCli(context.Background())
+	cli, err := newDoghouseCli(context.Background(), "")
 	if err != nil {
 		t.Fatalf("failed to create new client: %v", err)
 	}
@@ -63,7 +63,7 @@ func TestNewDoghouseCli_returnErrorForGitHubClient(t *testing.T) {
 		"REVIEWDOG_GITHUB_API_TOKEN": "", // missing
 	})
 	defer cleanup()
-	if _, err := newDoghouseCli(context.Background()); err == nil {
+	if _, err := newDoghouseCli(context.Background(), ""); err == nil {
 		t.Error("got no error but want REVIEWDOG_GITHUB_API_TOKEN missing error")
 	}
 }
@@ -75,7 +75,7 @@ func TestNewDoghouseCli_returnDogHouseClientWithReviewdogToken(t *testing.T) {
 		"REVIEWDOG_GITHUB_API_TOKEN": "xxx",
 	})
 	defer cleanup()
-	cli, err := newDoghouseCli(context.Background())
+	cli, err := newDoghouseCli(context.Background(), "")
 	if err != nil {
 		t.Fatalf("failed to create new client: %v", err)
 	}
@@ -91,7 +91,7 @@ func TestNewDoghouseCli_returnDogHouseClient(t *testing.T) {
 		"REVIEWDOG_GITHUB_API_TOKEN": "",
 	})
 	defer cleanup()
-	cli, err := newDoghouseCli(context.Background())
+	cli, err := newDoghouseCli(context.Background(), "")
 	if err != nil {
 		t.Fatalf("failed to create new client: %v", err)
 	}
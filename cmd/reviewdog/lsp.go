@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/commands"
+	"github.com/reviewdog/reviewdog/filter"
+	"github.com/reviewdog/reviewdog/lsp"
+	"github.com/reviewdog/reviewdog/project"
+)
+
+// runLSP runs reviewdog as a language server which communicates over r and
+// w. It runs runners of the config file on save and filters results by diff
+// from the merge-base of -diff-base ("auto" by default) like the local
+// reporter.
+func runLSP(ctx context.Context, r io.Reader, w io.Writer, opt *option) error {
+	if len(opt.efms) != 0 || opt.f != "" {
+		return errors.New("lsp runs runners of the config file and doesn't support -f and -efm")
+	}
+	o := withAutoDiffBase(opt)
+	s := lsp.NewServer(r, w, &lsp.Option{
+		LoadConfig: func() (*project.Config, error) {
+			return projectConfig(o.conf)
+		},
+		Runners: buildRunnersMap(o.runners),
+		NewDiffService: func() (reviewdog.DiffService, error) {
+			return newLocalDiffService(o)
+		},
+		FilterMode: o.filterMode,
+		FilterOptions: func(ctx context.Context) ([]filter.Option, error) {
+			return filterOptions(ctx, o)
+		},
+		Version: commands.Version,
+	})
+	return s.Serve(ctx)
+}
@@ -14,8 +14,11 @@ import (
 	"os"
 	"os/exec"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"text/tabwriter"
+	"time"
 
 	"golang.org/x/build/gerrit"
 	"golang.org/x/oauth2"
@@ -32,22 +35,32 @@ import (
 	"github.com/reviewdog/reviewdog/filter"
 	"github.com/reviewdog/reviewdog/parser"
 	"github.com/reviewdog/reviewdog/project"
+	"github.com/reviewdog/reviewdog/proto/rdf"
 	bbservice "github.com/reviewdog/reviewdog/service/bitbucket"
+	"github.com/reviewdog/reviewdog/service/commentutil"
 	gerritservice "github.com/reviewdog/reviewdog/service/gerrit"
 	githubservice "github.com/reviewdog/reviewdog/service/github"
 	"github.com/reviewdog/reviewdog/service/github/githubutils"
 	gitlabservice "github.com/reviewdog/reviewdog/service/gitlab"
+	"github.com/reviewdog/reviewdog/service/serviceutil"
+	webhookservice "github.com/reviewdog/reviewdog/service/webhook"
 )
 
 const usageMessage = "" +
 	`Usage:	reviewdog [flags]
 	reviewdog accepts any compiler or linter results from stdin and filters
 	them by diff for review. reviewdog also can posts the results as a comment to
-	GitHub if you use reviewdog in CI service.`
+	GitHub if you use reviewdog in CI service.
+
+	reviewdog lsp [flags]
+	reviewdog runs as a language server on stdin and stdout. It runs runners of
+	the config file on save and publishes the results filtered by diff from the
+	merge-base of -diff-base ("auto" by default) as diagnostics.`
 
 type option struct {
 	version          bool
 	diffCmd          string
+	diffBase         string
 	diffStrip        int
 	efms             strslice
 	f                string // format name
@@ -62,10 +75,23 @@ type option struct {
 	tee              bool
 	filterMode       filter.Mode
 	failOnError      bool
+	summaryComment   bool
+	printBuildInfo   bool
+	streamDiff       bool
+	blameBase        string
+	blameAuthor      string
+	blameSince       string
+	minSeverity      string
+	severityRoute    string
+	maxComments      int
+	maxCommentsFile  int
+	maxPerRule       int
+	watch            bool
 }
 
 const (
 	diffCmdDoc    = `diff command (e.g. "git diff") for local reporter. Do not use --relative flag for git command.`
+	diffBaseDoc   = `git ref (e.g. origin/main) to diff from for local reporter. It runs "git diff --find-renames" from the merge-base of the ref and HEAD to the working tree, and deepens shallow clones to find the merge-base. "auto" picks the upstream branch or the default branch of origin. It cannot be used with -diff`
 	diffStripDoc  = "strip NUM leading components from diff file names (equivalent to 'patch -p') (default is 1 for git diff)"
 	efmsDoc       = `list of supported machine-readable format and errorformat (https://github.com/reviewdog/errorformat)`
 	fDoc          = `format name (run -list to see supported format name) for input. It's also used as tool name in review comment if -name is empty`
@@ -76,9 +102,9 @@ const (
 	confDoc             = `config file path`
 	runnersDoc          = `comma separated runners name to run in config file. default: run all runners`
 	levelDoc            = `report level currently used for github-pr-check reporter ("info","warning","error").`
-	guessPullRequestDoc = `guess Pull Request ID by branch name and commit SHA`
+	guessPullRequestDoc = `guess Pull Request ID by branch name and commit SHA. It's enabled by default when no CI service is detected and build info is derived from the local git repository`
 	teeDoc              = `enable "tee"-like mode which outputs tools's output as is while reporting results to -reporter. Useful for debugging as well.`
-	filterModeDoc       = `how to filter checks results. [added, diff_context, file, nofilter].
+	filterModeDoc       = `how to filter checks results. [added, diff_context, file, nofilter, hunk, function, blame].
 		"added" (default)
 			Filter by added/modified diff lines.
 		"diff_context"
@@ -88,8 +114,19 @@ const (
 			Filter by added/modified file.
 		"nofilter"
 			Do not filter any results.
+		"hunk"
+			Filter by the new line range of diff hunks.
+		"function"
+			Filter by functions which contain changed lines, e.g. to report
+			findings at function headers of complexity linters. Functions are
+			detected by files in the working directory. Changed lines outside
+			functions fall back to "hunk".
+		"blame"
+			Filter by commits which last changed lines (git blame) regardless
+			of diff. Set conditions of commits with -blame-base, -blame-author
+			and -blame-since.
 `
-	reporterDoc = `reporter of reviewdog results. (local, github-check, github-pr-check, github-pr-review, gitlab-mr-discussion, gitlab-mr-commit)
+	reporterDoc = `reporter of reviewdog results. (local, github-check, github-pr-check, github-pr-review, gitlab-mr-discussion, gitlab-mr-commit, github-commit-status, gitlab-commit-status, webhook)
 	"local" (default)
 		Report results to stdout.
 
@@ -142,6 +179,24 @@ const (
 		Same as gitlab-mr-discussion, but report results to GitLab comments for
 		each commits in Merge Requests.
 
+	"github-commit-status"
+		Set a commit status per runner (context: reviewdog/<runner name>).
+		The state is "failure" if results are found with "error" level,
+		otherwise "success". The description tells the number of results
+		(e.g. "3 errors in diff"). It's useful when Check API is not available.
+		It also reports results to stdout.
+
+		Set REVIEWDOG_GITHUB_API_TOKEN with repo:status scope.
+
+		It filters results by the Pull Request diff if it's a Pull Request build,
+		otherwise by -diff if given. It doesn't filter results if neither of them
+		is available. The target URL is the CI job URL, which can be overridden
+		by REVIEWDOG_TARGET_URL.
+
+	"gitlab-commit-status"
+		Same as github-commit-status, but set GitLab commit statuses.
+		Set REVIEWDOG_GITLAB_API_TOKEN environment variable.
+
 	"gerrit-change-review"
 		Report results to Gerrit Change comments.
 
@@ -154,6 +209,14 @@ const (
 			$ export GERRIT_REVISION_ID=ed318bf9a3c
 			$ export GERRIT_BRANCH=master
 			$ export GERRIT_ADDRESS=http://localhost:8080
+
+		Results are posted as robot comments with fix suggestions. Set
+		GERRIT_ROBOT_RUN_ID to identify the run (default: GERRIT_REVISION_ID).
+
+		Optionally, set GERRIT_VOTE_LABEL to vote on the label. reviewdog votes
+		GERRIT_VOTE_FAIL (default: -1) when errors exist, otherwise
+		GERRIT_VOTE_PASS (default: 0).
+			$ export GERRIT_VOTE_LABEL=Verified
 	
 	"bitbucket-code-report"
 		Create Bitbucket Code Report via Code Insights
@@ -168,12 +231,26 @@ const (
 		- For AccessToken Auth you need to set BITBUCKET_ACCESS_TOKEN 
 		Running on Bitbucket Server is not tested/supported yet.
 
+	"webhook"
+		POST results as a JSON document to REVIEWDOG_WEBHOOK_URL. The document
+		contains build info (if available), counts by severity and diagnostics
+		in rdjson format per tool. It also reports results to stdout.
+
+		Optional environment variables:
+			REVIEWDOG_WEBHOOK_SECRET: sign the body with HMAC-SHA256 and set
+				X-Reviewdog-Signature-256: sha256=<hex digest>
+			REVIEWDOG_WEBHOOK_HEADERS: newline separated "Key: Value" headers
+			REVIEWDOG_WEBHOOK_RETRIES: max number of retries (default: 3)
+
+		It uses -diff to filter results if given, otherwise it doesn't filter results.
+
 	For GitHub Enterprise and self hosted GitLab, set
 	REVIEWDOG_INSECURE_SKIP_VERIFY to skip verifying SSL (please use this at your own risk)
 		$ export REVIEWDOG_INSECURE_SKIP_VERIFY=true
 
 	For non-local reporters, reviewdog automatically get necessary data from
-	environment variable in CI service (GitHub Actions, Travis CI, Circle CI, drone.io, GitLab CI, Bitbucket Pipelines).
+	environment variable in CI service (GitHub Actions, Travis CI, Circle CI, drone.io, GitLab CI, Bitbucket Pipelines,
+	Jenkins, Azure Pipelines, Buildkite, TeamCity, AWS CodeBuild, Woodpecker CI).
 	You can set necessary data with following environment variable manually if
 	you want (e.g. run reviewdog in Jenkins).
 
@@ -182,7 +259,31 @@ const (
 		$ export CI_REPO_OWNER="haya14busa" # repository owner
 		$ export CI_REPO_NAME="reviewdog" # repository name
 `
-	failOnErrorDoc = `Returns 1 as exit code if any errors/warnings found in input`
+	failOnErrorDoc    = `Returns 1 as exit code if any errors/warnings found in input`
+	summaryCommentDoc = `maintain a summary comment per tool on the Pull Request / Merge Request conversation which is edited in place on each run.
+	It shows counts by severity, results outside diff, and results which are not posted as inline comments.
+	Supported reporters: github-pr-review, gitlab-mr-discussion, gitlab-mr-commit`
+	printBuildInfoDoc = `print which CI service is detected and why, environment variables used to detect build info (owner, repo, commit, branch, Pull Request), and the detected build info`
+	streamDiffDoc     = `parse diff as a stream and keep only line numbers of changes to reduce memory usage for huge diff (e.g. generated files). Source lines of results are read from files in the working directory instead of diff`
+	blameBaseDoc      = `option for -filter-mode=blame: report lines last changed by commits since the merge-base of the git ref (e.g. origin/main) and HEAD. "auto" picks the upstream branch or the default branch of origin`
+	blameAuthorDoc    = `option for -filter-mode=blame: report lines last changed by commits of the author name or email (e.g. the Pull Request author)`
+	blameSinceDoc     = `option for -filter-mode=blame: report lines last changed by commits authored at or after the date (e.g. 2021-06-26, 2021-06-26T15:04:05Z) or in the duration (e.g. 30d, 72h)`
+	minSeverityDoc    = `report only results at or above the severity ("error", "warning", "info"). Results without severity are always reported. Results below it are not counted by -fail-on-error either`
+	severityRouteDoc  = `comma separated routes of results by severity in the form of <severity>:<destination> (e.g. "warning:summary,info:local").
+	Destinations:
+		"reporter"
+			Report with -reporter (default).
+		"summary"
+			Report only in the summary comment. It requires -summary-comment and
+			is supported by github-pr-review, gitlab-mr-discussion and
+			gitlab-mr-commit reporters.
+		"local"
+			Only print to the local log (stdout) instead of -reporter.
+	Not supported by github-check, github-pr-check, github-commit-status and gitlab-commit-status reporters`
+	maxCommentsDoc     = `max number of comments in a run. Extra comments are collapsed into an overflow summary of the reporter (e.g. a review body, a Merge Request note). 0 means no limit`
+	maxCommentsFileDoc = `max number of comments per file. 0 means no limit. See -max-comments`
+	maxPerRuleDoc      = `max number of comments per rule (i.e. tool name and diagnostic code). Results without code are not limited. 0 means no limit. See -max-comments`
+	watchDoc           = `watch the working tree and re-run runners of the config file whose "when_changed" globs match changed files. The output of local reporter is refreshed on each run with results filtered by diff from the merge-base of -diff-base ("auto" by default)`
 )
 
 var opt = &option{}
@@ -190,11 +291,13 @@ var opt = &option{}
 func init() {
 	flag.BoolVar(&opt.version, "version", false, "print version")
 	flag.StringVar(&opt.diffCmd, "diff", "", diffCmdDoc)
+	flag.StringVar(&opt.diffBase, "diff-base", "", diffBaseDoc)
 	flag.IntVar(&opt.diffStrip, "strip", 1, diffStripDoc)
 	flag.Var(&opt.efms, "efm", efmsDoc)
 	flag.StringVar(&opt.f, "f", "", fDoc)
 	flag.IntVar(&opt.fDiffStrip, "f.diff.strip", 1, fDiffStripDoc)
 	flag.BoolVar(&opt.list, "list", false, listDoc)
+	flag.BoolVar(&opt.printBuildInfo, "print-build-info", false, printBuildInfoDoc)
 	flag.StringVar(&opt.name, "name", "", nameDoc)
 	flag.StringVar(&opt.conf, "conf", "", confDoc)
 	flag.StringVar(&opt.runners, "runners", "", runnersDoc)
@@ -204,6 +307,17 @@ func init() {
 	flag.BoolVar(&opt.tee, "tee", false, teeDoc)
 	flag.Var(&opt.filterMode, "filter-mode", filterModeDoc)
 	flag.BoolVar(&opt.failOnError, "fail-on-error", false, failOnErrorDoc)
+	flag.BoolVar(&opt.summaryComment, "summary-comment", false, summaryCommentDoc)
+	flag.BoolVar(&opt.streamDiff, "stream-diff", false, streamDiffDoc)
+	flag.StringVar(&opt.blameBase, "blame-base", "", blameBaseDoc)
+	flag.StringVar(&opt.blameAuthor, "blame-author", "", blameAuthorDoc)
+	flag.StringVar(&opt.blameSince, "blame-since", "", blameSinceDoc)
+	flag.StringVar(&opt.minSeverity, "min-severity", "", minSeverityDoc)
+	flag.StringVar(&opt.severityRoute, "severity-route", "", severityRouteDoc)
+	flag.IntVar(&opt.maxComments, "max-comments", 0, maxCommentsDoc)
+	flag.IntVar(&opt.maxCommentsFile, "max-comments-per-file", 0, maxCommentsFileDoc)
+	flag.IntVar(&opt.maxPerRule, "max-per-rule", 0, maxPerRuleDoc)
+	flag.BoolVar(&opt.watch, "watch", false, watchDoc)
 }
 
 func usage() {
@@ -218,7 +332,15 @@ func usage() {
 func main() {
 	flag.Usage = usage
 	flag.Parse()
-	if err := run(os.Stdin, os.Stdout, opt); err != nil {
+	var err error
+	if flag.Arg(0) == "lsp" {
+		// Parse flags after the subcommand.
+		_ = flag.CommandLine.Parse(flag.Args()[1:])
+		err = runLSP(context.Background(), os.Stdin, os.Stdout, opt)
+	} else {
+		err = run(os.Stdin, os.Stdout, opt)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "reviewdog: %v\n", err)
 		os.Exit(1)
 	}
@@ -236,6 +358,14 @@ func run(r io.Reader, w io.Writer, opt *option) error {
 		return runList(w)
 	}
 
+	if opt.printBuildInfo {
+		return runPrintBuildInfo(w)
+	}
+
+	if opt.watch {
+		return runWatch(ctx, w, opt)
+	}
+
 	if opt.tee {
 		r = io.TeeReader(r, w)
 	}
@@ -256,8 +386,41 @@ func run(r io.Reader, w io.Writer, opt *option) error {
 
 		cs = reviewdog.NewUnifiedCommentWriter(w)
 	} else {
+		var err error
+		projectConf, err = localConfig(opt)
+		if err != nil {
+			return err
+		}
 		cs = reviewdog.NewRawCommentWriter(w)
 	}
+	localCS := cs
+
+	tmpl, err := commentTemplate(opt, projectConf)
+	if err != nil {
+		return err
+	}
+
+	routes, err := parseSeverityRoutes(opt.severityRoute)
+	if err != nil {
+		return err
+	}
+	limits := reviewdog.CommentLimits{
+		MaxComments:        opt.maxComments,
+		MaxCommentsPerFile: opt.maxCommentsFile,
+		MaxPerRule:         opt.maxPerRule,
+	}
+	switch opt.reporter {
+	case "github-check", "github-pr-check", "github-commit-status", "gitlab-commit-status":
+		if len(routes) > 0 {
+			return fmt.Errorf("-severity-route is not supported by -reporter=%s", opt.reporter)
+		}
+		if limits != (reviewdog.CommentLimits{}) {
+			return fmt.Errorf("-max-comments, -max-comments-per-file and -max-per-rule are not supported by -reporter=%s", opt.reporter)
+		}
+	}
+	// limited is true if the reporter applies the limits by itself after
+	// filtering out comments which it doesn't post.
+	limited := false
 
 	switch opt.reporter {
 	default:
@@ -266,6 +429,10 @@ func run(r io.Reader, w io.Writer, opt *option) error {
 		return runDoghouse(ctx, r, w, opt, isProject, false)
 	case "github-pr-check":
 		return runDoghouse(ctx, r, w, opt, isProject, true)
+	case "github-commit-status":
+		return runGitHubCommitStatus(ctx, r, w, opt, isProject)
+	case "gitlab-commit-status":
+		return runGitLabCommitStatus(ctx, r, w, opt, isProject)
 	case "github-pr-review":
 		gs, isPR, err := githubService(ctx, opt)
 		if err != nil {
@@ -286,8 +453,16 @@ so reviewdog will report results via logging command [2] and create annotations
 github-pr-check reporter as a fallback.
 [1]: https://docs.github.com/en/actions/reference/events-that-trigger-workflows#pull_request_target, 
 [2]: https://help.github.com/en/actions/automating-your-workflow-with-github-actions/development-tools-for-github-actions#logging-commands`)
-			cs = githubutils.NewGitHubActionLogWriter(opt.level)
+			lw := githubutils.NewGitHubActionLogWriter(opt.level)
+			lw.SetTemplate(tmpl)
+			cs = lw
 		} else {
+			if opt.summaryComment {
+				gs.EnableSummary(summaryTools(opt, projectConf))
+			}
+			gs.SetTemplate(tmpl)
+			gs.SetCommentLimits(limits)
+			limited = true
 			cs = reviewdog.MultiCommentService(gs, cs)
 		}
 		ds = gs
@@ -305,6 +480,12 @@ github-pr-check reporter as a fallback.
 		if err != nil {
 			return err
 		}
+		if opt.summaryComment {
+			gc.EnableSummary(summaryTools(opt, projectConf))
+		}
+		gc.SetTemplate(tmpl)
+		gc.SetCommentLimits(limits)
+		limited = true
 
 		cs = reviewdog.MultiCommentService(gc, cs)
 		ds, err = gitlabservice.NewGitLabMergeRequestDiff(cli, build.Owner, build.Repo, build.PullRequest, build.SHA)
@@ -325,6 +506,12 @@ github-pr-check reporter as a fallback.
 		if err != nil {
 			return err
 		}
+		if opt.summaryComment {
+			gc.EnableSummary(summaryTools(opt, projectConf))
+		}
+		gc.SetTemplate(tmpl)
+		gc.SetCommentLimits(limits)
+		limited = true
 
 		cs = reviewdog.MultiCommentService(gc, cs)
 		ds, err = gitlabservice.NewGitLabMergeRequestDiff(cli, build.Owner, build.Repo, build.PullRequest, build.SHA)
@@ -332,14 +519,22 @@ github-pr-check reporter as a fallback.
 			return err
 		}
 	case "gerrit-change-review":
-		b, cli, err := gerritBuildWithClient()
+		b, cli, reviewCli, err := gerritBuildWithClient()
+		if err != nil {
+			return err
+		}
+		vote, err := gerritLabelVote()
 		if err != nil {
 			return err
 		}
-		gc, err := gerritservice.NewChangeReviewCommenter(cli, b.GerritChangeID, b.GerritRevisionID)
+		gc, err := gerritservice.NewChangeReviewCommenter(reviewCli, b.GerritChangeID, b.GerritRevisionID,
+			os.Getenv("GERRIT_ROBOT_RUN_ID"), vote)
 		if err != nil {
 			return err
 		}
+		gc.SetTemplate(tmpl)
+		gc.SetCommentLimits(limits)
+		limited = true
 		cs = gc
 
 		d, err := gerritservice.NewChangeDiff(cli, b.Branch, b.GerritChangeID)
@@ -354,10 +549,12 @@ github-pr-check reporter as a fallback.
 		}
 		ctx = ct
 
-		cs = bbservice.NewReportAnnotator(client,
+		annotator := bbservice.NewReportAnnotator(client,
 			build.Owner, build.Repo, build.SHA, getRunnersList(opt, projectConf))
+		annotator.SetTemplate(tmpl)
+		cs = annotator
 
-		if !(opt.filterMode == filter.ModeDefault || opt.filterMode == filter.ModeNoFilter) {
+		if !(opt.filterMode == filter.ModeDefault || opt.filterMode == filter.ModeNoFilter || opt.filterMode == filter.ModeBlame) {
 			// by default scan whole project with out diff (filter.ModeNoFilter)
 			// Bitbucket pipelines doesn't give an easy way to know
 			// which commit run pipeline before so we can compare between them
@@ -365,15 +562,35 @@ github-pr-check reporter as a fallback.
 			// filtering of annotations dividing them in two groups:
 			// - This pull request (10)
 			// - All (50)
-			log.Printf("reviewdog: [bitbucket-code-report] supports only with filter.ModeNoFilter for now")
+			log.Printf("reviewdog: [bitbucket-code-report] supports only with filter.ModeNoFilter and filter.ModeBlame for now")
+		}
+		if opt.filterMode != filter.ModeBlame {
+			opt.filterMode = filter.ModeNoFilter
 		}
-		opt.filterMode = filter.ModeNoFilter
 		ds = &reviewdog.EmptyDiff{}
+	case "webhook":
+		wh, err := webhookReporter(getRunnersList(opt, projectConf))
+		if err != nil {
+			return err
+		}
+		cs = reviewdog.MultiCommentService(wh, cs)
+		if opt.diffCmd == "" && opt.diffBase == "" {
+			if opt.filterMode != filter.ModeBlame {
+				opt.filterMode = filter.ModeNoFilter
+			}
+			ds = &reviewdog.EmptyDiff{}
+		} else {
+			d, err := localDiffService(opt)
+			if err != nil {
+				return err
+			}
+			ds = d
+		}
 	case "local":
-		if opt.diffCmd == "" && opt.filterMode == filter.ModeNoFilter {
+		if opt.diffCmd == "" && opt.diffBase == "" && (opt.filterMode == filter.ModeNoFilter || opt.filterMode == filter.ModeBlame) {
 			ds = &reviewdog.EmptyDiff{}
 		} else {
-			d, err := diffService(opt.diffCmd, opt.diffStrip)
+			d, err := localDiffService(opt)
 			if err != nil {
 				return err
 			}
@@ -381,16 +598,34 @@ github-pr-check reporter as a fallback.
 		}
 	}
 
+	if len(routes) > 0 {
+		if cs, err = routeBySeverity(cs, localCS, routes, opt); err != nil {
+			return err
+		}
+	}
+	if limits != (reviewdog.CommentLimits{}) && !limited {
+		cs = reviewdog.LimitComments(cs, limits)
+	}
+
+	if opt.streamDiff {
+		ds = reviewdog.NewStreamingDiff(ds)
+	}
+
+	filterOpts, err := filterOptions(ctx, opt)
+	if err != nil {
+		return err
+	}
+
 	if isProject {
-		return project.Run(ctx, projectConf, buildRunnersMap(opt.runners), cs, ds, opt.tee, opt.filterMode, opt.failOnError)
+		return project.Run(ctx, projectConf, buildRunnersMap(opt.runners), cs, ds, opt.tee, opt.filterMode, opt.failOnError, filterOpts...)
 	}
 
-	p, err := newParserFromOpt(opt)
+	p, err := newParserFromOpt(opt, projectConf)
 	if err != nil {
 		return err
 	}
 
-	app := reviewdog.NewReviewdog(toolName(opt), p, cs, ds, opt.filterMode, opt.failOnError)
+	app := reviewdog.NewReviewdog(toolName(opt), p, cs, ds, opt.filterMode, opt.failOnError, filterOpts...)
 	return app.Run(ctx, r)
 }
 
@@ -400,6 +635,7 @@ func runList(w io.Writer) error {
 	fmt.Fprintf(tabw, "%s\t%s\t- %s\n", "rdjsonl", "Reviewdog Diagnostic JSONL Format (JSONL of Diagnostic message)", "https://github.com/reviewdog/reviewdog")
 	fmt.Fprintf(tabw, "%s\t%s\t- %s\n", "diff", "Unified Diff Format", "https://en.wikipedia.org/wiki/Diff#Unified_format")
 	fmt.Fprintf(tabw, "%s\t%s\t- %s\n", "checkstyle", "checkstyle XML format", "http://checkstyle.sourceforge.net/")
+	fmt.Fprintf(tabw, "%s\t%s\t- %s\n", "lsp", "JSON of Language Server Protocol diagnostics (Diagnostic[] or PublishDiagnosticsParams)", "https://microsoft.github.io/language-server-protocol/")
 	for _, f := range sortedFmts(fmts.DefinedFmts()) {
 		fmt.Fprintf(tabw, "%s\t%s\t- %s\n", f.Name, f.Description, f.URL)
 	}
@@ -421,6 +657,157 @@ func sortedFmts(fs fmts.Fmts) []*fmts.Fmt {
 	return r
 }
 
+// localDiffService returns a DiffService for local diff given by -diff or
+// -diff-base flag.
+func localDiffService(opt *option) (reviewdog.DiffService, error) {
+	if opt.diffBase == "" {
+		return diffService(opt.diffCmd, opt.diffStrip)
+	}
+	if opt.diffCmd != "" {
+		return nil, errors.New("-diff and -diff-base cannot be used together")
+	}
+	return serviceutil.NewGitDiffBase(opt.diffBase), nil
+}
+
+// withAutoDiffBase returns a copy of opt whose -diff-base is "auto" if
+// neither -diff nor -diff-base is given and -filter-mode uses diff. It's used
+// by long-running modes (lsp and -watch) which filter results by local diff.
+func withAutoDiffBase(opt *option) *option {
+	o := *opt
+	noDiff := o.filterMode == filter.ModeNoFilter || o.filterMode == filter.ModeBlame
+	if o.diffCmd == "" && o.diffBase == "" && !noDiff {
+		o.diffBase = serviceutil.DiffBaseAuto
+	}
+	return &o
+}
+
+// newLocalDiffService returns localDiffService(opt), or EmptyDiff if
+// neither -diff nor -diff-base is given. It must be called on each run
+// because the diff changes as files are edited.
+func newLocalDiffService(opt *option) (reviewdog.DiffService, error) {
+	if opt.diffCmd == "" && opt.diffBase == "" {
+		return &reviewdog.EmptyDiff{}, nil
+	}
+	return localDiffService(opt)
+}
+
+// filterOptions returns options of filter for opt. It creates a Blamer for
+// -filter-mode=blame.
+func filterOptions(ctx context.Context, opt *option) ([]filter.Option, error) {
+	var opts []filter.Option
+	if opt.minSeverity != "" {
+		s, err := parseSeverity(opt.minSeverity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -min-severity: %w", err)
+		}
+		opts = append(opts, filter.WithMinSeverity(s))
+	}
+	if opt.filterMode != filter.ModeBlame {
+		return opts, nil
+	}
+	if opt.blameBase == "" && opt.blameAuthor == "" && opt.blameSince == "" {
+		return nil, errors.New("-filter-mode=blame requires -blame-base, -blame-author or -blame-since")
+	}
+	var since time.Time
+	if opt.blameSince != "" {
+		var err error
+		if since, err = parseBlameSince(opt.blameSince, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	b, err := serviceutil.NewGitBlamer(ctx, opt.blameBase, opt.blameAuthor, since)
+	if err != nil {
+		return nil, err
+	}
+	return append(opts, filter.WithBlamer(b)), nil
+}
+
+// parseSeverity parses severity names of -min-severity and -severity-route.
+func parseSeverity(s string) (rdf.Severity, error) {
+	switch strings.ToLower(s) {
+	case "error":
+		return rdf.Severity_ERROR, nil
+	case "warning":
+		return rdf.Severity_WARNING, nil
+	case "info":
+		return rdf.Severity_INFO, nil
+	}
+	return rdf.Severity_UNKNOWN_SEVERITY, fmt.Errorf("unknown severity: %q", s)
+}
+
+// parseSeverityRoutes parses -severity-route flag (e.g.
+// "warning:summary,info:local") and returns destinations by severity.
+func parseSeverityRoutes(s string) (map[rdf.Severity]string, error) {
+	routes := make(map[rdf.Severity]string)
+	if s == "" {
+		return routes, nil
+	}
+	for _, route := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(route), ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid -severity-route: %q", route)
+		}
+		severity, err := parseSeverity(kv[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid -severity-route: %w", err)
+		}
+		switch dest := kv[1]; dest {
+		case "reporter", "summary", "local":
+			routes[severity] = dest
+		default:
+			return nil, fmt.Errorf("invalid -severity-route: unknown destination: %q", dest)
+		}
+	}
+	return routes, nil
+}
+
+// routeBySeverity returns a comment service which posts comments to the
+// destinations of -severity-route. cs is the comment service of -reporter
+// and local is the one which prints results to stdout.
+func routeBySeverity(cs, local reviewdog.CommentService, routes map[rdf.Severity]string, opt *option) (reviewdog.CommentService, error) {
+	services := make(map[rdf.Severity]reviewdog.CommentService, len(routes))
+	for severity, dest := range routes {
+		switch dest {
+		case "reporter":
+			services[severity] = cs
+		case "local":
+			services[severity] = local
+		case "summary":
+			switch opt.reporter {
+			case "github-pr-review", "gitlab-mr-discussion", "gitlab-mr-commit":
+			default:
+				return nil, fmt.Errorf("-severity-route destination summary is not supported by -reporter=%s", opt.reporter)
+			}
+			if !opt.summaryComment {
+				return nil, errors.New("-severity-route destination summary requires -summary-comment")
+			}
+			services[severity] = reviewdog.SummaryOnlyCommentService(cs)
+		}
+	}
+	return reviewdog.RouteBySeverity(cs, services), nil
+}
+
+// parseBlameSince parses -blame-since flag. It's a date (2006-01-02), a time
+// in RFC3339 or a duration before now in days (e.g. 30d) or time.Duration
+// format (e.g. 72h).
+func parseBlameSince(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if strings.HasSuffix(s, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(s, "d")); err == nil && days >= 0 {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid -blame-since: %q", s)
+}
+
 func diffService(s string, strip int) (reviewdog.DiffService, error) {
 	cmds, err := shellwords.Parse(s)
 	if err != nil {
@@ -434,12 +821,34 @@ func diffService(s string, strip int) (reviewdog.DiffService, error) {
 	return d, nil
 }
 
+var (
+	sharedTransportOnce sync.Once
+	sharedTransport     *serviceutil.Transport
+)
+
+// newHTTPClient returns a new HTTP client for reporters. All clients share
+// one rate-limit-aware transport, which retries requests on rate limit and
+// server errors and caps the number of in-flight requests across reporters.
 func newHTTPClient() *http.Client {
-	tr := &http.Transport{
-		Proxy:           http.ProxyFromEnvironment,
+	sharedTransportOnce.Do(func() {
+		sharedTransport = serviceutil.NewTransport(newBaseTransport(http.ProxyFromEnvironment), serviceutil.DefaultMaxConcurrency)
+	})
+	return &http.Client{Transport: sharedTransport}
+}
+
+// newHTTPClientWithProxy is same as newHTTPClient but it sends requests
+// through the given proxy (e.g. the proxy of Bitbucket Pipelines). It still
+// shares the cap of in-flight requests with other clients.
+func newHTTPClientWithProxy(proxy func(*http.Request) (*url.URL, error)) *http.Client {
+	newHTTPClient()
+	return &http.Client{Transport: sharedTransport.WithBase(newBaseTransport(proxy))}
+}
+
+func newBaseTransport(proxy func(*http.Request) (*url.URL, error)) *http.Transport {
+	return &http.Transport{
+		Proxy:           proxy,
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: insecureSkipVerify()},
 	}
-	return &http.Client{Transport: tr}
 }
 
 func insecureSkipVerify() bool {
@@ -456,13 +865,15 @@ func githubService(ctx context.Context, opt *option) (gs *githubservice.PullRequ
 		return nil, isPR, err
 	}
 
-	client, err := githubClient(ctx, token)
+	client, err := githubClient(ctx, token, g.Host)
 	if err != nil {
 		return nil, isPR, err
 	}
 
 	if !isPR {
-		if !opt.guessPullRequest {
+		// Guess Pull Request when running locally as there is no way to get
+		// Pull Request number from the local git repository.
+		if !opt.guessPullRequest && !cienv.IsLocal() {
 			return nil, false, nil
 		}
 
@@ -516,7 +927,10 @@ func getPullRequestIDByBranchOrCommit(ctx context.Context, client *github.Client
 	return *pullRequests.Issues[0].Number, nil
 }
 
-func githubClient(ctx context.Context, token string) (*github.Client, error) {
+// githubClient returns a GitHub API client. host is the host of the git
+// remote (BuildInfo.Host) and it's used for GitHub Enterprise Server if it's
+// given.
+func githubClient(ctx context.Context, token, host string) (*github.Client, error) {
 	ctx = context.WithValue(ctx, oauth2.HTTPClient, newHTTPClient())
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
@@ -524,13 +938,16 @@ func githubClient(ctx context.Context, token string) (*github.Client, error) {
 	tc := oauth2.NewClient(ctx, ts)
 	client := github.NewClient(tc)
 	var err error
-	client.BaseURL, err = githubBaseURL()
+	client.BaseURL, err = githubBaseURL(host)
 	return client, err
 }
 
 const defaultGitHubAPI = "https://api.github.com/"
 
-func githubBaseURL() (*url.URL, error) {
+// githubBaseURL returns the base URL of GitHub API. GITHUB_API and
+// GITHUB_API_URL take precedence over the API of GitHub Enterprise Server on
+// host (e.g. the host of the local git remote).
+func githubBaseURL(host string) (*url.URL, error) {
 	if baseURL := os.Getenv("GITHUB_API"); baseURL != "" {
 		u, err := url.Parse(baseURL)
 		if err != nil {
@@ -547,6 +964,14 @@ func githubBaseURL() (*url.URL, error) {
 		}
 		return u, nil
 	}
+	if host != "" && host != "github.com" {
+		baseURL := "https://" + host + "/api/v3/"
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("GitHub base URL from git remote is invalid: %v, %w", baseURL, err)
+		}
+		return u, nil
+	}
 	u, err := url.Parse(defaultGitHubAPI)
 	if err != nil {
 		return nil, fmt.Errorf("GitHub base URL from reviewdog default is invalid: %v, %w", defaultGitHubAPI, err)
@@ -565,7 +990,7 @@ func gitlabBuildWithClient() (*cienv.BuildInfo, *gitlab.Client, error) {
 		return nil, nil, err
 	}
 
-	client, err := gitlabClient(token)
+	client, err := gitlabClient(token, g.Host)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -583,31 +1008,105 @@ func gitlabBuildWithClient() (*cienv.BuildInfo, *gitlab.Client, error) {
 	return g, client, err
 }
 
-func gerritBuildWithClient() (*cienv.BuildInfo, *gerrit.Client, error) {
+func gerritBuildWithClient() (*cienv.BuildInfo, *gerrit.Client, *gerritservice.Client, error) {
 	buildInfo, err := cienv.GetGerritBuildInfo()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	gerritAddr := os.Getenv("GERRIT_ADDRESS")
 	if gerritAddr == "" {
-		return nil, nil, errors.New("cannot get gerrit host address from environment variable. Set GERRIT_ADDRESS ?")
+		return nil, nil, nil, errors.New("cannot get gerrit host address from environment variable. Set GERRIT_ADDRESS ?")
 	}
 
+	var client *gerrit.Client
+	var reviewClient *gerritservice.Client
 	username := os.Getenv("GERRIT_USERNAME")
 	password := os.Getenv("GERRIT_PASSWORD")
-	if username != "" && password != "" {
-		client := gerrit.NewClient(gerritAddr, gerrit.BasicAuth(username, password))
-		return buildInfo, client, nil
+	useGitCookiePath := os.Getenv("GERRIT_GIT_COOKIE_PATH")
+	switch {
+	case username != "" && password != "":
+		client = gerrit.NewClient(gerritAddr, gerrit.BasicAuth(username, password))
+		reviewClient = gerritservice.NewClient(gerritAddr, gerritservice.BasicAuth(username, password))
+	case useGitCookiePath != "":
+		client = gerrit.NewClient(gerritAddr, gerrit.GitCookieFileAuth(useGitCookiePath))
+		reviewClient = gerritservice.NewClient(gerritAddr, gerritservice.GitCookieFileAuth(useGitCookiePath))
+	default:
+		client = gerrit.NewClient(gerritAddr, gerrit.NoAuth)
+		reviewClient = gerritservice.NewClient(gerritAddr, gerritservice.NoAuth)
 	}
+	client.HTTPClient = newHTTPClient()
+	reviewClient.HTTPClient = newHTTPClient()
+	return buildInfo, client, reviewClient, nil
+}
 
-	if useGitCookiePath := os.Getenv("GERRIT_GIT_COOKIE_PATH"); useGitCookiePath != "" {
-		client := gerrit.NewClient(gerritAddr, gerrit.GitCookieFileAuth(useGitCookiePath))
-		return buildInfo, client, nil
+// gerritLabelVote returns label vote config from environment variables.
+// It returns nil if GERRIT_VOTE_LABEL is not set.
+func gerritLabelVote() (*gerritservice.LabelVote, error) {
+	label := os.Getenv("GERRIT_VOTE_LABEL")
+	if label == "" {
+		return nil, nil
+	}
+	vote := &gerritservice.LabelVote{Label: label, Fail: -1, Pass: 0}
+	if v := os.Getenv("GERRIT_VOTE_FAIL"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("GERRIT_VOTE_FAIL is invalid: %w", err)
+		}
+		vote.Fail = n
 	}
+	if v := os.Getenv("GERRIT_VOTE_PASS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("GERRIT_VOTE_PASS is invalid: %w", err)
+		}
+		vote.Pass = n
+	}
+	return vote, nil
+}
 
-	client := gerrit.NewClient(gerritAddr, gerrit.NoAuth)
-	return buildInfo, client, nil
+// webhookReporter returns webhook reporter configured by environment
+// variables. tools are names of tools of the run.
+func webhookReporter(tools []string) (*webhookservice.Reporter, error) {
+	u := os.Getenv("REVIEWDOG_WEBHOOK_URL")
+	if u == "" {
+		return nil, errors.New("REVIEWDOG_WEBHOOK_URL is not set")
+	}
+	opt := &webhookservice.Option{
+		Secret:  os.Getenv("REVIEWDOG_WEBHOOK_SECRET"),
+		Header:  make(http.Header),
+		Retries: 3,
+		Tools:   tools,
+	}
+	for _, line := range strings.Split(os.Getenv("REVIEWDOG_WEBHOOK_HEADERS"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("REVIEWDOG_WEBHOOK_HEADERS is invalid: %q", line)
+		}
+		opt.Header.Add(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+	}
+	if v := os.Getenv("REVIEWDOG_WEBHOOK_RETRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("REVIEWDOG_WEBHOOK_RETRIES is invalid: %w", err)
+		}
+		opt.Retries = n
+	}
+	// Build info is optional. e.g. it's not available when running locally.
+	build, _, err := cienv.GetBuildInfo()
+	if err != nil {
+		build = nil
+	}
+	// Use a client without the retrying transport of newHTTPClient as the
+	// reporter retries requests by itself with REVIEWDOG_WEBHOOK_RETRIES.
+	cli := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: insecureSkipVerify()},
+	}}
+	return webhookservice.NewReporter(cli, u, build, opt), nil
 }
 
 func bitbucketBuildWithClient(ctx context.Context) (*cienv.BuildInfo, *bitbucket.APIClient, context.Context, error) {
@@ -628,7 +1127,12 @@ func bitbucketBuildWithClient(ctx context.Context) (*cienv.BuildInfo, *bitbucket
 		ctx = bbservice.WithAccessToken(ctx, bbAccessToken)
 	}
 
-	client := bbservice.NewAPIClient(cienv.IsInBitbucketPipeline(), cienv.IsInBitbucketPipe())
+	httpClient := newHTTPClient()
+	inPipeline := cienv.IsInBitbucketPipeline()
+	if inPipeline {
+		httpClient = newHTTPClientWithProxy(bbservice.PipelineProxy(cienv.IsInBitbucketPipe()))
+	}
+	client := bbservice.NewAPIClient(httpClient, inPipeline)
 	return build, client, ctx, nil
 }
 
@@ -650,12 +1154,17 @@ func fetchMergeRequestIDFromCommit(cli *gitlab.Client, projectID, sha string) (i
 	return 0, nil
 }
 
-func gitlabClient(token string) (*gitlab.Client, error) {
-	baseURL, err := gitlabBaseURL()
+// gitlabClient returns a GitLab API client. host is the host of the git
+// remote (BuildInfo.Host) and it's used for self-hosted GitLab if it's given.
+func gitlabClient(token, host string) (*gitlab.Client, error) {
+	baseURL, err := gitlabBaseURL(host)
 	if err != nil {
 		return nil, err
 	}
-	client, err := gitlab.NewClient(token, gitlab.WithHTTPClient(newHTTPClient()), gitlab.WithBaseURL(baseURL.String()))
+	// Disable retries of go-gitlab as the transport of newHTTPClient handles
+	// them.
+	client, err := gitlab.NewClient(token, gitlab.WithHTTPClient(newHTTPClient()), gitlab.WithBaseURL(baseURL.String()),
+		gitlab.WithoutRetries())
 	if err != nil {
 		return nil, err
 	}
@@ -664,7 +1173,10 @@ func gitlabClient(token string) (*gitlab.Client, error) {
 
 const defaultGitLabAPI = "https://gitlab.com/api/v4"
 
-func gitlabBaseURL() (*url.URL, error) {
+// gitlabBaseURL returns the base URL of GitLab API. GITLAB_API and
+// CI_API_V4_URL take precedence over the API of self-hosted GitLab on host
+// (e.g. the host of the local git remote).
+func gitlabBaseURL(host string) (*url.URL, error) {
 	gitlabAPI := os.Getenv("GITLAB_API")
 	gitlabV4URL := os.Getenv("CI_API_V4_URL")
 
@@ -673,6 +1185,8 @@ func gitlabBaseURL() (*url.URL, error) {
 		baseURL = gitlabAPI
 	} else if gitlabV4URL != "" {
 		baseURL = gitlabV4URL
+	} else if host != "" && host != "gitlab.com" {
+		baseURL = "https://" + host + "/api/v4"
 	} else {
 		baseURL = defaultGitLabAPI
 	}
@@ -703,6 +1217,48 @@ func (ss *strslice) Set(value string) error {
 	return nil
 }
 
+// templateReporters is a set of reporters which support comment templates.
+var templateReporters = map[string]bool{
+	"github-pr-review":      true,
+	"gitlab-mr-discussion":  true,
+	"gitlab-mr-commit":      true,
+	"gerrit-change-review":  true,
+	"bitbucket-code-report": true,
+}
+
+// commentTemplate returns the template of comment bodies for -reporter in
+// the config. conf is nil if there is no config. It returns an error if the
+// config has templates for -reporter which doesn't support templates.
+func commentTemplate(opt *option, conf *project.Config) (*commentutil.Template, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	if !templateReporters[opt.reporter] {
+		for _, t := range conf.Templates {
+			if t.Reporter == opt.reporter {
+				return nil, fmt.Errorf("templates are not supported by -reporter=%s", opt.reporter)
+			}
+		}
+	}
+	return conf.CommentTemplate(opt.reporter)
+}
+
+// localConfig returns the config for runs which read results from stdin
+// (-f or -efm). Templates and rewrite rules of the config apply to the
+// results. The config is looked up in the same way as project based runs, but
+// it returns nil if -conf is not given and there is no config file.
+func localConfig(opt *option) (*project.Config, error) {
+	if opt.conf != "" {
+		return projectConfig(opt.conf)
+	}
+	for _, f := range confFiles("") {
+		if _, err := os.Stat(f); err == nil {
+			return projectConfig(f)
+		}
+	}
+	return nil, nil
+}
+
 func projectConfig(path string) (*project.Config, error) {
 	b, err := readConf(path)
 	if err != nil {
@@ -715,19 +1271,21 @@ func projectConfig(path string) (*project.Config, error) {
 	return conf, nil
 }
 
-func readConf(conf string) ([]byte, error) {
-	var conffiles []string
+// confFiles returns candidate paths of the config file.
+func confFiles(conf string) []string {
 	if conf != "" {
-		conffiles = []string{conf}
-	} else {
-		conffiles = []string{
-			".reviewdog.yaml",
-			".reviewdog.yml",
-			"reviewdog.yaml",
-			"reviewdog.yml",
-		}
+		return []string{conf}
+	}
+	return []string{
+		".reviewdog.yaml",
+		".reviewdog.yml",
+		"reviewdog.yaml",
+		"reviewdog.yml",
 	}
-	for _, f := range conffiles {
+}
+
+func readConf(conf string) ([]byte, error) {
+	for _, f := range confFiles(conf) {
 		bytes, err := ioutil.ReadFile(f)
 		if err == nil {
 			return bytes, nil
@@ -736,7 +1294,9 @@ func readConf(conf string) ([]byte, error) {
 	return nil, errors.New(".reviewdog.yml not found")
 }
 
-func newParserFromOpt(opt *option) (parser.Parser, error) {
+// newParserFromOpt returns the parser of -f or -efm. The parser rewrites
+// diagnostics with rewrite rules of conf if conf isn't nil.
+func newParserFromOpt(opt *option, conf *project.Config) (parser.Parser, error) {
 	p, err := parser.New(&parser.Option{
 		FormatName:  opt.f,
 		DiffStrip:   opt.fDiffStrip,
@@ -745,7 +1305,29 @@ func newParserFromOpt(opt *option) (parser.Parser, error) {
 	if err != nil {
 		return nil, fmt.Errorf("fail to create parser. use either -f or -efm: %w", err)
 	}
-	return p, err
+	if conf != nil && len(conf.Rewrite) > 0 {
+		p = &rewriteParser{p: p, runner: toolName(opt), rules: conf.Rewrite}
+	}
+	return p, nil
+}
+
+// rewriteParser rewrites parsed diagnostics with rewrite rules of the config
+// as if they were reported by the runner.
+type rewriteParser struct {
+	p      parser.Parser
+	runner string
+	rules  []*project.RewriteRule
+}
+
+func (p *rewriteParser) Parse(r io.Reader) ([]*rdf.Diagnostic, error) {
+	diagnostics, err := p.p.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := project.Rewrite(p.runner, diagnostics, p.rules); err != nil {
+		return nil, err
+	}
+	return diagnostics, nil
 }
 
 func toolName(opt *option) string {
@@ -766,6 +1348,17 @@ func buildRunnersMap(runners string) map[string]bool {
 	return m
 }
 
+// summaryTools returns names of tools of the run whose summary comments are
+// maintained by -summary-comment.
+func summaryTools(opt *option, conf *project.Config) []string {
+	tools := getRunnersList(opt, conf)
+	if len(tools) == 0 {
+		// Results without tool name are summarized as "reviewdog".
+		return []string{"reviewdog"}
+	}
+	return tools
+}
+
 func getRunnersList(opt *option, conf *project.Config) []string {
 	if len(opt.runners) > 0 { // if runners explicitly defined, use them
 		return strings.Split(opt.runners, ",")
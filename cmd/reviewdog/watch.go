@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/project"
+	"github.com/reviewdog/reviewdog/watch"
+)
+
+// clearScreen clears the terminal and moves the cursor to the top left.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// runWatch runs runners of the config file and reports the results with the
+// local reporter. Then it watches the working tree and re-runs runners whose
+// when_changed globs match changed files. Files ignored by git and files
+// which runners write on each run are not watched. The report of the latest results of
+// all runners is refreshed on each run, filtered by diff from the merge-base
+// of -diff-base ("auto" by default).
+func runWatch(ctx context.Context, w io.Writer, opt *option) error {
+	if len(opt.efms) != 0 || opt.f != "" {
+		return errors.New("-watch runs runners of the config file and doesn't support -f and -efm")
+	}
+	if opt.reporter != "local" {
+		return fmt.Errorf("-watch supports only -reporter=local: got %s", opt.reporter)
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	// Start watching before the first run not to miss changes during it.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	watcher, err := watch.New(ctx, root, nil)
+	if err != nil {
+		return err
+	}
+	s := &watchSession{opt: withAutoDiffBase(opt), w: w, root: root, results: make(map[string]*reviewdog.Result)}
+	run := func(files []string) error {
+		// Changes made while runners are running are held until they finish,
+		// so that files which runners write on each run (e.g. caches and
+		// reports) don't trigger runs forever.
+		watcher.Pause()
+		defer watcher.Resume()
+		return s.run(ctx, files)
+	}
+	if err := run(nil); err != nil {
+		return err
+	}
+	for files := range watcher.Changes() {
+		if err := run(files); err != nil {
+			fmt.Fprintf(w, "reviewdog: %v\n", err)
+		}
+	}
+	return watcher.Err()
+}
+
+// watchSession keeps the latest results of runners in watch mode.
+type watchSession struct {
+	opt  *option
+	w    io.Writer
+	root string
+	// results by runner names.
+	results map[string]*reviewdog.Result
+}
+
+// run runs runners triggered by changed files and reports the results. All
+// runners run if files is nil (i.e. the first run) or the config file is
+// changed.
+func (s *watchSession) run(ctx context.Context, files []string) error {
+	conf, err := projectConfig(s.opt.conf)
+	if err != nil {
+		return err
+	}
+	runners := buildRunnersMap(s.opt.runners)
+	if files != nil && !s.confChanged(files) {
+		triggered := make(map[string]bool)
+		for name := range conf.ChangedRunners(files) {
+			if len(runners) == 0 || runners[name] {
+				triggered[name] = true
+			}
+		}
+		if len(triggered) == 0 {
+			return nil
+		}
+		runners = triggered
+	}
+	results, err := project.RunAndParse(ctx, conf, runners, "", false)
+	if err != nil {
+		return err
+	}
+
+	// Forget results of runners removed from the config.
+	names := make(map[string]bool)
+	for _, r := range conf.Runner {
+		names[r.Name] = true
+	}
+	for name := range s.results {
+		if !names[name] {
+			delete(s.results, name)
+		}
+	}
+	results.Range(func(name string, r *reviewdog.Result) {
+		s.results[name] = r
+	})
+	return s.report(ctx)
+}
+
+// report reports the latest results of all runners filtered by the current
+// diff. It writes the whole report at once after clearing the terminal.
+func (s *watchSession) report(ctx context.Context) error {
+	d, err := newLocalDiffService(s.opt)
+	if err != nil {
+		return err
+	}
+	filediffs, err := reviewdog.FileDiffs(ctx, d)
+	if err != nil {
+		return err
+	}
+	filterOpts, err := filterOptions(ctx, s.opt)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	cs := reviewdog.NewUnifiedCommentWriter(&buf)
+	names := make([]string, 0, len(s.results))
+	for name := range s.results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result := s.results[name]
+		if err := result.CheckUnexpectedFailure(); err != nil {
+			fmt.Fprintf(&buf, "reviewdog: %s: %v\n", name, err)
+			continue
+		}
+		if err := reviewdog.RunFromResult(ctx, cs, result.Diagnostics, filediffs, d.Strip(), name, s.opt.filterMode, false, filterOpts...); err != nil {
+			return err
+		}
+	}
+	if isTerminal(s.w) {
+		fmt.Fprint(s.w, clearScreen)
+	}
+	_, err = buf.WriteTo(s.w)
+	return err
+}
+
+// confChanged reports whether changed files contain the config file.
+func (s *watchSession) confChanged(files []string) bool {
+	for _, c := range confFiles(s.opt.conf) {
+		if filepath.IsAbs(c) {
+			if rel, err := filepath.Rel(s.root, c); err == nil {
+				c = rel
+			}
+		}
+		for _, f := range files {
+			if filepath.FromSlash(f) == filepath.Clean(c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/filter"
+)
+
+func TestWatchSession_run(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reviewdog-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFile := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conf := filepath.Join(dir, "reviewdog.yml")
+	writeFile("reviewdog.yml", fmt.Sprintf(`runner:
+  golint:
+    cmd: cat %s
+    errorformat: ["%%f:%%l: %%m"]
+    when_changed: ["*.go"]
+  misspell:
+    cmd: cat %s
+    errorformat: ["%%f:%%l: %%m"]
+    when_changed: ["*.md"]
+`, filepath.Join(dir, "golint.txt"), filepath.Join(dir, "misspell.txt")))
+	writeFile("golint.txt", "a.go:1: golint 1\n")
+	writeFile("misspell.txt", "README.md:1: misspell 1\n")
+
+	stdout := new(bytes.Buffer)
+	s := &watchSession{
+		opt:     withAutoDiffBase(&option{conf: conf, reporter: "local", filterMode: filter.ModeNoFilter}),
+		w:       stdout,
+		root:    dir,
+		results: make(map[string]*reviewdog.Result),
+	}
+	run := func(files []string, want ...string) {
+		t.Helper()
+		stdout.Reset()
+		if err := s.run(context.Background(), files); err != nil {
+			t.Fatal(err)
+		}
+		for _, w := range want {
+			if !strings.Contains(stdout.String(), w) {
+				t.Errorf("run(%q) got:\n%s\nwant %q", files, stdout.String(), w)
+			}
+		}
+	}
+	run(nil, "golint 1", "misspell 1")
+
+	writeFile("golint.txt", "a.go:1: golint 2\n")
+	writeFile("misspell.txt", "README.md:1: misspell 2\n")
+	// Only golint runs and the result of misspell is kept.
+	run([]string{"sub/a.go"}, "golint 2", "misspell 1")
+
+	// Nothing runs.
+	run([]string{"a.txt"})
+	if stdout.Len() != 0 {
+		t.Errorf("run() without triggered runners got:\n%s", stdout.String())
+	}
+
+	// All runners run when the config is changed.
+	run([]string{"reviewdog.yml"}, "golint 2", "misspell 2")
+}
+
+func TestRunWatch_runnerWritesFiles(t *testing.T) {
+	cwd, _ := os.Getwd()
+	defer func(dir string) {
+		if err := os.Chdir(dir); err != nil {
+			t.Error(err)
+		}
+	}(cwd)
+	dir, err := ioutil.TempDir("", "reviewdog-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	countDir, err := ioutil.TempDir("", "reviewdog-watch-count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(countDir)
+	countFile := filepath.Join(countDir, "count")
+	// The runner without when_changed runs on any changes and writes a file
+	// in the watched directory on each run.
+	if err := ioutil.WriteFile(filepath.Join(dir, "reviewdog.yml"), []byte(fmt.Sprintf(`runner:
+  touch:
+    cmd: echo run >> %s && sleep 0.5 && date +%%s%%N > report.txt
+    errorformat: ["%%f:%%l: %%m"]
+`, countFile)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	runs := func() int {
+		b, _ := ioutil.ReadFile(countFile)
+		return strings.Count(string(b), "run")
+	}
+	waitStart := func(want int) {
+		t.Helper()
+		for deadline := time.Now().Add(10 * time.Second); runs() < want; {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %d runs: got %d runs", want, runs())
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	waitRuns := func(want int) {
+		t.Helper()
+		waitStart(want)
+		// The file written by the runner doesn't trigger runs.
+		time.Sleep(2 * time.Second)
+		if got := runs(); got != want {
+			t.Errorf("got %d runs, want %d runs", got, want)
+		}
+	}
+	writeFile := func(name string) {
+		t.Helper()
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("package a"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- runWatch(ctx, ioutil.Discard, &option{conf: "reviewdog.yml", reporter: "local", filterMode: filter.ModeNoFilter})
+	}()
+	// The report written by the first run triggers a run once because it's
+	// not known that the runner writes it yet.
+	waitRuns(2)
+	writeFile("a.go")
+	waitRuns(3)
+
+	// A file edited while the runner is running triggers a run after it.
+	writeFile("b.go")
+	waitStart(4)
+	writeFile("c.go")
+	waitRuns(5)
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Errorf("runWatch() = %v", err)
+	}
+}
+
+func TestRun_watchUnsupported(t *testing.T) {
+	err := run(strings.NewReader(""), ioutil.Discard, &option{watch: true, f: "golint", reporter: "local"})
+	if err == nil || !strings.Contains(err.Error(), "-watch runs runners of the config file") {
+		t.Errorf("run() = %v, want error about -f", err)
+	}
+	err = run(strings.NewReader(""), ioutil.Discard, &option{watch: true, reporter: "github-pr-review"})
+	if err == nil || !strings.Contains(err.Error(), "-watch supports only -reporter=local") {
+		t.Errorf("run() = %v, want error about -reporter", err)
+	}
+}
@@ -1,6 +1,11 @@
 package reviewdog
 
-import "context"
+import (
+	"context"
+	"sort"
+
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
 
 var _ BulkCommentService = &multiCommentService{}
 
@@ -35,3 +40,92 @@ func MultiCommentService(services ...CommentService) CommentService {
 	copy(s, services)
 	return &multiCommentService{services: s}
 }
+
+var _ BulkCommentService = &severityRouter{}
+
+type severityRouter struct {
+	def    CommentService
+	routes map[rdf.Severity]CommentService
+}
+
+func (r *severityRouter) Post(ctx context.Context, c *Comment) error {
+	cs, ok := r.routes[c.Result.Diagnostic.GetSeverity()]
+	if !ok {
+		cs = r.def
+	}
+	return cs.Post(ctx, c)
+}
+
+func (r *severityRouter) Flush(ctx context.Context) error {
+	flushed := make(map[CommentService]bool)
+	for _, cs := range append([]CommentService{r.def}, r.sortedRoutes()...) {
+		// Flush the underlying service only once even if it's routed as both
+		// normal and summary-only comments.
+		if s, ok := cs.(*summaryOnlyCommentService); ok {
+			cs = s.cs
+		}
+		if flushed[cs] {
+			continue
+		}
+		flushed[cs] = true
+		if bulk, ok := cs.(BulkCommentService); ok {
+			if err := bulk.Flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortedRoutes returns the routed services in the order of severities so
+// that Flush is deterministic.
+func (r *severityRouter) sortedRoutes() []CommentService {
+	severities := make([]int, 0, len(r.routes))
+	for s := range r.routes {
+		severities = append(severities, int(s))
+	}
+	sort.Ints(severities)
+	services := make([]CommentService, 0, len(severities))
+	for _, s := range severities {
+		services = append(services, r.routes[rdf.Severity(s)])
+	}
+	return services
+}
+
+// RouteBySeverity creates a comment service that posts comments to the
+// service of their severity in routes or def otherwise. Flush is called once
+// for each distinct service, including services wrapped by
+// SummaryOnlyCommentService.
+func RouteBySeverity(def CommentService, routes map[rdf.Severity]CommentService) CommentService {
+	r := make(map[rdf.Severity]CommentService, len(routes))
+	for s, cs := range routes {
+		r[s] = cs
+	}
+	return &severityRouter{def: def, routes: r}
+}
+
+var _ BulkCommentService = &summaryOnlyCommentService{}
+
+type summaryOnlyCommentService struct {
+	cs CommentService
+}
+
+func (s *summaryOnlyCommentService) Post(ctx context.Context, c *Comment) error {
+	summaryOnly := *c
+	summaryOnly.SummaryOnly = true
+	return s.cs.Post(ctx, &summaryOnly)
+}
+
+func (s *summaryOnlyCommentService) Flush(ctx context.Context) error {
+	if bulk, ok := s.cs.(BulkCommentService); ok {
+		return bulk.Flush(ctx)
+	}
+	return nil
+}
+
+// SummaryOnlyCommentService creates a comment service that posts comments to
+// cs as Comment.SummaryOnly, so reporters which support summary comments
+// report them only in the summary.
+func SummaryOnlyCommentService(cs CommentService) CommentService {
+	return &summaryOnlyCommentService{cs: cs}
+}
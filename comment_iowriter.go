@@ -4,14 +4,47 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sync"
 )
 
-var _ CommentService = &RawCommentWriter{}
+// omittedComments counts Comment.Overflow comments which comment writers
+// don't write.
+type omittedComments struct {
+	mu sync.Mutex
+	n  int
+}
+
+// omit returns true and counts the comment if it's Comment.Overflow.
+func (o *omittedComments) omit(c *Comment) bool {
+	if !c.Overflow {
+		return false
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.n++
+	return true
+}
+
+// flush writes the number of omitted comments to w if any and resets it.
+func (o *omittedComments) flush(w io.Writer) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.n == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "reviewdog: %d more results omitted (limits of comments exceeded)\n", o.n)
+	o.n = 0
+	return err
+}
+
+var _ BulkCommentService = &RawCommentWriter{}
 
 // RawCommentWriter is comment writer which writes results to given writer
-// without any formatting.
+// without any formatting. Comment.Overflow comments are not written and Flush
+// writes the number of them.
 type RawCommentWriter struct {
-	w io.Writer
+	w       io.Writer
+	omitted omittedComments
 }
 
 func NewRawCommentWriter(w io.Writer) *RawCommentWriter {
@@ -19,11 +52,19 @@ func NewRawCommentWriter(w io.Writer) *RawCommentWriter {
 }
 
 func (s *RawCommentWriter) Post(_ context.Context, c *Comment) error {
+	if s.omitted.omit(c) {
+		return nil
+	}
 	_, err := fmt.Fprintln(s.w, c.Result.Diagnostic.OriginalOutput)
 	return err
 }
 
-var _ CommentService = &UnifiedCommentWriter{}
+// Flush writes the number of omitted Comment.Overflow comments.
+func (s *RawCommentWriter) Flush(_ context.Context) error {
+	return s.omitted.flush(s.w)
+}
+
+var _ BulkCommentService = &UnifiedCommentWriter{}
 
 // UnifiedCommentWriter is comment writer which writes results to given writer
 // in one of following unified formats.
@@ -33,8 +74,12 @@ var _ CommentService = &UnifiedCommentWriter{}
 //   - <file>:<lnum>: [<tool name>] <message>
 //   - <file>:<lnum>:<col>: [<tool name>] <message>
 // where <message> can be multiple lines.
+//
+// Comment.Overflow comments are not written and Flush writes the number of
+// them.
 type UnifiedCommentWriter struct {
-	w io.Writer
+	w       io.Writer
+	omitted omittedComments
 }
 
 func NewUnifiedCommentWriter(w io.Writer) *UnifiedCommentWriter {
@@ -42,6 +87,9 @@ func NewUnifiedCommentWriter(w io.Writer) *UnifiedCommentWriter {
 }
 
 func (mc *UnifiedCommentWriter) Post(_ context.Context, c *Comment) error {
+	if mc.omitted.omit(c) {
+		return nil
+	}
 	loc := c.Result.Diagnostic.GetLocation()
 	s := loc.GetPath()
 	start := loc.GetRange().GetStart()
@@ -55,3 +103,8 @@ func (mc *UnifiedCommentWriter) Post(_ context.Context, c *Comment) error {
 	_, err := fmt.Fprintln(mc.w, s)
 	return err
 }
+
+// Flush writes the number of omitted Comment.Overflow comments.
+func (mc *UnifiedCommentWriter) Flush(_ context.Context) error {
+	return mc.omitted.flush(mc.w)
+}
@@ -0,0 +1,183 @@
+package reviewdog
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// CommentLimits represents limits of the number of comments in a run. Zero
+// means no limit.
+type CommentLimits struct {
+	// Max number of comments.
+	MaxComments int
+	// Max number of comments per file.
+	MaxCommentsPerFile int
+	// Max number of comments per rule (i.e. tool name and diagnostic code).
+	// Comments without code are not limited by it.
+	MaxPerRule int
+}
+
+// CommentLimiter charges comments against CommentLimits in a run. Reporters
+// which don't post some comments (e.g. already posted comments or comments
+// outside diff) use it in Flush after filtering them out, so that only
+// comments which are actually posted are counted. A nil *CommentLimiter
+// doesn't limit comments.
+type CommentLimiter struct {
+	limits CommentLimits
+
+	mu      sync.Mutex
+	charged map[*Comment]bool
+	total   int
+	perFile map[string]int
+	perRule map[string]int
+}
+
+// NewCommentLimiter returns a new CommentLimiter.
+func NewCommentLimiter(limits CommentLimits) *CommentLimiter {
+	return &CommentLimiter{
+		limits:  limits,
+		charged: make(map[*Comment]bool),
+		perFile: make(map[string]int),
+		perRule: make(map[string]int),
+	}
+}
+
+// Limit splits comments into ones within the limits and ones exceeding them,
+// keeping the order of comments. Comments are charged in a stable order of
+// their locations, tool names and messages, so the result doesn't depend on
+// the order of posting. Counts are kept across calls, and comments charged by
+// earlier calls are within the limits.
+func (l *CommentLimiter) Limit(comments []*Comment) (within, overflow []*Comment) {
+	if l == nil {
+		return comments, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sorted := make([]*Comment, len(comments))
+	copy(sorted, comments)
+	sort.SliceStable(sorted, func(i, j int) bool { return commentLess(sorted[i], sorted[j]) })
+	ok := make(map[*Comment]bool)
+	for _, c := range sorted {
+		ok[c] = l.take(c)
+	}
+	for _, c := range comments {
+		if ok[c] {
+			within = append(within, c)
+		} else {
+			overflow = append(overflow, c)
+		}
+	}
+	return within, overflow
+}
+
+// take returns true and counts the comment if it's already charged or doesn't
+// exceed any limits.
+func (l *CommentLimiter) take(c *Comment) bool {
+	if l.charged[c] {
+		return true
+	}
+	path := c.Result.Diagnostic.GetLocation().GetPath()
+	rule := ""
+	if code := c.Result.Diagnostic.GetCode().GetValue(); code != "" {
+		rule = c.ToolName + ":" + code
+	}
+	if exceeds(l.total, l.limits.MaxComments) ||
+		exceeds(l.perFile[path], l.limits.MaxCommentsPerFile) ||
+		(rule != "" && exceeds(l.perRule[rule], l.limits.MaxPerRule)) {
+		return false
+	}
+	l.charged[c] = true
+	l.total++
+	l.perFile[path]++
+	if rule != "" {
+		l.perRule[rule]++
+	}
+	return true
+}
+
+func exceeds(n, limit int) bool {
+	return limit > 0 && n >= limit
+}
+
+// commentLess orders comments by path, line, column, tool name and message.
+func commentLess(a, b *Comment) bool {
+	la, lb := a.Result.Diagnostic.GetLocation(), b.Result.Diagnostic.GetLocation()
+	if la.GetPath() != lb.GetPath() {
+		return la.GetPath() < lb.GetPath()
+	}
+	sa, sb := la.GetRange().GetStart(), lb.GetRange().GetStart()
+	if sa.GetLine() != sb.GetLine() {
+		return sa.GetLine() < sb.GetLine()
+	}
+	if sa.GetColumn() != sb.GetColumn() {
+		return sa.GetColumn() < sb.GetColumn()
+	}
+	if a.ToolName != b.ToolName {
+		return a.ToolName < b.ToolName
+	}
+	return a.Result.Diagnostic.GetMessage() < b.Result.Diagnostic.GetMessage()
+}
+
+var _ BulkCommentService = &commentLimiter{}
+
+type commentLimiter struct {
+	cs      CommentService
+	limiter *CommentLimiter
+
+	mu       sync.Mutex
+	comments []*Comment
+}
+
+// Post accepts a comment and holds it. Flush method actually posts comments
+// to the underlying comment service.
+func (l *commentLimiter) Post(_ context.Context, c *Comment) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.comments = append(l.comments, c)
+	return nil
+}
+
+// Flush charges held comments in a stable order, posts them and flushes the
+// underlying comment service.
+func (l *commentLimiter) Flush(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var candidates []*Comment
+	for _, c := range l.comments {
+		// Comment.SummaryOnly comments are not posted individually.
+		if !c.SummaryOnly && !c.Overflow {
+			candidates = append(candidates, c)
+		}
+	}
+	_, overflow := l.limiter.Limit(candidates)
+	isOverflow := make(map[*Comment]bool)
+	for _, c := range overflow {
+		isOverflow[c] = true
+	}
+	for _, c := range l.comments {
+		if isOverflow[c] {
+			o := *c
+			o.Overflow = true
+			c = &o
+		}
+		if err := l.cs.Post(ctx, c); err != nil {
+			return err
+		}
+	}
+	l.comments = nil
+	if bulk, ok := l.cs.(BulkCommentService); ok {
+		return bulk.Flush(ctx)
+	}
+	return nil
+}
+
+// LimitComments creates a comment service that posts comments to cs as
+// Comment.Overflow once they exceed the limits, so that reporters collapse
+// them into an overflow summary instead of posting them individually.
+// Comments are held and charged in a stable order on Flush. Comment.SummaryOnly
+// comments are not counted. Reporters which filter out comments before
+// posting should use CommentLimiter instead.
+func LimitComments(cs CommentService, limits CommentLimits) CommentService {
+	return &commentLimiter{cs: cs, limiter: NewCommentLimiter(limits)}
+}
@@ -0,0 +1,135 @@
+package reviewdog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/reviewdog/reviewdog/filter"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+func TestLimitComments(t *testing.T) {
+	newComment := func(path, code string) *Comment {
+		return &Comment{
+			Result: &filter.FilteredDiagnostic{Diagnostic: &rdf.Diagnostic{
+				Location: &rdf.Location{Path: path},
+				Code:     &rdf.Code{Value: code},
+			}},
+			ToolName: "tool",
+		}
+	}
+	comments := []*Comment{
+		newComment("a.go", "R1"),
+		newComment("a.go", "R1"),
+		newComment("a.go", "R2"),
+		newComment("a.go", ""),
+		newComment("b.go", "R1"),
+		newComment("b.go", ""),
+		newComment("c.go", ""),
+		newComment("d.go", ""),
+	}
+	summaryOnly := newComment("e.go", "")
+	summaryOnly.SummaryOnly = true
+	comments = append(comments, summaryOnly)
+
+	tests := []struct {
+		name   string
+		limits CommentLimits
+		want   string // Overflow of comments.
+	}{
+		{name: "no limits", want: "ooooooooo"},
+		{name: "max comments", limits: CommentLimits{MaxComments: 3}, want: "oooxxxxxo"},
+		{name: "per file", limits: CommentLimits{MaxCommentsPerFile: 2}, want: "ooxxooooo"},
+		{name: "per rule", limits: CommentLimits{MaxPerRule: 1}, want: "oxooxoooo"},
+		{name: "all", limits: CommentLimits{MaxComments: 5, MaxCommentsPerFile: 2, MaxPerRule: 1}, want: "oxoxxoooo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeCommentService{}
+			cs := LimitComments(f, tt.limits)
+			for _, c := range comments {
+				if err := cs.Post(context.Background(), c); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := cs.(BulkCommentService).Flush(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			for _, c := range f.comments {
+				if c.Overflow {
+					got += "x"
+				} else {
+					got += "o"
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("overflow has diff:\n%s", diff)
+			}
+			if f.flushed != 1 {
+				t.Errorf("Flush() is called %d times, want 1", f.flushed)
+			}
+			for _, c := range comments {
+				if c.Overflow {
+					t.Fatalf("LimitComments modified the given comment: %v", c)
+				}
+			}
+		})
+	}
+}
+
+func TestCommentLimiter_Limit(t *testing.T) {
+	newComment := func(tool, path string, line int32) *Comment {
+		return &Comment{
+			Result: &filter.FilteredDiagnostic{Diagnostic: &rdf.Diagnostic{
+				Location: &rdf.Location{Path: path, Range: &rdf.Range{Start: &rdf.Position{Line: line}}},
+			}},
+			ToolName: tool,
+		}
+	}
+	a1, a2 := newComment("a", "a.go", 1), newComment("a", "a.go", 2)
+	b1, b2 := newComment("b", "a.go", 1), newComment("b", "b.go", 1)
+	names := map[*Comment]string{a1: "a1", a2: "a2", b1: "b1", b2: "b2"}
+	str := func(cs []*Comment) []string {
+		var s []string
+		for _, c := range cs {
+			s = append(s, names[c])
+		}
+		return s
+	}
+
+	// The result doesn't depend on the order of comments.
+	for _, in := range [][]*Comment{{a1, a2, b1, b2}, {b2, b1, a2, a1}} {
+		l := NewCommentLimiter(CommentLimits{MaxComments: 2})
+		within, overflow := l.Limit(in)
+		if diff := cmp.Diff([]string{"a1", "b1"}, str(within), cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+			t.Errorf("within comments of %v has diff:\n%s", str(in), diff)
+		}
+		if len(overflow) != 2 {
+			t.Errorf("got %v as overflow, want 2 comments", str(overflow))
+		}
+	}
+
+	// Comments charged by earlier calls are within the limits, and counts are
+	// kept across calls.
+	l := NewCommentLimiter(CommentLimits{MaxComments: 2})
+	if within, _ := l.Limit([]*Comment{a2}); len(within) != 1 {
+		t.Fatalf("got %v, want a2 within the limits", str(within))
+	}
+	within, overflow := l.Limit([]*Comment{a1, a2, b1, b2})
+	if diff := cmp.Diff([]string{"a1", "a2"}, str(within)); diff != "" {
+		t.Errorf("within comments has diff:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"b1", "b2"}, str(overflow)); diff != "" {
+		t.Errorf("overflow comments has diff:\n%s", diff)
+	}
+
+	// nil CommentLimiter doesn't limit comments.
+	var nilLimiter *CommentLimiter
+	if within, overflow := nilLimiter.Limit([]*Comment{a1, b1}); len(within) != 2 || len(overflow) != 0 {
+		t.Errorf("nil CommentLimiter got within %v, overflow %v", str(within), str(overflow))
+	}
+}
@@ -3,9 +3,12 @@ package reviewdog
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/reviewdog/reviewdog/filter"
 	"github.com/reviewdog/reviewdog/proto/rdf"
 )
@@ -56,3 +59,55 @@ func TestMultiCommentService_Flush(t *testing.T) {
 		t.Error("MultiCommentService_Flush should run Flush() for every services")
 	}
 }
+
+type fakeCommentService struct {
+	comments []*Comment
+	flushed  int
+}
+
+func (f *fakeCommentService) Post(_ context.Context, c *Comment) error {
+	f.comments = append(f.comments, c)
+	return nil
+}
+
+func (f *fakeCommentService) Flush(_ context.Context) error {
+	f.flushed++
+	return nil
+}
+
+func TestRouteBySeverity(t *testing.T) {
+	reporter := &fakeCommentService{}
+	local := &fakeCommentService{}
+	w := RouteBySeverity(reporter, map[rdf.Severity]CommentService{
+		rdf.Severity_WARNING: SummaryOnlyCommentService(reporter),
+		rdf.Severity_INFO:    local,
+	})
+	for _, s := range []rdf.Severity{rdf.Severity_ERROR, rdf.Severity_WARNING, rdf.Severity_INFO, rdf.Severity_UNKNOWN_SEVERITY} {
+		c := &Comment{Result: &filter.FilteredDiagnostic{Diagnostic: &rdf.Diagnostic{Severity: s}}}
+		if err := w.Post(context.Background(), c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.(BulkCommentService).Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, c := range reporter.comments {
+		got = append(got, fmt.Sprintf("%s summaryOnly=%v", c.Result.Diagnostic.GetSeverity(), c.SummaryOnly))
+	}
+	want := []string{
+		"ERROR summaryOnly=false",
+		"WARNING summaryOnly=true",
+		"UNKNOWN_SEVERITY summaryOnly=false",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("reporter comments has diff:\n%s", diff)
+	}
+	if len(local.comments) != 1 || local.comments[0].Result.Diagnostic.GetSeverity() != rdf.Severity_INFO {
+		t.Errorf("local comments = %v, want only INFO", local.comments)
+	}
+	if reporter.flushed != 1 || local.flushed != 1 {
+		t.Errorf("Flush() is called (reporter=%d, local=%d) times, want once for each service", reporter.flushed, local.flushed)
+	}
+}
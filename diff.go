@@ -1,11 +1,45 @@
 package reviewdog
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os/exec"
 	"sync"
+
+	"github.com/reviewdog/reviewdog/diff"
 )
 
+// FileDiffService is an optional interface of DiffService which returns
+// parsed file diffs directly without formatting and parsing unified diff.
+type FileDiffService interface {
+	DiffService
+	FileDiffs(context.Context) ([]*diff.FileDiff, error)
+}
+
+// FileDiffs returns file diffs of the DiffService. It uses FileDiffService
+// if d implements it.
+func FileDiffs(ctx context.Context, d DiffService) ([]*diff.FileDiff, error) {
+	if fd, ok := d.(FileDiffService); ok {
+		filediffs, err := fd.FileDiffs(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("fail to get diff: %w", err)
+		}
+		return filediffs, nil
+	}
+	b, err := d.Diff(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get diff: %w", err)
+	}
+	filediffs, err := diff.ParseMultiFile(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("fail to parse diff: %w", err)
+	}
+	return filediffs, nil
+}
+
 var _ DiffService = &DiffString{}
 
 type DiffString struct {
@@ -61,6 +95,157 @@ func (d *DiffCmd) Strip() int {
 	return d.strip
 }
 
+// DiffReader returns a reader of the output of the diff command without
+// loading it in memory. It runs a copy of the command unless the output is
+// already cached by Diff.
+func (d *DiffCmd) DiffReader(ctx context.Context) (io.ReadCloser, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.done {
+		return ioutil.NopCloser(bytes.NewReader(d.out)), nil
+	}
+	cmd := exec.CommandContext(ctx, d.cmd.Path)
+	cmd.Args = d.cmd.Args
+	cmd.Dir = d.cmd.Dir
+	cmd.Env = d.cmd.Env
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &cmdReader{r: stdout, cmd: cmd}, nil
+}
+
+// cmdReader reads stdout of the command and waits for the command on Close.
+type cmdReader struct {
+	r   io.Reader
+	cmd *exec.Cmd
+	n   int
+}
+
+func (r *cmdReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += n
+	return n, err
+}
+
+func (r *cmdReader) Close() error {
+	// Drain the output so that the command doesn't block on writing.
+	n, _ := io.Copy(ioutil.Discard, r.r)
+	r.n += int(n)
+	// Exit status of `git diff` is 1 if diff exists, so ignore the error if diff
+	// presents.
+	if err := r.cmd.Wait(); err != nil && r.n == 0 {
+		return err
+	}
+	return nil
+}
+
+// DiffReaderService is an optional interface of DiffService which returns a
+// reader of unified diff instead of loading the whole diff in memory.
+type DiffReaderService interface {
+	DiffService
+	DiffReader(context.Context) (io.ReadCloser, error)
+}
+
+var _ FileDiffService = &StreamingDiff{}
+
+// StreamingDiff is a diff service which parses diff of the underlying diff
+// service with diff.ScanMultiFile. It keeps only line number intervals of
+// hunks instead of lines, and source lines of results are read from files on
+// disk instead, so it reduces memory usage for huge diff (e.g. generated
+// files or vendored dependencies). It reads diff from DiffReader if the
+// underlying service implements DiffReaderService.
+type StreamingDiff struct {
+	DiffService
+
+	once      sync.Once
+	filediffs []*diff.FileDiff
+	err       error
+}
+
+// NewStreamingDiff returns a new StreamingDiff which wraps d.
+func NewStreamingDiff(d DiffService) *StreamingDiff {
+	return &StreamingDiff{DiffService: d}
+}
+
+// FileDiffs returns file diffs without lines of hunks. It caches the result
+// and can be used more than once.
+func (d *StreamingDiff) FileDiffs(ctx context.Context) ([]*diff.FileDiff, error) {
+	d.once.Do(func() {
+		d.filediffs, d.err = d.scan(ctx)
+	})
+	return d.filediffs, d.err
+}
+
+func (d *StreamingDiff) scan(ctx context.Context) ([]*diff.FileDiff, error) {
+	if rs, ok := d.DiffService.(DiffReaderService); ok {
+		r, err := rs.DiffReader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		filediffs, err := diff.ScanMultiFile(r)
+		if cerr := r.Close(); err == nil {
+			err = cerr
+		}
+		return filediffs, err
+	}
+	b, err := d.DiffService.Diff(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return diff.ScanMultiFile(bytes.NewReader(b))
+}
+
+var _ FileDiffService = &DiffDirs{}
+
+// DiffDirs is a diff service which compares two directory trees. e.g. a
+// pristine checkout and its copy rewritten by a code generator or formatter.
+// It doesn't require git or diff command.
+type DiffDirs struct {
+	oldDir string
+	newDir string
+
+	once      sync.Once
+	filediffs []*diff.FileDiff
+	err       error
+}
+
+// NewDiffDirs returns a new DiffDirs which changes files in oldDir to files
+// in newDir. Paths in diff are relative to the directories.
+func NewDiffDirs(oldDir, newDir string) *DiffDirs {
+	return &DiffDirs{oldDir: oldDir, newDir: newDir}
+}
+
+// FileDiffs returns file diffs. It caches the result and can be used more
+// than once.
+func (d *DiffDirs) FileDiffs(_ context.Context) ([]*diff.FileDiff, error) {
+	d.once.Do(func() {
+		d.filediffs, d.err = diff.CompareDirs(d.oldDir, d.newDir)
+	})
+	return d.filediffs, d.err
+}
+
+// Diff returns diff in unified diff format.
+func (d *DiffDirs) Diff(ctx context.Context) ([]byte, error) {
+	filediffs, err := d.FileDiffs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := diff.Format(&buf, filediffs); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Strip returns 1 to strip "a/" and "b/" prefixes.
+func (d *DiffDirs) Strip() int {
+	return 1
+}
+
 // EmptyDiff service return empty diff.
 type EmptyDiff struct{}
 
@@ -1,4 +1,4 @@
-// Package diff provides a utility to parse unified diff.
+// Package diff provides a utility to parse and generate unified diff.
 // https://en.wikipedia.org/wiki/Diff_utility#Unified_format
 package diff
 
@@ -23,8 +23,39 @@ type FileDiff struct {
 	// extended header lines (e.g., git's "new mode <mode>", "rename from <path>", index fb14f33..c19311b 100644, etc.)
 	Extended []string
 
-	// TODO: we may want `\ No newline at end of file` information for both the
-	// old and new file.
+	// Fields below are parsed from extended header lines of git.
+
+	// the old and new file mode (e.g. "100644"). OldMode is empty for a new
+	// file and NewMode is empty for a deleted file.
+	OldMode string
+	NewMode string
+
+	// true if the file is added ("new file mode <mode>").
+	IsNew bool
+	// true if the file is deleted ("deleted file mode <mode>").
+	IsDeleted bool
+	// true if the file is renamed ("rename from <path>", "rename to <path>").
+	IsRename bool
+	// similarity index (%) of the renamed file. 0 if not present.
+	Similarity int
+	// true if the file is binary ("Binary files <old> and <new> differ" or
+	// "GIT binary patch"). Binary diff doesn't have hunks.
+	IsBinary bool
+
+	// true if the old or new file doesn't end with newline ("\ No newline at
+	// end of file").
+	NoNewlineOld bool
+	NoNewlineNew bool
+
+	// compact index of lines in hunks which ScanMultiFile sets instead of
+	// Lines of hunks. nil if hunks have lines.
+	Index *LineIndex `json:"-"`
+}
+
+// IsModeChangeOnly returns true if only file mode of the file is changed.
+func (fd *FileDiff) IsModeChangeOnly() bool {
+	return fd.OldMode != "" && fd.NewMode != "" && fd.OldMode != fd.NewMode &&
+		!fd.IsRename && !fd.IsBinary && len(fd.Hunks) == 0
 }
 
 // Hunk represents change hunks that contain the line differences in the file.
@@ -55,6 +86,12 @@ type Hunk struct {
 	// optional section heading
 	Section string
 
+	// the starting line numbers and the numbers of lines of each parent in a
+	// combined diff (e.g. "@@@ -1,3 -1,4 +1,5 @@@"). StartLineOld and
+	// LineLengthOld are the ones of the first parent. nil for a normal diff.
+	StartLineParents  []int `json:",omitempty"`
+	LineLengthParents []int `json:",omitempty"`
+
 	// the body lines of the hunk
 	Lines []*Line
 }
@@ -94,4 +131,10 @@ type Line struct {
 	// the line number of the new file for LineUnchanged and LineAdded type.
 	// 0 for LineDeleted type.
 	LnumNew int
+
+	// the types of this line relative to each parent in a combined diff. nil
+	// for a normal diff. Type is LineAdded only if the line is added relative
+	// to all parents, and LineDeleted if it's deleted from any parent.
+	// LnumOld is the line number of the first parent.
+	ParentTypes []LineType `json:",omitempty"`
 }
@@ -0,0 +1,24 @@
+package diff
+
+import "testing"
+
+func TestFileDiff_IsModeChangeOnly(t *testing.T) {
+	hunks := []*Hunk{{StartLineOld: 1, LineLengthOld: 1, StartLineNew: 1, LineLengthNew: 1}}
+	tests := []struct {
+		name string
+		in   *FileDiff
+		want bool
+	}{
+		{name: "mode change", in: &FileDiff{OldMode: "100644", NewMode: "100755"}, want: true},
+		{name: "mode and content change", in: &FileDiff{OldMode: "100644", NewMode: "100755", Hunks: hunks}},
+		{name: "mode change with rename", in: &FileDiff{OldMode: "100644", NewMode: "100755", IsRename: true}},
+		{name: "mode change of binary", in: &FileDiff{OldMode: "100644", NewMode: "100755", IsBinary: true}},
+		{name: "same mode", in: &FileDiff{OldMode: "100644", NewMode: "100644"}},
+		{name: "new file", in: &FileDiff{NewMode: "100644", IsNew: true}},
+	}
+	for _, tt := range tests {
+		if got := tt.in.IsModeChangeOnly(); got != tt.want {
+			t.Errorf("[%s] IsModeChangeOnly() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
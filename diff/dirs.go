@@ -0,0 +1,155 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// IsBinary detects binary content in the same way as git. i.e. it contains
+// NUL in the first 8000 bytes.
+func IsBinary(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// CompareDirs returns file diffs which change files in oldDir to files in
+// newDir, including added and removed files, as `git diff --no-index` does.
+// Paths are relative to the directories with "a/" and "b/" prefixes, so strip
+// is 1. Symlinks are followed and .git directories are skipped.
+func CompareDirs(oldDir, newDir string) ([]*FileDiff, error) {
+	oldFiles, err := listFiles(oldDir)
+	if err != nil {
+		return nil, err
+	}
+	newFiles, err := listFiles(newDir)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(newFiles))
+	for path := range newFiles {
+		paths = append(paths, path)
+	}
+	for path := range oldFiles {
+		if _, ok := newFiles[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var filediffs []*FileDiff
+	for _, path := range paths {
+		var old, new *dirFile
+		if mode, ok := oldFiles[path]; ok {
+			old = &dirFile{path: filepath.Join(oldDir, path), mode: mode}
+		}
+		if mode, ok := newFiles[path]; ok {
+			new = &dirFile{path: filepath.Join(newDir, path), mode: mode}
+		}
+		fd, err := compareFiles(filepath.ToSlash(path), old, new)
+		if err != nil {
+			return nil, err
+		}
+		if fd != nil {
+			filediffs = append(filediffs, fd)
+		}
+	}
+	return filediffs, nil
+}
+
+type dirFile struct {
+	path string
+	mode os.FileMode
+}
+
+// listFiles returns regular files in dir with their modes. Keys are relative
+// to dir.
+func listFiles(dir string) (map[string]os.FileMode, error) {
+	files := make(map[string]os.FileMode)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" && path != dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			if info, err = os.Stat(path); err != nil || !info.Mode().IsRegular() {
+				// Ignore broken symlinks and symlinks to directories.
+				return nil
+			}
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[rel] = info.Mode()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fail to list files in %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// gitMode returns file mode in git format.
+func gitMode(mode os.FileMode) string {
+	if mode&0111 != 0 {
+		return "100755"
+	}
+	return "100644"
+}
+
+// compareFiles returns file diff of path. old or new is nil if the file is
+// added or removed. It returns nil if there is no difference.
+func compareFiles(path string, old, new *dirFile) (*FileDiff, error) {
+	var oldContent, newContent []byte
+	var err error
+	fd := &FileDiff{PathOld: "/dev/null", PathNew: "/dev/null"}
+	if old != nil {
+		fd.PathOld = "a/" + path
+		if oldContent, err = ioutil.ReadFile(old.path); err != nil {
+			return nil, err
+		}
+	}
+	if new != nil {
+		fd.PathNew = "b/" + path
+		if newContent, err = ioutil.ReadFile(new.path); err != nil {
+			return nil, err
+		}
+	}
+	fd.Extended = []string{fmt.Sprintf("diff --git %s %s", QuotePath("a/"+path), QuotePath("b/"+path))}
+	switch {
+	case old == nil:
+		fd.Extended = append(fd.Extended, "new file mode "+gitMode(new.mode))
+	case new == nil:
+		fd.Extended = append(fd.Extended, "deleted file mode "+gitMode(old.mode))
+	default:
+		if oldMode, newMode := gitMode(old.mode), gitMode(new.mode); oldMode != newMode {
+			fd.Extended = append(fd.Extended, "old mode "+oldMode, "new mode "+newMode)
+		} else if bytes.Equal(oldContent, newContent) {
+			return nil, nil
+		}
+	}
+	if IsBinary(oldContent) || IsBinary(newContent) {
+		if !bytes.Equal(oldContent, newContent) {
+			fd.Extended = append(fd.Extended,
+				fmt.Sprintf("Binary files %s and %s differ", QuotePath(fd.PathOld), QuotePath(fd.PathNew)))
+		}
+	} else {
+		fd.SetHunks(oldContent, newContent, DefaultContextLines)
+	}
+	parseExtendedHeaderFields(fd)
+	return fd, nil
+}
@@ -0,0 +1,85 @@
+package diff
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCompareDirs(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "reviewdog-comparedirs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	oldDir, newDir := filepath.Join(tmp, "old"), filepath.Join(tmp, "new")
+	writeFiles(t, oldDir, map[string]string{
+		"unchanged.txt":   "a\n",
+		"sub/changed.txt": "a\nb\nc\n",
+		"removed.txt":     "removed\n",
+		"binary.dat":      "a\x00b",
+		".git/HEAD":       "ref: refs/heads/main\n",
+	})
+	writeFiles(t, newDir, map[string]string{
+		"unchanged.txt":   "a\n",
+		"sub/changed.txt": "a\nB\nc\n",
+		"added.txt":       "added\n",
+		"binary.dat":      "a\x00c",
+	})
+	if err := os.Chmod(filepath.Join(newDir, "unchanged.txt"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	filediffs, err := CompareDirs(oldDir, newDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Format(&buf, filediffs); err != nil {
+		t.Fatal(err)
+	}
+	want := `diff --git a/added.txt b/added.txt
+new file mode 100644
+--- /dev/null
++++ b/added.txt
+@@ -0,0 +1 @@
++added
+diff --git a/binary.dat b/binary.dat
+Binary files a/binary.dat and b/binary.dat differ
+diff --git a/removed.txt b/removed.txt
+deleted file mode 100644
+--- a/removed.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-removed
+diff --git a/sub/changed.txt b/sub/changed.txt
+--- a/sub/changed.txt
++++ b/sub/changed.txt
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+diff --git a/unchanged.txt b/unchanged.txt
+old mode 100644
+new mode 100755
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
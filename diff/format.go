@@ -0,0 +1,157 @@
+package diff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format writes file diffs in unified diff format which ParseMultiFile can
+// parse.
+func Format(w io.Writer, filediffs []*FileDiff) error {
+	bw := bufio.NewWriter(w)
+	for _, fd := range filediffs {
+		formatFile(bw, fd)
+	}
+	return bw.Flush()
+}
+
+func formatFile(w *bufio.Writer, fd *FileDiff) {
+	for _, e := range fd.Extended {
+		fmt.Fprintln(w, e)
+	}
+	if len(fd.Hunks) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s %s\n", tokenOldFile, formatFileHeader(fd.PathOld, fd.TimeOld))
+	fmt.Fprintf(w, "%s %s\n", tokenNewFile, formatFileHeader(fd.PathNew, fd.TimeNew))
+	lastOld, lastNew := lastLines(fd.Hunks[len(fd.Hunks)-1])
+	for _, h := range fd.Hunks {
+		formatHunkRange(w, h)
+		for _, l := range h.Lines {
+			if l.ParentTypes != nil {
+				for _, t := range l.ParentTypes {
+					w.WriteString(lineToken(t))
+				}
+			} else {
+				w.WriteString(lineToken(l.Type))
+			}
+			w.WriteString(l.Content)
+			w.WriteByte('\n')
+			if (l == lastOld && fd.NoNewlineOld) || (l == lastNew && fd.NoNewlineNew) {
+				fmt.Fprintln(w, noNewlineMarker)
+			}
+		}
+	}
+}
+
+// formatHunkRange writes "@@ -l,s +l,s @@ section" line, or "@@@ -l,s -l,s
+// +l,s @@@ section" line for a hunk of combined diff.
+func formatHunkRange(w *bufio.Writer, h *Hunk) {
+	marker := tokenStartHunk
+	if len(h.StartLineParents) > 0 {
+		marker = strings.Repeat("@", len(h.StartLineParents)+1)
+		w.WriteString(marker)
+		for i, l := range h.StartLineParents {
+			fmt.Fprintf(w, " -%s", formatRange(l, h.LineLengthParents[i]))
+		}
+	} else {
+		fmt.Fprintf(w, "%s -%s", marker, formatRange(h.StartLineOld, h.LineLengthOld))
+	}
+	fmt.Fprintf(w, " +%s %s", formatRange(h.StartLineNew, h.LineLengthNew), marker)
+	if h.Section != "" {
+		fmt.Fprintf(w, " %s", h.Section)
+	}
+	fmt.Fprintln(w)
+}
+
+func lineToken(t LineType) string {
+	switch t {
+	case LineAdded:
+		return tokenAddedLine
+	case LineDeleted:
+		return tokenDeletedLine
+	}
+	return tokenUnchangedLine
+}
+
+const noNewlineMarker = tokenNoNewlineAtEOF + " No newline at end of file"
+
+// lastLines returns the last lines of the old and new file in the hunk.
+func lastLines(h *Hunk) (lastOld, lastNew *Line) {
+	for _, l := range h.Lines {
+		if l.Type != LineAdded {
+			lastOld = l
+		}
+		if l.Type != LineDeleted {
+			lastNew = l
+		}
+	}
+	return lastOld, lastNew
+}
+
+func formatFileHeader(path, timestamp string) string {
+	if timestamp == "" {
+		// git appends a tab to a path with spaces to terminate it.
+		if strings.Contains(path, " ") && QuotePath(path) == path {
+			return path + "\t"
+		}
+		return QuotePath(path)
+	}
+	return QuotePath(path) + "\t" + timestamp
+}
+
+// formatRange formats l[,s] of hunk range. s is omitted if it's 1.
+func formatRange(l, s int) string {
+	if s == 1 {
+		return fmt.Sprint(l)
+	}
+	return fmt.Sprintf("%d,%d", l, s)
+}
+
+// QuotePath quotes name in the same way as git if it contains special
+// characters. It's the reverse of unquoteCStyle.
+func QuotePath(name string) string {
+	needsQuote := false
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; c < 0x20 || c == '"' || c == '\\' || c >= 0x7f {
+			needsQuote = true
+			break
+		}
+	}
+	if !needsQuote {
+		return name
+	}
+	res := make([]byte, 0, len(name)+2)
+	res = append(res, '"')
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; c {
+		case '\a':
+			res = append(res, `\a`...)
+		case '\b':
+			res = append(res, `\b`...)
+		case '\t':
+			res = append(res, `\t`...)
+		case '\n':
+			res = append(res, `\n`...)
+		case '\v':
+			res = append(res, `\v`...)
+		case '\f':
+			res = append(res, `\f`...)
+		case '\r':
+			res = append(res, `\r`...)
+		case '"':
+			res = append(res, `\"`...)
+		case '\\':
+			res = append(res, `\\`...)
+		default:
+			if c < 0x20 || c >= 0x7f {
+				res = append(res, fmt.Sprintf(`\%03o`, c)...)
+			} else {
+				res = append(res, c)
+			}
+		}
+	}
+	return string(append(res, '"'))
+}
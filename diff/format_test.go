@@ -0,0 +1,50 @@
+package diff
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFormat(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fname := range files {
+		f, err := os.Open(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := ParseMultiFile(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := Format(&buf, want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseMultiFile(&buf)
+		if err != nil {
+			t.Fatalf("%s: %v", fname, err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%s: parsing formatted diff has diff:\n%s", fname, diff)
+		}
+	}
+}
+
+func TestQuotePath(t *testing.T) {
+	for _, name := range []string{"a.txt", "empty space.txt", "日本語.txt", "tab\there", `quote"and\backslash`} {
+		if got := unquoteCStyle(QuotePath(name)); got != name {
+			t.Errorf("unquoteCStyle(QuotePath(%q)) = %q", name, got)
+		}
+	}
+	if got, want := QuotePath("日本語"), `"\346\227\245\346\234\254\350\252\236"`; got != want {
+		t.Errorf("QuotePath(%q) = %s, want %s", "日本語", got, want)
+	}
+}
@@ -0,0 +1,146 @@
+package diff
+
+import (
+	"bufio"
+	"io"
+	"sort"
+)
+
+// ScanMultiFile parses a multi-file unified diff in the same way as
+// ParseMultiFile, but it doesn't keep lines of hunks to reduce memory usage
+// for huge diff. Hunks of returned file diffs don't have Lines and Index is
+// set instead, so they cannot be formatted by Format.
+func ScanMultiFile(r io.Reader) ([]*FileDiff, error) {
+	br := bufio.NewReader(r)
+	var fds []*FileDiff
+	for {
+		index := &LineIndex{}
+		fd, err := (&fileParser{r: br, onLine: index.add}).Parse()
+		if err != nil || fd == nil {
+			break
+		}
+		fd.Index = index
+		fds = append(fds, fd)
+	}
+	return fds, nil
+}
+
+// LineIndex is a compact index of lines in hunks of a file diff. It keeps
+// only line number intervals instead of lines.
+type LineIndex struct {
+	// line numbers of the new file in hunks. i.e. unchanged and added lines.
+	InHunk LineSet
+	// line numbers of the new file of added lines.
+	Added LineSet
+
+	// runs of unchanged lines to map line numbers of the new file to the old
+	// file.
+	unchanged []unchangedRun
+}
+
+// unchangedRun represents n unchanged lines which start from newStart in the
+// new file and oldStart in the old file.
+type unchangedRun struct {
+	newStart, oldStart, n int
+}
+
+func (x *LineIndex) add(l *Line) {
+	if l.LnumNew == 0 {
+		return // deleted line
+	}
+	x.InHunk.Add(l.LnumNew)
+	if l.Type == LineAdded {
+		x.Added.Add(l.LnumNew)
+		return
+	}
+	if l.LnumOld == 0 {
+		return // unchanged line of combined diff which is not in the first parent.
+	}
+	if n := len(x.unchanged); n > 0 {
+		if last := &x.unchanged[n-1]; last.newStart+last.n == l.LnumNew && last.oldStart+last.n == l.LnumOld {
+			last.n++
+			return
+		}
+	}
+	x.unchanged = append(x.unchanged, unchangedRun{newStart: l.LnumNew, oldStart: l.LnumOld, n: 1})
+}
+
+// Line returns the line of the new file in hunks, or nil if it's not in
+// hunks. Content and LnumDiff of the returned line are not set.
+func (x *LineIndex) Line(lnum int) *Line {
+	if !x.InHunk.Contains(lnum) {
+		return nil
+	}
+	if x.Added.Contains(lnum) {
+		return &Line{Type: LineAdded, LnumNew: lnum}
+	}
+	line := &Line{Type: LineUnchanged, LnumNew: lnum}
+	i := sort.Search(len(x.unchanged), func(i int) bool {
+		return x.unchanged[i].newStart+x.unchanged[i].n > lnum
+	})
+	if i < len(x.unchanged) && x.unchanged[i].newStart <= lnum {
+		line.LnumOld = x.unchanged[i].oldStart + lnum - x.unchanged[i].newStart
+	}
+	return line
+}
+
+// LineSet is a set of line numbers. It's represented by sorted intervals, so
+// consecutive lines (e.g. an added file) take constant memory.
+type LineSet struct {
+	// sorted, non-overlapping and non-adjacent intervals.
+	intervals []LineRange
+}
+
+// LineRange represents lines from Start to End (inclusive).
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// Add adds a line number. It's fast to add lines in ascending order.
+func (s *LineSet) Add(lnum int) {
+	n := len(s.intervals)
+	i := n // Append a new interval by default.
+	if n > 0 && s.intervals[n-1].End+1 >= lnum {
+		// The first interval which ends at lnum-1 or later.
+		i = sort.Search(n, func(i int) bool { return s.intervals[i].End+1 >= lnum })
+		iv := &s.intervals[i]
+		switch {
+		case iv.Start <= lnum && lnum <= iv.End:
+			return
+		case iv.End+1 == lnum:
+			iv.End = lnum
+			if i+1 < n && s.intervals[i+1].Start == lnum+1 {
+				iv.End = s.intervals[i+1].End
+				s.intervals = append(s.intervals[:i+1], s.intervals[i+2:]...)
+			}
+			return
+		case iv.Start == lnum+1:
+			iv.Start = lnum
+			return
+		}
+	}
+	s.intervals = append(s.intervals, LineRange{})
+	copy(s.intervals[i+1:], s.intervals[i:])
+	s.intervals[i] = LineRange{Start: lnum, End: lnum}
+}
+
+// Contains returns true if the set contains the line number.
+func (s *LineSet) Contains(lnum int) bool {
+	i := sort.Search(len(s.intervals), func(i int) bool { return s.intervals[i].End >= lnum })
+	return i < len(s.intervals) && s.intervals[i].Start <= lnum
+}
+
+// Ranges returns the sorted line ranges in the set.
+func (s *LineSet) Ranges() []LineRange {
+	return append([]LineRange(nil), s.intervals...)
+}
+
+// Len returns the number of lines in the set.
+func (s *LineSet) Len() int {
+	n := 0
+	for _, iv := range s.intervals {
+		n += iv.End - iv.Start + 1
+	}
+	return n
+}
@@ -0,0 +1,99 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestScanMultiFile(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fname := range files {
+		t.Run(fname, func(t *testing.T) {
+			want := parseTestFile(t, fname)
+			f, err := os.Open(fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			got, err := ScanMultiFile(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := []cmp.Option{
+				cmpopts.IgnoreFields(FileDiff{}, "Index"),
+				cmpopts.IgnoreFields(Hunk{}, "Lines"),
+			}
+			if diff := cmp.Diff(want, got, opts...); diff != "" {
+				t.Fatalf("ScanMultiFile() has diff:\n%s", diff)
+			}
+			for i, fd := range want {
+				index := got[i].Index
+				if index == nil {
+					t.Fatalf("%s: Index is nil", fd.PathNew)
+				}
+				for _, hunk := range got[i].Hunks {
+					if hunk.Lines != nil {
+						t.Errorf("%s: lines are kept", fd.PathNew)
+					}
+				}
+				inHunk := 0
+				for _, hunk := range fd.Hunks {
+					for _, line := range hunk.Lines {
+						if line.LnumNew == 0 {
+							continue
+						}
+						inHunk++
+						w := &Line{Type: line.Type, LnumOld: line.LnumOld, LnumNew: line.LnumNew}
+						if diff := cmp.Diff(w, index.Line(line.LnumNew)); diff != "" {
+							t.Errorf("%s: Line(%d) has diff:\n%s", fd.PathNew, line.LnumNew, diff)
+						}
+					}
+				}
+				if got := index.InHunk.Len(); got != inHunk {
+					t.Errorf("%s: InHunk.Len() = %d, want %d", fd.PathNew, got, inHunk)
+				}
+			}
+		})
+	}
+}
+
+func parseTestFile(t *testing.T, fname string) []*FileDiff {
+	t.Helper()
+	f, err := os.Open(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fds, err := ParseMultiFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fds
+}
+
+func TestLineSet(t *testing.T) {
+	var s LineSet
+	for _, l := range []int{1, 2, 3, 10, 12, 7, 5, 6, 11, 4, 20, 2, 8} {
+		s.Add(l)
+	}
+	want := []LineRange{{1, 8}, {10, 12}, {20, 20}}
+	if diff := cmp.Diff(want, s.Ranges()); diff != "" {
+		t.Errorf("Ranges() has diff:\n%s", diff)
+	}
+	for l := 0; l <= 21; l++ {
+		want := (1 <= l && l <= 8) || (10 <= l && l <= 12) || l == 20
+		if got := s.Contains(l); got != want {
+			t.Errorf("Contains(%d) = %v, want %v", l, got, want)
+		}
+	}
+	if got := s.Len(); got != 12 {
+		t.Errorf("Len() = %d, want 12", got)
+	}
+}
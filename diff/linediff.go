@@ -0,0 +1,310 @@
+package diff
+
+import (
+	"bytes"
+	"strings"
+)
+
+// DefaultContextLines is the default number of context lines around changes
+// in generated hunks. It's the same as git diff and diff -u.
+const DefaultContextLines = 3
+
+// Hunks returns hunks of unified diff which changes old content to new
+// content with given number of context lines. LnumDiff of lines are
+// positions in the file diff as ParseFile sets.
+func Hunks(old, new []byte, context int) []*Hunk {
+	a, b := splitLines(old), splitLines(new)
+	ops := editScript(a, b)
+	return buildHunks(ops, context)
+}
+
+// SetHunks sets Hunks of fd which changes old content to new content, and
+// NoNewlineOld and NoNewlineNew if the last line without newline is in hunks.
+func (fd *FileDiff) SetHunks(old, new []byte, context int) {
+	fd.Hunks = Hunks(old, new, context)
+	fd.NoNewlineOld, fd.NoNewlineNew = false, false
+	if len(fd.Hunks) == 0 {
+		return
+	}
+	lastOld, lastNew := lastLines(fd.Hunks[len(fd.Hunks)-1])
+	if lastOld != nil && len(old) > 0 && old[len(old)-1] != '\n' {
+		fd.NoNewlineOld = lastOld.LnumOld == bytes.Count(old, []byte("\n"))+1
+	}
+	if lastNew != nil && len(new) > 0 && new[len(new)-1] != '\n' {
+		fd.NoNewlineNew = lastNew.LnumNew == bytes.Count(new, []byte("\n"))+1
+	}
+}
+
+// splitLines splits content into lines including line terminators, so that
+// a last line without newline differs from the same line with newline.
+func splitLines(content []byte) []string {
+	var lines []string
+	for len(content) > 0 {
+		i := bytes.IndexByte(content, '\n')
+		if i < 0 {
+			lines = append(lines, string(content))
+			break
+		}
+		lines = append(lines, string(content[:i+1]))
+		content = content[i+1:]
+	}
+	return lines
+}
+
+type editOp struct {
+	typ     LineType
+	content string
+}
+
+// editScript returns the shortest edit script which changes a to b.
+// Deleted lines come before added lines in each changed block.
+func editScript(a, b []string) []editOp {
+	// Intern lines to compare ints instead of strings.
+	ids := make(map[string]int)
+	intern := func(lines []string) []int {
+		r := make([]int, len(lines))
+		for i, l := range lines {
+			id, ok := ids[l]
+			if !ok {
+				id = len(ids)
+				ids[l] = id
+			}
+			r[i] = id
+		}
+		return r
+	}
+	m := &myers{a: intern(a), b: intern(b), deleted: make([]bool, len(a)), added: make([]bool, len(b))}
+	size := 2*(len(a)+len(b)) + 3
+	m.vf, m.vb = make([]int, size), make([]int, size)
+	m.compare(0, len(a), 0, len(b))
+	slideDown(m.a, m.deleted)
+	slideDown(m.b, m.added)
+
+	ops := make([]editOp, 0, len(a)+len(b))
+	for i, j := 0, 0; i < len(a) || j < len(b); {
+		switch {
+		case i < len(a) && m.deleted[i]:
+			ops = append(ops, editOp{typ: LineDeleted, content: a[i]})
+			i++
+		case j < len(b) && m.added[j]:
+			ops = append(ops, editOp{typ: LineAdded, content: b[j]})
+			j++
+		default:
+			ops = append(ops, editOp{typ: LineUnchanged, content: b[j]})
+			i++
+			j++
+		}
+	}
+	return ops
+}
+
+// slideDown slides each block of changed lines down as far as the line after
+// the block equals the first line of the block, as git and GNU diff do. e.g.
+// an added function is shown with its closing brace rather than with the
+// closing brace of the previous function.
+func slideDown(lines []int, changed []bool) {
+	for s := 0; s < len(lines); {
+		if !changed[s] {
+			s++
+			continue
+		}
+		e := s
+		for e < len(lines) && changed[e] {
+			e++
+		}
+		for e < len(lines) && lines[s] == lines[e] {
+			changed[s], changed[e] = false, true
+			s++
+			e++
+			// Merge with the following block.
+			for e < len(lines) && changed[e] {
+				e++
+			}
+		}
+		s = e
+	}
+}
+
+// myers implements the linear space variant of "An O(ND) Difference
+// Algorithm and Its Variations" by Eugene W. Myers.
+type myers struct {
+	a, b           []int
+	deleted, added []bool
+	// vf and vb hold the furthest reaching x of forward and reverse paths
+	// for each diagonal.
+	vf, vb []int
+}
+
+func (m *myers) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && m.a[aLo] == m.b[bLo] {
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && m.a[aHi-1] == m.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+	switch {
+	case aLo == aHi:
+		for j := bLo; j < bHi; j++ {
+			m.added[j] = true
+		}
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			m.deleted[i] = true
+		}
+	default:
+		// Both ranges are non-empty and differ at both ends, so the edit
+		// distance is at least 2 and the split point is strictly inside.
+		x, y := m.split(aLo, aHi, bLo, bHi)
+		m.compare(aLo, x, bLo, y)
+		m.compare(x, aHi, y, bHi)
+	}
+}
+
+// split returns a point on a shortest edit path from (aLo, bLo) to (aHi, bHi)
+// which splits the edit distance into halves.
+func (m *myers) split(aLo, aHi, bLo, bHi int) (int, int) {
+	n, mm := aHi-aLo, bHi-bLo
+	delta := n - mm
+	odd := delta&1 != 0
+	off := n + mm + 1
+	m.vf[off+1] = 0
+	m.vb[off+1] = 0
+	for d := 0; d <= (n+mm+1)/2; d++ {
+		// Forward path. k = x - y.
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && m.vf[off+k-1] < m.vf[off+k+1]) {
+				x = m.vf[off+k+1]
+			} else {
+				x = m.vf[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < mm && m.a[aLo+x] == m.b[bLo+y] {
+				x++
+				y++
+			}
+			m.vf[off+k] = x
+			// The reverse path on diagonal delta-k has done d-1 steps.
+			if kr := delta - k; odd && -(d-1) <= kr && kr <= d-1 && x+m.vb[off+kr] >= n {
+				return aLo + x, bLo + y
+			}
+		}
+		// Reverse path on reversed sequences. u = n - x, v = mm - y and
+		// kr = u - v.
+		for kr := -d; kr <= d; kr += 2 {
+			var u int
+			if kr == -d || (kr != d && m.vb[off+kr-1] < m.vb[off+kr+1]) {
+				u = m.vb[off+kr+1]
+			} else {
+				u = m.vb[off+kr-1] + 1
+			}
+			v := u - kr
+			for u < n && v < mm && m.a[aHi-1-u] == m.b[bHi-1-v] {
+				u++
+				v++
+			}
+			m.vb[off+kr] = u
+			if k := delta - kr; !odd && -d <= k && k <= d && u+m.vf[off+k] >= n {
+				return aHi - u, bHi - v
+			}
+		}
+	}
+	// Should not happen.
+	return aLo + n/2, bLo + mm/2
+}
+
+// buildHunks groups edit operations into hunks with context lines.
+func buildHunks(ops []editOp, context int) []*Hunk {
+	// Line numbers of old and new file at each operation.
+	startOld, startNew := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	startOld[0], startNew[0] = 1, 1
+	for i, op := range ops {
+		startOld[i+1], startNew[i+1] = startOld[i], startNew[i]
+		if op.typ != LineAdded {
+			startOld[i+1]++
+		}
+		if op.typ != LineDeleted {
+			startNew[i+1]++
+		}
+	}
+	var hunks []*Hunk
+	lnumdiff := 0
+	for i := 0; i < len(ops); {
+		if ops[i].typ == LineUnchanged {
+			i++
+			continue
+		}
+		// Find the end of the hunk. Changes separated by at most 2*context
+		// unchanged lines are in the same hunk.
+		start := maxInt(i-context, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].typ != LineUnchanged {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].typ == LineUnchanged {
+				next++
+			}
+			if next == len(ops) || next-end > 2*context {
+				break
+			}
+			end = next
+		}
+		end = minInt(end+context, len(ops))
+
+		lold, lnew := startOld[start], startNew[start]
+		h := &Hunk{StartLineOld: lold, StartLineNew: lnew}
+		for _, op := range ops[start:end] {
+			lnumdiff++
+			l := &Line{Type: op.typ, Content: trimNewline(op.content), LnumDiff: lnumdiff}
+			switch op.typ {
+			case LineUnchanged:
+				l.LnumOld, l.LnumNew = lold, lnew
+				lold++
+				lnew++
+			case LineAdded:
+				l.LnumNew = lnew
+				lnew++
+			case LineDeleted:
+				l.LnumOld = lold
+				lold++
+			}
+			h.Lines = append(h.Lines, l)
+		}
+		h.LineLengthOld = lold - h.StartLineOld
+		h.LineLengthNew = lnew - h.StartLineNew
+		// An empty range starts at the line before the range as diff -u does.
+		if h.LineLengthOld == 0 {
+			h.StartLineOld--
+		}
+		if h.LineLengthNew == 0 {
+			h.StartLineNew--
+		}
+		hunks = append(hunks, h)
+		lnumdiff++ // count up by an additional hunk
+		i = end
+	}
+	return hunks
+}
+
+func trimNewline(s string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(s, "\n"), "\r")
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,92 @@
+package diff
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestHunks(t *testing.T) {
+	for _, name := range []string{"sample", "golint", "日本語"} {
+		t.Run(name, func(t *testing.T) {
+			ext := ".txt"
+			if name == "golint" {
+				ext = ".go"
+			}
+			old, err := ioutil.ReadFile("testdata/" + name + ".old" + ext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			new, err := ioutil.ReadFile("testdata/" + name + ".new" + ext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.Open("testdata/" + name + ".diff")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			want, err := ParseFile(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := Hunks(old, new, DefaultContextLines)
+			if diff := cmp.Diff(want.Hunks, got, cmpopts.IgnoreFields(Hunk{}, "Section")); diff != "" {
+				t.Errorf("Hunks() has diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHunks_apply(t *testing.T) {
+	tests := []struct {
+		old, new string
+	}{
+		{old: "", new: "a\nb\n"},
+		{old: "a\nb\n", new: ""},
+		{old: "a\nb\nc\n", new: "a\nc\n"},
+		{old: "a\nb\nc\n", new: "c\nb\na\n"},
+		{old: "a\nb", new: "a\nb\n"},
+		{old: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", new: "0\n1\n2\n3\n5\n6\n7\n8\n9\nx\n"},
+		{old: "}\n\nfunc a() {\n}\n", new: "}\n\nfunc a() {\n}\n\nfunc b() {\n}\n"},
+	}
+	for _, tt := range tests {
+		for _, context := range []int{0, 1, 3} {
+			hunks := Hunks([]byte(tt.old), []byte(tt.new), context)
+			if got := applyHunks(strings.Split(tt.old, "\n"), hunks); got != strings.TrimSuffix(tt.new, "\n") {
+				t.Errorf("applying Hunks(%q, %q, %d) = %q", tt.old, tt.new, context, got)
+			}
+		}
+	}
+}
+
+// applyHunks applies hunks to lines ignoring differences of the last newline.
+func applyHunks(lines []string, hunks []*Hunk) string {
+	var res []string
+	i := 0 // index of lines
+	for _, h := range hunks {
+		start := h.StartLineOld - 1
+		if h.LineLengthOld == 0 {
+			start++
+		}
+		res = append(res, lines[i:start]...)
+		i = start
+		for _, l := range h.Lines {
+			switch l.Type {
+			case LineUnchanged:
+				res = append(res, lines[i])
+				i++
+			case LineDeleted:
+				i++
+			case LineAdded:
+				res = append(res, l.Content)
+			}
+		}
+	}
+	res = append(res, lines[i:]...)
+	return strings.TrimSuffix(strings.Join(res, "\n"), "\n")
+}
@@ -66,11 +66,16 @@ func ParseFile(r io.Reader) (*FileDiff, error) {
 
 type fileParser struct {
 	r *bufio.Reader
+
+	// onLine is passed to hunkParser.
+	onLine func(*Line)
 }
 
 func (p *fileParser) Parse() (*FileDiff, error) {
 	fd := &FileDiff{}
 	fd.Extended = parseExtendedHeader(p.r)
+	renameFrom, renameTo := parseExtendedHeaderFields(fd)
+	defer setPathsFromExtendedHeader(fd, renameFrom, renameTo)
 	b, err := p.r.Peek(len(tokenOldFile))
 	if err != nil {
 		if err == io.EOF && len(fd.Extended) > 0 {
@@ -90,14 +95,16 @@ func (p *fileParser) Parse() (*FileDiff, error) {
 		fd.PathNew, fd.TimeNew = parseFileHeader(newline)
 	}
 	// parse hunks
-	fd.Hunks, err = p.parseHunks()
+	hp := &hunkParser{r: p.r, onLine: p.onLine}
+	fd.Hunks, err = p.parseHunks(hp)
 	if err != nil {
 		return nil, err
 	}
+	fd.NoNewlineOld, fd.NoNewlineNew = hp.noNewlineOld, hp.noNewlineNew
 	return fd, nil
 }
 
-func (p *fileParser) parseHunks() ([]*Hunk, error) {
+func (p *fileParser) parseHunks(hp *hunkParser) ([]*Hunk, error) {
 	b, err := p.r.Peek(len(tokenOldFile))
 	if err != nil {
 		return nil, ErrNoHunks
@@ -115,7 +122,6 @@ func (p *fileParser) parseHunks() ([]*Hunk, error) {
 		return nil, ErrNoHunks
 	}
 	var hunks []*Hunk
-	hp := &hunkParser{r: p.r}
 	for {
 		h, err := hp.Parse()
 		if err != nil {
@@ -231,9 +237,139 @@ func parseExtendedHeader(r *bufio.Reader) []string {
 	return es
 }
 
+// parseExtendedHeaderFields sets typed fields of fd from git extended header
+// lines. It returns unquoted paths of "rename from" and "rename to" lines.
+func parseExtendedHeaderFields(fd *FileDiff) (renameFrom, renameTo string) {
+	for _, line := range fd.Extended {
+		switch {
+		case strings.HasPrefix(line, "old mode "):
+			fd.OldMode = strings.TrimPrefix(line, "old mode ")
+		case strings.HasPrefix(line, "new mode "):
+			fd.NewMode = strings.TrimPrefix(line, "new mode ")
+		case strings.HasPrefix(line, "new file mode "):
+			fd.IsNew = true
+			fd.NewMode = strings.TrimPrefix(line, "new file mode ")
+		case strings.HasPrefix(line, "deleted file mode "):
+			fd.IsDeleted = true
+			fd.OldMode = strings.TrimPrefix(line, "deleted file mode ")
+		case strings.HasPrefix(line, "rename from "):
+			fd.IsRename = true
+			renameFrom = unquoteCStyle(strings.TrimPrefix(line, "rename from "))
+		case strings.HasPrefix(line, "rename to "):
+			fd.IsRename = true
+			renameTo = unquoteCStyle(strings.TrimPrefix(line, "rename to "))
+		case strings.HasPrefix(line, "similarity index "):
+			fd.Similarity, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"))
+		case strings.HasPrefix(line, "index "):
+			// index <hash>..<hash>[ <mode>]
+			if ss := strings.Fields(line); len(ss) == 3 && fd.OldMode == "" && fd.NewMode == "" {
+				fd.OldMode, fd.NewMode = ss[2], ss[2]
+			}
+		case strings.HasPrefix(line, "Binary files ") && strings.HasSuffix(line, " differ"),
+			line == "GIT binary patch":
+			fd.IsBinary = true
+		}
+	}
+	return renameFrom, renameTo
+}
+
+// setPathsFromExtendedHeader sets PathOld and PathNew from the "diff --git"
+// line if the file diff doesn't have "---" and "+++" lines. e.g. a renamed
+// file without changes, a mode change, a binary file and an empty file.
+func setPathsFromExtendedHeader(fd *FileDiff, renameFrom, renameTo string) {
+	if fd.PathOld != "" || fd.PathNew != "" || len(fd.Extended) == 0 {
+		return
+	}
+	const prefix = tokenDiff + " --git "
+	if !strings.HasPrefix(fd.Extended[0], prefix) {
+		return
+	}
+	fd.PathOld, fd.PathNew = splitGitDiffPaths(strings.TrimPrefix(fd.Extended[0], prefix), renameFrom, renameTo)
+	if fd.IsNew {
+		fd.PathOld = "/dev/null"
+	}
+	if fd.IsDeleted {
+		fd.PathNew = "/dev/null"
+	}
+}
+
+// splitGitDiffPaths splits "<old> <new>" of "diff --git <old> <new>" line.
+// Paths may contain spaces, so it uses rename paths or assumes that paths are
+// the same except their prefixes (e.g. "a/" and "b/").
+func splitGitDiffPaths(s, renameFrom, renameTo string) (string, string) {
+	if strings.HasPrefix(s, `"`) {
+		// Quoted path. Find the closing quote.
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+				continue
+			}
+			if s[i] == '"' {
+				return unquoteCStyle(s[:i+1]), unquoteCStyle(strings.TrimPrefix(s[i+1:], " "))
+			}
+		}
+		return unquoteCStyle(s), ""
+	}
+	var candidates [][2]string
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' {
+			candidates = append(candidates, [2]string{s[:i], unquoteCStyle(s[i+1:])})
+		}
+	}
+	for _, c := range candidates {
+		if renameFrom != "" || renameTo != "" {
+			if hasPathSuffix(c[0], renameFrom) && hasPathSuffix(c[1], renameTo) {
+				return c[0], c[1]
+			}
+			continue
+		}
+		if stripFirstDir(c[0]) == stripFirstDir(c[1]) {
+			return c[0], c[1]
+		}
+	}
+	if len(candidates) > 0 {
+		c := candidates[len(candidates)/2]
+		return c[0], c[1]
+	}
+	return s, ""
+}
+
+func hasPathSuffix(path, suffix string) bool {
+	return path == suffix || strings.HasSuffix(path, "/"+suffix)
+}
+
+func stripFirstDir(path string) string {
+	if i := strings.Index(path, "/"); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
 type hunkParser struct {
 	r        *bufio.Reader
 	lnumdiff int
+
+	// true if "\ No newline at end of file" follows a line of the old or new
+	// file.
+	noNewlineOld bool
+	noNewlineNew bool
+
+	// onLine is called for each line instead of adding it to Hunk.Lines if
+	// it's set. It's used to scan diff without keeping lines.
+	onLine func(*Line)
+	// the last parsed line of the current hunk.
+	last *Line
+}
+
+// addLine adds the line to the hunk, or passes it to onLine instead if it's
+// set.
+func (p *hunkParser) addLine(hunk *Hunk, line *Line) {
+	p.last = line
+	if p.onLine != nil {
+		p.onLine(line)
+		return
+	}
+	hunk.Lines = append(hunk.Lines, line)
 }
 
 func (p *hunkParser) Parse() (*Hunk, error) {
@@ -241,6 +377,10 @@ func (p *hunkParser) Parse() (*Hunk, error) {
 		return nil, nil
 	}
 	rangeline, _ := readline(p.r)
+	p.last = nil
+	if strings.HasPrefix(rangeline, tokenStartHunk+"@") {
+		return p.parseCombined(rangeline)
+	}
 	hr, err := parseHunkRange(rangeline)
 	if err != nil {
 		return nil, err
@@ -285,10 +425,20 @@ endhunk:
 				line.LnumOld = lold
 				lold++
 			}
-			hunk.Lines = append(hunk.Lines, line)
+			p.addLine(hunk, line)
 		case tokenNoNewlineAtEOF:
-			// skip \ No newline at end of file. just consume line
+			// \ No newline at end of file. It applies to the previous line.
 			readline(p.r)
+			if p.last != nil {
+				switch p.last.Type {
+				case LineUnchanged:
+					p.noNewlineOld, p.noNewlineNew = true, true
+				case LineDeleted:
+					p.noNewlineOld = true
+				case LineAdded:
+					p.noNewlineNew = true
+				}
+			}
 		default:
 			break endhunk
 		}
@@ -297,6 +447,111 @@ endhunk:
 	return hunk, nil
 }
 
+// parseCombined parses a hunk of combined diff. Each line has a column per
+// parent and the line is added only if it's added relative to all parents.
+func (p *hunkParser) parseCombined(rangeline string) (*Hunk, error) {
+	hr, err := parseCombinedHunkRange(rangeline)
+	if err != nil {
+		return nil, err
+	}
+	n := len(hr.parents)
+	hunk := &Hunk{
+		StartLineOld:  hr.parents[0].l,
+		LineLengthOld: hr.parents[0].s,
+		StartLineNew:  hr.lnew,
+		LineLengthNew: hr.snew,
+		Section:       hr.section,
+	}
+	for _, pr := range hr.parents {
+		hunk.StartLineParents = append(hunk.StartLineParents, pr.l)
+		hunk.LineLengthParents = append(hunk.LineLengthParents, pr.s)
+	}
+	lolds := make([]int, n)
+	for i, pr := range hr.parents {
+		lolds[i] = pr.l
+	}
+	lnew := hr.lnew
+	for !p.doneCombined(lolds, lnew, hr) {
+		b, err := p.r.Peek(n)
+		if err != nil || !isCombinedToken(b) {
+			if len(b) > 0 && string(b[:1]) == tokenNoNewlineAtEOF {
+				readline(p.r)
+				if p.last != nil {
+					if p.last.Type == LineDeleted {
+						p.noNewlineOld = true
+					} else {
+						p.noNewlineNew = true
+					}
+				}
+				continue
+			}
+			break
+		}
+		p.lnumdiff++
+		l, _ := readline(p.r)
+		line := &Line{Content: l[n:], LnumDiff: p.lnumdiff, ParentTypes: make([]LineType, n)}
+		deleted := bytes.IndexByte(b, tokenDeletedLine[0]) >= 0
+		addedToAll := true
+		for i, c := range b {
+			switch string(c) {
+			case tokenAddedLine:
+				line.ParentTypes[i] = LineAdded
+			case tokenDeletedLine:
+				line.ParentTypes[i] = LineDeleted
+				lolds[i]++
+				addedToAll = false
+			default:
+				line.ParentTypes[i] = LineUnchanged
+				addedToAll = false
+				if !deleted {
+					lolds[i]++
+				}
+			}
+		}
+		switch {
+		case deleted:
+			line.Type = LineDeleted
+			if line.ParentTypes[0] == LineDeleted {
+				line.LnumOld = lolds[0] - 1
+			}
+		case addedToAll:
+			line.Type = LineAdded
+			line.LnumNew = lnew
+			lnew++
+		default:
+			line.Type = LineUnchanged
+			if line.ParentTypes[0] == LineUnchanged {
+				line.LnumOld = lolds[0] - 1
+			}
+			line.LnumNew = lnew
+			lnew++
+		}
+		p.addLine(hunk, line)
+	}
+	p.lnumdiff++ // count up by an additional hunk
+	return hunk, nil
+}
+
+func isCombinedToken(b []byte) bool {
+	for _, c := range b {
+		if c != tokenUnchangedLine[0] && c != tokenAddedLine[0] && c != tokenDeletedLine[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *hunkParser) doneCombined(lolds []int, lnew int, hr *combinedHunkRange) bool {
+	end := lnew >= hr.lnew+hr.snew
+	for i, pr := range hr.parents {
+		end = end && lolds[i] >= pr.l+pr.s
+	}
+	if b, err := p.r.Peek(1); err != nil || (string(b) != tokenNoNewlineAtEOF && end) {
+		return true
+	}
+	return false
+}
+
 func (p *hunkParser) done(lold, lnew int, hr *hunkrange) bool {
 	end := lold >= hr.lold+hr.sold && lnew >= hr.lnew+hr.snew
 	if b, err := p.r.Peek(1); err != nil || (string(b) != tokenNoNewlineAtEOF && end) {
@@ -346,6 +601,55 @@ func parseHunkRange(rangeline string) (*hunkrange, error) {
 	return hunkrange, nil
 }
 
+// combinedHunkRange is a hunk range of combined diff.
+// @@@ -l,s -l,s +l,s @@@ optional section heading
+type combinedHunkRange struct {
+	parents    []lineRange
+	lnew, snew int
+	section    string
+}
+
+type lineRange struct{ l, s int }
+
+// @@@ -l[,s] -l[,s] +lnew[,snew] @@@[ section]
+// The number of '@' is the number of parents + 1.
+func parseCombinedHunkRange(rangeline string) (*combinedHunkRange, error) {
+	invalidErr := &ErrInvalidHunkRange{invalid: rangeline}
+	i := strings.IndexByte(rangeline, ' ')
+	if i < 0 || strings.Trim(rangeline[:i], "@") != "" {
+		return nil, invalidErr
+	}
+	marker := rangeline[:i]
+	n := len(marker) - 1
+	ps := strings.SplitN(rangeline, " ", n+4)
+	if len(ps) < n+3 || ps[n+2] != marker {
+		return nil, invalidErr
+	}
+	hr := &combinedHunkRange{}
+	for _, r := range ps[1 : n+1] {
+		if !strings.HasPrefix(r, "-") {
+			return nil, invalidErr
+		}
+		l, s, err := parseLS(r[1:])
+		if err != nil {
+			return nil, invalidErr
+		}
+		hr.parents = append(hr.parents, lineRange{l: l, s: s})
+	}
+	if !strings.HasPrefix(ps[n+1], "+") {
+		return nil, invalidErr
+	}
+	lnew, snew, err := parseLS(ps[n+1][1:])
+	if err != nil {
+		return nil, invalidErr
+	}
+	hr.lnew, hr.snew = lnew, snew
+	if len(ps) == n+4 {
+		hr.section = ps[n+3]
+	}
+	return hr, nil
+}
+
 // l[,s]
 func parseLS(ls string) (l, s int, err error) {
 	ss := strings.SplitN(ls, ",", 2)
@@ -123,6 +123,8 @@ func TestParseMultiFile_sample(t *testing.T) {
 					},
 				},
 			},
+			NoNewlineOld: true,
+			NoNewlineNew: true,
 		},
 	}
 	if !reflect.DeepEqual(got, want) {
@@ -154,11 +156,75 @@ deleted file mode 100644
 index e69de29..0000000
 `,
 			want: &FileDiff{
+				PathOld: "a/empty.txt",
+				PathNew: "/dev/null",
 				Extended: []string{
 					"diff --git a/empty.txt b/empty.txtq",
 					"deleted file mode 100644",
 					"index e69de29..0000000",
 				},
+				OldMode:   "100644",
+				IsDeleted: true,
+			},
+		},
+		{
+			in: `diff --git a/old name.txt b/new name.txt
+old mode 100644
+new mode 100755
+similarity index 100%
+rename from old name.txt
+rename to new name.txt
+`,
+			want: &FileDiff{
+				PathOld: "a/old name.txt",
+				PathNew: "b/new name.txt",
+				Extended: []string{
+					"diff --git a/old name.txt b/new name.txt",
+					"old mode 100644",
+					"new mode 100755",
+					"similarity index 100%",
+					"rename from old name.txt",
+					"rename to new name.txt",
+				},
+				OldMode:    "100644",
+				NewMode:    "100755",
+				IsRename:   true,
+				Similarity: 100,
+			},
+		},
+		{
+			in: `diff --git "a/\346\227\245 b.dat" "b/\346\227\245 b.dat"
+index 20b5be9..88f3700 100644
+Binary files "a/\346\227\245 b.dat" and "b/\346\227\245 b.dat" differ
+`,
+			want: &FileDiff{
+				PathOld: "a/日 b.dat",
+				PathNew: "b/日 b.dat",
+				Extended: []string{
+					`diff --git "a/\346\227\245 b.dat" "b/\346\227\245 b.dat"`,
+					"index 20b5be9..88f3700 100644",
+					`Binary files "a/\346\227\245 b.dat" and "b/\346\227\245 b.dat" differ`,
+				},
+				OldMode:  "100644",
+				NewMode:  "100644",
+				IsBinary: true,
+			},
+		},
+		{
+			in: `diff --git a/a b c/d b/a b c/d
+old mode 100644
+new mode 100755
+`,
+			want: &FileDiff{
+				PathOld: "a/a b c/d",
+				PathNew: "b/a b c/d",
+				Extended: []string{
+					"diff --git a/a b c/d b/a b c/d",
+					"old mode 100644",
+					"new mode 100755",
+				},
+				OldMode: "100644",
+				NewMode: "100755",
 			},
 		},
 		{
@@ -413,6 +479,36 @@ func TestParseHunkRange(t *testing.T) {
 	}
 }
 
+func TestParseCombinedHunkRange(t *testing.T) {
+	tests := []struct {
+		in   string
+		want *combinedHunkRange
+	}{
+		{
+			in:   "@@@ -1,7 -1,10 +1,13 @@@",
+			want: &combinedHunkRange{parents: []lineRange{{1, 7}, {1, 10}}, lnew: 1, snew: 13},
+		},
+		{
+			in:   "@@@@ -1 -2,0 -3,2 +1,4 @@@@ func a() {",
+			want: &combinedHunkRange{parents: []lineRange{{1, 1}, {2, 0}, {3, 2}}, lnew: 1, snew: 4, section: "func a() {"},
+		},
+	}
+	for _, tt := range tests {
+		got, err := parseCombinedHunkRange(tt.in)
+		if err != nil {
+			t.Errorf("parseCombinedHunkRange(%v) got an unexpected err %v", tt.in, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseCombinedHunkRange(%v) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"@@@ -1,7 +1,13 @@@", "@@@ -1,7 -1 +1 @@", "@@@@"} {
+		if _, err := parseCombinedHunkRange(in); err == nil {
+			t.Errorf("parseCombinedHunkRange(%v) got no error", in)
+		}
+	}
+}
+
 func TestParseLS(t *testing.T) {
 	tests := []struct {
 		in string
@@ -13,3 +13,5 @@ git diff --no-index empty.txt /dev/null > newline_and_empty_deleted.diff
 git diff --no-index golint.{old,new}.go >> newline_and_empty_deleted.diff
 git -c core.quotepath=true diff --no-index 日本語.{old,new}.txt > 日本語.diff
 gofmt -d gofmt.go > gofmt.diff
+# combined.diff is `git show --format= <merge commit>` of a merge commit which
+# resolves a conflict in combined.go.
@@ -1,12 +1,20 @@
 package reviewdog
 
 import (
+	"bytes"
 	"context"
 	"io/ioutil"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/reviewdog/reviewdog/diff"
 )
 
 func TestDiffString(t *testing.T) {
@@ -65,3 +73,81 @@ func TestDiffCmd(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestDiffDirs(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "reviewdog-diffdirs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	for dir, content := range map[string]string{"old": "a\nb\n", "new": "a\nc\n"} {
+		if err := os.MkdirAll(filepath.Join(tmp, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(tmp, dir, "x.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := NewDiffDirs(filepath.Join(tmp, "old"), filepath.Join(tmp, "new"))
+	b, err := d.Diff(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `diff --git a/x.txt b/x.txt
+--- a/x.txt
++++ b/x.txt
+@@ -1,2 +1,2 @@
+ a
+-b
++c
+`
+	if got := string(b); got != want {
+		t.Errorf("got:\n%v\nwant:\n%v", got, want)
+	}
+	filediffs, err := FileDiffs(context.Background(), d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filediffs) != 1 || filediffs[0].PathNew != "b/x.txt" {
+		t.Errorf("FileDiffs() = %v, want diff of b/x.txt", filediffs)
+	}
+	if d.Strip() != 1 {
+		t.Errorf("Strip() = %d, want 1", d.Strip())
+	}
+}
+
+func TestStreamingDiff(t *testing.T) {
+	b, err := ioutil.ReadFile("./diff/testdata/golint.diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := diff.ScanMultiFile(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("git", "diff", "--no-index", "golint.old.go", "golint.new.go")
+	cmd.Dir = "./diff/testdata"
+	for name, ds := range map[string]DiffService{
+		"cmd":    NewDiffCmd(cmd, 1),
+		"string": NewDiffString(string(b), 1),
+	} {
+		t.Run(name, func(t *testing.T) {
+			d := NewStreamingDiff(ds)
+			for i := 0; i < 2; i++ {
+				got, err := FileDiffs(context.Background(), d)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(diff.FileDiff{}, "Index")); diff != "" {
+					t.Errorf("FileDiffs() has diff:\n%s", diff)
+				}
+				if len(got) != 1 || got[0].Index == nil || got[0].Index.Added.Len() != want[0].Index.Added.Len() {
+					t.Errorf("FileDiffs() = %v, want indexed added lines", got)
+				}
+			}
+			if d.Strip() != 1 {
+				t.Errorf("Strip() = %d, want 1", d.Strip())
+			}
+		})
+	}
+}
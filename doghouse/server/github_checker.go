@@ -32,17 +32,20 @@ func (c *checkerGitHubClient) CreateCheckRun(ctx context.Context, owner, repo st
 
 func (c *checkerGitHubClient) UpdateCheckRun(ctx context.Context, owner, repo string, checkID int64, opt github.UpdateCheckRunOptions) (*github.CheckRun, error) {
 	// Retry requests because GitHub API somehow returns 401 Bad credentials from
-	// time to time...
+	// time to time... Keep this loop even though clients retry rate limit and
+	// server errors in their transport, as the transport never retries 401.
 	var err error
 	for i := 0; i < 5; i++ {
 		checkRun, resp, err1 := c.Checks.UpdateCheckRun(ctx, owner, repo, checkID, opt)
 		if err1 != nil {
 			err = err1
-			b, err1 := ioutil.ReadAll(resp.Body)
-			if err1 != nil {
-				aelog.Errorf(ctx, "failed to read error response body: %v", err1)
+			if resp != nil {
+				b, err1 := ioutil.ReadAll(resp.Body)
+				if err1 != nil {
+					aelog.Errorf(ctx, "failed to read error response body: %v", err1)
+				}
+				aelog.Errorf(ctx, "UpdateCheckRun failed: %s", string(b))
 			}
-			aelog.Errorf(ctx, "UpdateCheckRun failed: %s", string(b))
 			aelog.Debugf(ctx, "Retrying UpdateCheckRun...: %d", i+1)
 			time.Sleep(time.Second)
 			continue
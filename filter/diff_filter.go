@@ -2,10 +2,12 @@ package filter
 
 import (
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
 	"strings"
 
 	"github.com/reviewdog/reviewdog/diff"
+	"github.com/reviewdog/reviewdog/proto/rdf"
 	"github.com/reviewdog/reviewdog/service/serviceutil"
 )
 
@@ -27,6 +29,14 @@ const (
 	ModeFile
 	// ModeNoFilter doesn't filter out any results.
 	ModeNoFilter
+	// ModeHunk represents filtering by the new line range of hunks.
+	ModeHunk
+	// ModeFunction represents filtering by functions which contain changed
+	// lines. Changed lines outside functions fall back to ModeHunk.
+	ModeFunction
+	// ModeBlame represents filtering by commits which last changed lines
+	// (i.e. git blame) regardless of diff. It requires Blamer.
+	ModeBlame
 )
 
 // String implements the flag.Value interface
@@ -37,8 +47,11 @@ func (mode *Mode) String() string {
 		"diff_context",
 		"file",
 		"nofilter",
+		"hunk",
+		"function",
+		"blame",
 	}
-	if *mode < ModeDefault || *mode > ModeNoFilter {
+	if *mode < ModeDefault || *mode > ModeBlame {
 		return "Unknown mode"
 	}
 
@@ -58,6 +71,12 @@ func (mode *Mode) Set(value string) error {
 		*mode = ModeFile
 	case "nofilter":
 		*mode = ModeNoFilter
+	case "hunk":
+		*mode = ModeHunk
+	case "function":
+		*mode = ModeFunction
+	case "blame":
+		*mode = ModeBlame
 	default:
 		return fmt.Errorf("invalid mode name: %s", value)
 	}
@@ -78,6 +97,48 @@ type DiffFilter struct {
 
 	difflines difflines
 	difffiles difffiles
+
+	// source lines of files in diff which are loaded lazily for file diffs
+	// with Index and ModeFunction.
+	sources map[normalizedPath][]string
+
+	// sorted line ranges of files in diff to report for ModeHunk and
+	// ModeFunction, which are computed lazily.
+	ranges map[normalizedPath][]diff.LineRange
+
+	blamer Blamer
+
+	// the least severe severity to report. UNKNOWN_SEVERITY reports all.
+	minSeverity rdf.Severity
+}
+
+// Blamer tells whether a line of a file is last changed by commits to report
+// for ModeBlame. e.g. commits since the merge-base or by the Pull Request
+// author.
+type Blamer interface {
+	// Match returns true if the line should be reported. path is relative to
+	// the current working directory or absolute.
+	Match(path string, lnum int) bool
+}
+
+// Option is an option of DiffFilter.
+type Option func(*DiffFilter)
+
+// WithBlamer sets Blamer for ModeBlame. ModeBlame doesn't report any results
+// without Blamer.
+func WithBlamer(b Blamer) Option {
+	return func(df *DiffFilter) {
+		df.blamer = b
+	}
+}
+
+// WithMinSeverity sets the least severe severity to report (e.g. WARNING
+// reports ERROR and WARNING). Results with unknown severity are always
+// reported.
+func WithMinSeverity(s rdf.Severity) Option {
+	return func(df *DiffFilter) {
+		df.minSeverity = s
+	}
 }
 
 // difflines is a hash table of normalizedPath to line number to *diff.Line.
@@ -87,26 +148,41 @@ type difflines map[normalizedPath]map[int]*diff.Line
 type difffiles map[normalizedPath]*diff.FileDiff
 
 // NewDiffFilter creates a new DiffFilter.
-func NewDiffFilter(diff []*diff.FileDiff, strip int, cwd string, mode Mode) *DiffFilter {
+func NewDiffFilter(filediffs []*diff.FileDiff, strip int, cwd string, mode Mode, opts ...Option) *DiffFilter {
 	df := &DiffFilter{
 		strip:     strip,
 		cwd:       cwd,
 		mode:      mode,
 		difflines: make(difflines),
 		difffiles: make(difffiles),
+		sources:   make(map[normalizedPath][]string),
+		ranges:    make(map[normalizedPath][]diff.LineRange),
+	}
+	for _, opt := range opts {
+		opt(df)
 	}
 	// If cwd is empty, projectRelPath should not have any meaningful data too.
 	if cwd != "" {
 		df.projectRelPath, _ = serviceutil.GitRelWorkdir()
 	}
-	df.addDiff(diff)
+	df.addDiff(filediffs)
 	return df
 }
 
+// addDiff adds file diffs. Files which are renamed or whose modes are
+// changed without content changes are also added, so ModeFile reports results
+// in them. Deleted files are ignored.
 func (df *DiffFilter) addDiff(filediffs []*diff.FileDiff) {
 	for _, filediff := range filediffs {
+		if filediff.IsDeleted || filediff.PathNew == "/dev/null" {
+			continue
+		}
 		path := df.normalizeDiffPath(filediff)
 		df.difffiles[path] = filediff
+		if filediff.Index != nil || filediff.IsModeChangeOnly() {
+			// Lines are looked up by the index, or there are no lines.
+			continue
+		}
 		lines, ok := df.difflines[path]
 		if !ok {
 			lines = make(map[int]*diff.Line)
@@ -126,36 +202,90 @@ func (df *DiffFilter) addDiff(filediffs []*diff.FileDiff) {
 // the filter Mode. It also optionally return diff file/line.
 func (df *DiffFilter) ShouldReport(path string, lnum int) (bool, *diff.FileDiff, *diff.Line) {
 	npath := df.normalizePath(path)
-	file := df.difffiles[npath]
-	lines, ok := df.difflines[npath]
+	file, ok := df.difffiles[npath]
+	if df.mode == ModeBlame {
+		var line *diff.Line
+		if ok {
+			line = df.diffLine(npath, path, lnum)
+		}
+		return df.blamer != nil && df.blamer.Match(path, lnum), file, line
+	}
 	if !ok {
 		return df.mode == ModeNoFilter, file, nil
 	}
-	line, ok := lines[lnum]
-	if !ok {
+	line := df.diffLine(npath, path, lnum)
+	if df.mode == ModeHunk || df.mode == ModeFunction {
+		return inRanges(df.lineRanges(file, npath, path), lnum), file, line
+	}
+	if line == nil {
 		return df.mode == ModeNoFilter || df.mode == ModeFile, file, nil
 	}
 	return df.isSignificantLine(line), file, line
 }
 
+// ShouldReportSeverity returns true if results with the given severity
+// should be reported.
+func (df *DiffFilter) ShouldReportSeverity(s rdf.Severity) bool {
+	if df.minSeverity == rdf.Severity_UNKNOWN_SEVERITY || s == rdf.Severity_UNKNOWN_SEVERITY {
+		return true
+	}
+	// Severities are ordered from the most severe.
+	return s <= df.minSeverity
+}
+
+// lineRanges returns line ranges of the file to report for ModeHunk and
+// ModeFunction.
+func (df *DiffFilter) lineRanges(file *diff.FileDiff, npath normalizedPath, path string) []diff.LineRange {
+	rs, ok := df.ranges[npath]
+	if !ok {
+		if df.mode == ModeFunction {
+			rs = functionRanges(file, path, df.source(npath, path))
+		} else {
+			rs = mergeRanges(hunkRanges(file))
+		}
+		df.ranges[npath] = rs
+	}
+	return rs
+}
+
 // DiffLine returns diff data from given new path and lnum. Returns nil if not
 // found.
 func (df *DiffFilter) DiffLine(path string, lnum int) *diff.Line {
-	npath := df.normalizePath(path)
-	lines, ok := df.difflines[npath]
-	if !ok {
-		return nil
+	return df.diffLine(df.normalizePath(path), path, lnum)
+}
+
+func (df *DiffFilter) diffLine(npath normalizedPath, path string, lnum int) *diff.Line {
+	if file, ok := df.difffiles[npath]; ok && file.Index != nil {
+		line := file.Index.Line(lnum)
+		if line != nil {
+			if src := df.source(npath, path); lnum <= len(src) {
+				line.Content = src[lnum-1]
+			}
+		}
+		return line
 	}
-	line, ok := lines[lnum]
+	return df.difflines[npath][lnum]
+}
+
+// source returns lines of the file on disk. It reads the file only once and
+// returns nil if it cannot be read.
+func (df *DiffFilter) source(npath normalizedPath, path string) []string {
+	lines, ok := df.sources[npath]
 	if !ok {
-		return nil
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(df.cwd, path)
+		}
+		if b, err := ioutil.ReadFile(path); err == nil {
+			lines = strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+		}
+		df.sources[npath] = lines
 	}
-	return line
+	return lines
 }
 
 func (df *DiffFilter) isSignificantLine(line *diff.Line) bool {
 	switch df.mode {
-	case ModeDiffContext, ModeFile, ModeNoFilter:
+	case ModeDiffContext, ModeFile, ModeNoFilter, ModeHunk, ModeFunction:
 		return true // any lines in diff are significant.
 	case ModeAdded, ModeDefault:
 		return line.Type == diff.LineAdded
@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -21,6 +22,9 @@ func TestMode_Set(t *testing.T) {
 		{value: "diff_context", want: ModeDiffContext},
 		{value: "file", want: ModeFile},
 		{value: "nofilter", want: ModeNoFilter},
+		{value: "hunk", want: ModeHunk},
+		{value: "function", want: ModeFunction},
+		{value: "blame", want: ModeBlame},
 		{value: "unknown", wantErr: true},
 	}
 	for _, tt := range tests {
@@ -276,3 +280,105 @@ func TestDiffFilter_subdir(t *testing.T) {
 		}
 	}
 }
+
+const renameDiff = `diff --git a/old.txt b/renamed.txt
+similarity index 100%
+rename from old.txt
+rename to renamed.txt
+diff --git a/run.sh b/run.sh
+old mode 100644
+new mode 100755
+diff --git a/deleted.txt b/deleted.txt
+deleted file mode 100644
+index 7898192..0000000
+--- a/deleted.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-a
+`
+
+func TestDiffFilter_renameAndModeChange(t *testing.T) {
+	files := getDiff(t, renameDiff)
+	tests := []struct {
+		path string
+		mode Mode
+		want bool
+	}{
+		{path: "renamed.txt", mode: ModeFile, want: true},
+		{path: "renamed.txt", mode: ModeAdded, want: false},
+		{path: "renamed.txt", mode: ModeDiffContext, want: false},
+		{path: "run.sh", mode: ModeFile, want: true},
+		{path: "run.sh", mode: ModeAdded, want: false},
+		{path: "old.txt", mode: ModeFile, want: false},
+		{path: "deleted.txt", mode: ModeFile, want: false},
+	}
+	for _, tt := range tests {
+		df := NewDiffFilter(files, 1, "", tt.mode)
+		if got, _, _ := df.ShouldReport(tt.path, 1); got != tt.want {
+			t.Errorf("[%s] ShouldReport(%q, 1) = %v, want %v", tt.mode.String(), tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDiffFilter_combined(t *testing.T) {
+	b, err := ioutil.ReadFile("../diff/testdata/combined.diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := getDiff(t, string(b))
+	tests := []struct {
+		lnum int
+		mode Mode
+		want bool
+	}{
+		{lnum: 2, mode: ModeAdded, want: true},  // added relative to both parents
+		{lnum: 9, mode: ModeAdded, want: false}, // added relative to the first parent only
+		{lnum: 9, mode: ModeDiffContext, want: true},
+		{lnum: 12, mode: ModeAdded, want: true},
+		{lnum: 1, mode: ModeAdded, want: false},
+	}
+	for _, tt := range tests {
+		df := NewDiffFilter(files, 1, "", tt.mode)
+		if got, _, _ := df.ShouldReport("combined.go", tt.lnum); got != tt.want {
+			t.Errorf("[%s] ShouldReport(combined.go, %d) = %v, want %v", tt.mode.String(), tt.lnum, got, tt.want)
+		}
+	}
+}
+
+type fakeBlamer map[string][]int
+
+func (b fakeBlamer) Match(path string, lnum int) bool {
+	for _, l := range b[path] {
+		if l == lnum {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDiffFilter_blame(t *testing.T) {
+	files := getDiff(t, sampleDiffRoot)
+	blamer := fakeBlamer{"sample.new.txt": {1, 10}, "other.txt": {3}}
+	df := NewDiffFilter(files, 1, "", ModeBlame, WithBlamer(blamer))
+	tests := []struct {
+		path     string
+		lnum     int
+		want     bool
+		wantLine bool
+	}{
+		{path: "sample.new.txt", lnum: 1, want: true, wantLine: true},
+		{path: "sample.new.txt", lnum: 2, want: false, wantLine: true},
+		{path: "sample.new.txt", lnum: 10, want: true},
+		{path: "other.txt", lnum: 3, want: true},
+		{path: "other.txt", lnum: 4, want: false},
+	}
+	for _, tt := range tests {
+		got, _, line := df.ShouldReport(tt.path, tt.lnum)
+		if got != tt.want || (line != nil) != tt.wantLine {
+			t.Errorf("ShouldReport(%s, %d) = (%v, %v), want (%v, line=%v)", tt.path, tt.lnum, got, line, tt.want, tt.wantLine)
+		}
+	}
+	if got, _, _ := NewDiffFilter(files, 1, "", ModeBlame).ShouldReport("sample.new.txt", 1); got {
+		t.Error("ShouldReport() without Blamer = true, want false")
+	}
+}
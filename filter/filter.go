@@ -33,12 +33,13 @@ type FilteredDiagnostic struct {
 	OldLine int
 }
 
-// FilterCheck filters check results by diff. It doesn't drop check which
-// is not in diff but set FilteredDiagnostic.ShouldReport field false.
+// FilterCheck filters check results by diff and severity. It doesn't drop
+// check which is not in diff but set FilteredDiagnostic.ShouldReport field
+// false.
 func FilterCheck(results []*rdf.Diagnostic, diff []*diff.FileDiff, strip int,
-	cwd string, mode Mode) []*FilteredDiagnostic {
+	cwd string, mode Mode, opts ...Option) []*FilteredDiagnostic {
 	checks := make([]*FilteredDiagnostic, 0, len(results))
-	df := NewDiffFilter(diff, strip, cwd, mode)
+	df := NewDiffFilter(diff, strip, cwd, mode, opts...)
 	for _, result := range results {
 		check := &FilteredDiagnostic{Diagnostic: result, SourceLines: make(map[int]string)}
 		loc := result.GetLocation()
@@ -81,6 +82,9 @@ func FilterCheck(results []*rdf.Diagnostic, diff []*diff.FileDiff, strip int,
 				check.FirstSuggestionInDiffContext = inDiffContext
 			}
 		}
+		if !df.ShouldReportSeverity(result.GetSeverity()) {
+			check.ShouldReport = false
+		}
 		checks = append(checks, check)
 	}
 	return checks
@@ -107,14 +111,25 @@ func NormalizePath(path, workdir, projectRelPath string) string {
 	return filepath.ToSlash(path)
 }
 
+// getOldPosition returns the position in the old file of the given position
+// in the new file. It returns the old path of renamed files, and empty path
+// for added files.
 func getOldPosition(filediff *diff.FileDiff, strip int, newPath string, newLine int) (oldPath string, oldLine int) {
-	if filediff == nil {
+	if filediff == nil || filediff.IsNew {
 		return "", 0
 	}
 	if NormalizeDiffPath(filediff.PathNew, strip) != newPath {
 		return "", 0
 	}
 	oldPath = NormalizeDiffPath(filediff.PathOld, strip)
+	if filediff.IsModeChangeOnly() {
+		return oldPath, newLine
+	}
+	if filediff.Index != nil {
+		if line := filediff.Index.Line(newLine); line != nil {
+			return oldPath, line.LnumOld
+		}
+	}
 	delta := 0
 	for _, hunk := range filediff.Hunks {
 		if newLine < hunk.StartLineNew {
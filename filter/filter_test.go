@@ -277,6 +277,80 @@ func TestFilterCheckByDiffContext(t *testing.T) {
 	}
 }
 
+func TestFilterCheck_streaming(t *testing.T) {
+	defer cd("../diff/testdata")()
+	var results []*rdf.Diagnostic
+	for _, path := range []string{"sample.new.txt", "nonewline.new.txt", "not_found.txt"} {
+		for l := 1; l <= 5; l++ {
+			results = append(results, &rdf.Diagnostic{
+				Location: &rdf.Location{
+					Path:  path,
+					Range: &rdf.Range{Start: &rdf.Position{Line: int32(l)}},
+				},
+				Suggestions: []*rdf.Suggestion{{
+					Range: &rdf.Range{Start: &rdf.Position{Line: int32(l)}, End: &rdf.Position{Line: int32(l + 1)}},
+				}},
+			})
+		}
+	}
+	parsed, err := diff.ParseMultiFile(strings.NewReader(diffContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanned, err := diff.ScanMultiFile(strings.NewReader(diffContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mode := range []Mode{ModeAdded, ModeDiffContext, ModeFile, ModeNoFilter} {
+		want := FilterCheck(results, parsed, 0, "", mode)
+		got := FilterCheck(results, scanned, 0, "", mode)
+		if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+			t.Errorf("FilterCheck(%s) with ScanMultiFile has diff:\n%s", mode.String(), diff)
+		}
+	}
+}
+
+func TestFilterCheck_minSeverity(t *testing.T) {
+	severities := []rdf.Severity{
+		rdf.Severity_ERROR,
+		rdf.Severity_WARNING,
+		rdf.Severity_INFO,
+		rdf.Severity_UNKNOWN_SEVERITY,
+	}
+	var results []*rdf.Diagnostic
+	for _, s := range severities {
+		results = append(results, &rdf.Diagnostic{
+			Location: &rdf.Location{
+				Path:  "sample.new.txt",
+				Range: &rdf.Range{Start: &rdf.Position{Line: 2}},
+			},
+			Severity: s,
+		})
+	}
+	files, err := diff.ParseMultiFile(strings.NewReader(diffContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		min  rdf.Severity
+		want []bool
+	}{
+		{min: rdf.Severity_UNKNOWN_SEVERITY, want: []bool{true, true, true, true}},
+		{min: rdf.Severity_ERROR, want: []bool{true, false, false, true}},
+		{min: rdf.Severity_WARNING, want: []bool{true, true, false, true}},
+		{min: rdf.Severity_INFO, want: []bool{true, true, true, true}},
+	}
+	for _, tt := range tests {
+		var got []bool
+		for _, check := range FilterCheck(results, files, 0, "", ModeAdded, WithMinSeverity(tt.min)) {
+			got = append(got, check.ShouldReport)
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("FilterCheck(min-severity=%s) has diff:\n%s", tt.min, diff)
+		}
+	}
+}
+
 func findFileDiff(filediffs []*diff.FileDiff, path string, strip int) *diff.FileDiff {
 	for _, file := range filediffs {
 		if NormalizeDiffPath(file.PathNew, strip) == path {
@@ -346,3 +420,46 @@ func TestGetOldPosition_added(t *testing.T) {
 		t.Errorf("got %q as old path for added diff file, want empty", gotPath)
 	}
 }
+
+func TestGetOldPosition_renamed(t *testing.T) {
+	const strip = 1
+	filediffs, _ := diff.ParseMultiFile(strings.NewReader(`diff --git a/old.txt b/new.txt
+similarity index 90%
+rename from old.txt
+rename to new.txt
+index 7898192..6178079 100644
+--- a/old.txt
++++ b/new.txt
+@@ -1,2 +1,3 @@
+ a
++b
+ c
+diff --git a/same.txt b/same_renamed.txt
+similarity index 100%
+rename from same.txt
+rename to same_renamed.txt
+diff --git a/script.sh b/script.sh
+old mode 100644
+new mode 100755
+`))
+	tests := []struct {
+		newPath     string
+		newLine     int
+		wantOldPath string
+		wantOldLine int
+	}{
+		{newPath: "new.txt", newLine: 1, wantOldPath: "old.txt", wantOldLine: 1},
+		{newPath: "new.txt", newLine: 3, wantOldPath: "old.txt", wantOldLine: 2},
+		{newPath: "new.txt", newLine: 10, wantOldPath: "old.txt", wantOldLine: 9},
+		{newPath: "same_renamed.txt", newLine: 5, wantOldPath: "same.txt", wantOldLine: 5},
+		{newPath: "script.sh", newLine: 5, wantOldPath: "script.sh", wantOldLine: 5},
+	}
+	for _, tt := range tests {
+		fdiff := findFileDiff(filediffs, tt.newPath, strip)
+		gotPath, gotLine := getOldPosition(fdiff, strip, tt.newPath, tt.newLine)
+		if !(gotPath == tt.wantOldPath && gotLine == tt.wantOldLine) {
+			t.Errorf("getOldPosition(..., %s, %d) = (%s, %d), want (%s, %d)",
+				tt.newPath, tt.newLine, gotPath, gotLine, tt.wantOldPath, tt.wantOldLine)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package filter
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/reviewdog/reviewdog/diff"
+)
+
+// hunkRanges returns the new line ranges of hunks.
+func hunkRanges(filediff *diff.FileDiff) []diff.LineRange {
+	var rs []diff.LineRange
+	for _, hunk := range filediff.Hunks {
+		if hunk.LineLengthNew > 0 {
+			rs = append(rs, diff.LineRange{Start: hunk.StartLineNew, End: hunk.StartLineNew + hunk.LineLengthNew - 1})
+		}
+	}
+	return rs
+}
+
+// changedLines returns line ranges of the new file which are added or next
+// to deleted lines. Deleted lines are not available for file diffs with
+// Index.
+func changedLines(filediff *diff.FileDiff) []diff.LineRange {
+	if filediff.Index != nil {
+		return filediff.Index.Added.Ranges()
+	}
+	var s diff.LineSet
+	for _, hunk := range filediff.Hunks {
+		lnew := hunk.StartLineNew
+		for _, line := range hunk.Lines {
+			switch {
+			case line.Type == diff.LineAdded:
+				s.Add(line.LnumNew)
+			case line.Type == diff.LineDeleted && lnew > 0:
+				s.Add(lnew)
+			}
+			if line.LnumNew > 0 {
+				lnew = line.LnumNew + 1
+			}
+		}
+	}
+	return s.Ranges()
+}
+
+// functionRanges returns line ranges of the outermost functions in src which
+// contain changed lines of the file diff. Changed lines outside functions fall back
+// to the ranges of their hunks.
+func functionRanges(filediff *diff.FileDiff, path string, src []string) []diff.LineRange {
+	scopes := detectScopes(path, src)
+	sort.Slice(scopes, func(i, j int) bool { return scopes[i].Start < scopes[j].Start })
+	hunks := hunkRanges(filediff)
+	var rs []diff.LineRange
+	for _, changed := range changedLines(filediff) {
+		for l := changed.Start; l <= changed.End; {
+			if scope, ok := outermostScope(scopes, l); ok {
+				rs = append(rs, scope)
+				l = scope.End + 1
+				continue
+			}
+			// Lines until the next scope are not in any scope.
+			next := changed.End + 1
+			if i := sort.Search(len(scopes), func(i int) bool { return scopes[i].Start > l }); i < len(scopes) && scopes[i].Start < next {
+				next = scopes[i].Start
+			}
+			for _, hunk := range hunks {
+				if hunk.Start < next && hunk.End >= l {
+					rs = append(rs, hunk)
+				}
+			}
+			l = next
+		}
+	}
+	return mergeRanges(rs)
+}
+
+// outermostScope returns the scope which starts first among sorted scopes
+// containing lnum, so that changed lines in closures report the whole
+// enclosing function.
+func outermostScope(scopes []diff.LineRange, lnum int) (diff.LineRange, bool) {
+	var found diff.LineRange
+	ok := false
+	for _, scope := range scopes {
+		if scope.Start > lnum {
+			break
+		}
+		if scope.End >= lnum && (!ok || scope.End > found.End) {
+			found, ok = scope, true
+		}
+	}
+	return found, ok
+}
+
+// mergeRanges sorts and merges overlapping line ranges.
+func mergeRanges(rs []diff.LineRange) []diff.LineRange {
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Start < rs[j].Start })
+	var merged []diff.LineRange
+	for _, r := range rs {
+		if n := len(merged); n > 0 && merged[n-1].End+1 >= r.Start {
+			if merged[n-1].End < r.End {
+				merged[n-1].End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// inRanges returns true if lnum is in the sorted and merged line ranges.
+func inRanges(rs []diff.LineRange, lnum int) bool {
+	i := sort.Search(len(rs), func(i int) bool { return rs[i].End >= lnum })
+	return i < len(rs) && rs[i].Start <= lnum
+}
+
+// detectScopes returns line ranges of functions in src. It's a lightweight
+// detector depending on the file extension, which doesn't parse the source.
+func detectScopes(path string, src []string) []diff.LineRange {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".go", ".c", ".h", ".cc", ".cpp", ".cxx", ".hpp", ".cs", ".java", ".kt",
+		".kts", ".scala", ".groovy", ".swift", ".dart", ".php", ".js", ".jsx",
+		".mjs", ".cjs", ".ts", ".tsx", ".rs":
+		// Single quotes are lifetimes and labels in Rust.
+		return braceScopes(src, ext != ".rs")
+	case ".py":
+		return indentScopes(src, []string{"def ", "async def "}, false)
+	case ".rb":
+		return indentScopes(src, []string{"def "}, true)
+	}
+	return funcnameScopes(src)
+}
+
+// braceScopes returns blocks enclosed by braces whose headers look like
+// functions. It skips braces in comments and string literals.
+func braceScopes(src []string, singleQuote bool) []diff.LineRange {
+	type open struct{ header int }
+	var stack []open
+	var scopes []diff.LineRange
+	var quote byte // the quote character of the current string literal.
+	inComment := false
+	for i, line := range src {
+		if quote != '`' {
+			quote = 0 // string literals other than raw strings end at the end of line.
+		}
+		for j := 0; j < len(line); j++ {
+			c := line[j]
+			switch {
+			case inComment:
+				if c == '*' && j+1 < len(line) && line[j+1] == '/' {
+					inComment = false
+					j++
+				}
+			case quote != 0:
+				if c == '\\' && quote != '`' {
+					j++
+				} else if c == quote {
+					quote = 0
+				}
+			case c == '/' && j+1 < len(line) && line[j+1] == '/':
+				j = len(line)
+			case c == '/' && j+1 < len(line) && line[j+1] == '*':
+				inComment = true
+				j++
+			case c == '"' || c == '`' || (c == '\'' && singleQuote):
+				quote = c
+			case c == '{':
+				header := i
+				if strings.TrimSpace(line[:j]) == "" {
+					// The brace is on its own line (e.g. Allman style).
+					for k := i - 1; k >= 0; k-- {
+						if strings.TrimSpace(src[k]) != "" {
+							header = k
+							break
+						}
+					}
+				}
+				stack = append(stack, open{header: header})
+			case c == '}':
+				if len(stack) == 0 {
+					continue
+				}
+				o := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if isFuncHeader(src[o.header]) {
+					scopes = append(scopes, diff.LineRange{Start: o.header + 1, End: i + 1})
+				}
+			}
+		}
+	}
+	return scopes
+}
+
+// controlKeywords are keywords of control flow statements which start blocks
+// but not functions.
+var controlKeywords = []string{
+	"if", "else", "for", "foreach", "while", "do", "switch", "case", "select",
+	"try", "catch", "finally", "match", "loop", "when", "with", "using",
+	"synchronized", "unsafe", "return",
+}
+
+// isFuncHeader returns true if the line looks like a header of a function.
+func isFuncHeader(line string) bool {
+	line = strings.TrimLeft(strings.TrimSpace(line), "} ")
+	words := strings.FieldsFunc(line, func(r rune) bool {
+		return !(r == '_' || r == '$' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	})
+	for _, w := range words {
+		if w == "func" || w == "function" || w == "fn" || w == "fun" || w == "def" {
+			return true
+		}
+	}
+	if len(words) > 0 {
+		for _, k := range controlKeywords {
+			if words[0] == k {
+				return false
+			}
+		}
+	}
+	return strings.Contains(line, "=>") || strings.Contains(line, ")")
+}
+
+// indentScopes returns blocks which start with lines with the prefixes and
+// continue while lines are indented deeper. Decorators are included. If
+// endLine is true, the following line with the same indentation (e.g. "end")
+// is included.
+func indentScopes(src []string, prefixes []string, endLine bool) []diff.LineRange {
+	var scopes []diff.LineRange
+	for i, line := range src {
+		trimmed := strings.TrimLeft(line, " \t")
+		if !hasAnyPrefix(trimmed, prefixes) {
+			continue
+		}
+		indent := len(line) - len(trimmed)
+		start := i
+		for start > 0 && strings.HasPrefix(strings.TrimLeft(src[start-1], " \t"), "@") {
+			start--
+		}
+		end := i
+		for j := i + 1; j < len(src); j++ {
+			t := strings.TrimLeft(src[j], " \t")
+			if t == "" {
+				continue
+			}
+			if len(src[j])-len(t) <= indent {
+				if endLine && len(src[j])-len(t) == indent && strings.HasPrefix(t, "end") {
+					end = j
+				}
+				break
+			}
+			end = j
+		}
+		scopes = append(scopes, diff.LineRange{Start: start + 1, End: end + 1})
+	}
+	return scopes
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// funcnameScopes returns ranges from a line which git uses as a hunk section
+// heading by default (i.e. it starts with an alphabet, '_' or '$') to the line
+// before the next one.
+func funcnameScopes(src []string) []diff.LineRange {
+	var scopes []diff.LineRange
+	for i, line := range src {
+		if line == "" || !(line[0] == '_' || line[0] == '$' || 'a' <= line[0] && line[0] <= 'z' || 'A' <= line[0] && line[0] <= 'Z') {
+			continue
+		}
+		if n := len(scopes); n > 0 {
+			scopes[n-1].End = lastNonBlank(src, scopes[n-1].Start-1, i-1) + 1
+		}
+		scopes = append(scopes, diff.LineRange{Start: i + 1})
+	}
+	if n := len(scopes); n > 0 {
+		scopes[n-1].End = lastNonBlank(src, scopes[n-1].Start-1, len(src)-1) + 1
+	}
+	return scopes
+}
+
+// lastNonBlank returns the index of the last non-blank line in src[from:to+1]
+// or from if all of them are blank.
+func lastNonBlank(src []string, from, to int) int {
+	for i := to; i > from; i-- {
+		if strings.TrimSpace(src[i]) != "" {
+			return i
+		}
+	}
+	return from
+}
@@ -0,0 +1,209 @@
+package filter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/reviewdog/reviewdog/diff"
+)
+
+func TestDetectScopes(t *testing.T) {
+	tests := []struct {
+		path string
+		src  string
+		want []diff.LineRange
+	}{
+		{
+			path: "a.go",
+			src: `package a
+
+// F is { not a block.
+func F() {
+	if x {
+		s := "}"
+		_ = s
+	}
+	f := func() {
+	}
+	_ = T{
+		A: 1,
+	}
+}
+
+type T struct {
+	A int
+}
+`,
+			want: []diff.LineRange{{Start: 9, End: 10}, {Start: 4, End: 14}},
+		},
+		{
+			path: "A.java",
+			src: `class A {
+  int f;
+
+  void m(int x)
+  {
+    for (int i = 0; i < x; i++) {
+    }
+  }
+}
+`,
+			want: []diff.LineRange{{Start: 4, End: 8}},
+		},
+		{
+			path: "a.rs",
+			src: `fn f<'a>(s: &'a str) {
+    loop {
+    }
+}
+`,
+			want: []diff.LineRange{{Start: 1, End: 4}},
+		},
+		{
+			path: "a.py",
+			src: `import os
+
+@decorator
+def f(x):
+    if x:
+
+        return 1
+    return 2
+
+class A:
+    def m(self):
+        pass
+`,
+			want: []diff.LineRange{{Start: 3, End: 8}, {Start: 11, End: 12}},
+		},
+		{
+			path: "a.rb",
+			src: `class A
+  def m
+    1
+  end
+end
+`,
+			want: []diff.LineRange{{Start: 2, End: 4}},
+		},
+		{
+			path: "a.sh",
+			src: `#!/bin/sh
+f() {
+  echo
+}
+
+g() {
+  echo
+}
+
+`,
+			want: []diff.LineRange{{Start: 2, End: 4}, {Start: 6, End: 8}},
+		},
+	}
+	for _, tt := range tests {
+		src := strings.Split(strings.TrimSuffix(tt.src, "\n"), "\n")
+		if diff := cmp.Diff(tt.want, detectScopes(tt.path, src)); diff != "" {
+			t.Errorf("detectScopes(%q) has diff:\n%s", tt.path, diff)
+		}
+	}
+}
+
+func TestDiffFilter_hunkAndFunction(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "reviewdog-filter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	defer cd(tmp)()
+	src := `package a
+
+func F() int {
+	x := 1
+	y := 2
+	z := 3
+	return x + y + z
+}
+
+var V = 1
+`
+	if err := ioutil.WriteFile(filepath.Join(tmp, "a.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := getDiff(t, `--- a/a.go
++++ b/a.go
+@@ -5,1 +5,1 @@
+-	y := 0
++	y := 2
+@@ -9,0 +10,1 @@
++var V = 1
+`)
+	tests := []struct {
+		mode Mode
+		want []int // lines to report
+	}{
+		{mode: ModeAdded, want: []int{5, 10}},
+		{mode: ModeHunk, want: []int{5, 10}},
+		{mode: ModeFunction, want: []int{3, 4, 5, 6, 7, 8, 10}},
+	}
+	for _, tt := range tests {
+		df := NewDiffFilter(files, 1, "", tt.mode)
+		var got []int
+		for l := 1; l <= 11; l++ {
+			if ok, _, _ := df.ShouldReport("a.go", l); ok {
+				got = append(got, l)
+			}
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("[%s] reported lines has diff:\n%s", tt.mode.String(), diff)
+		}
+	}
+}
+
+func TestDiffFilter_functionWithClosure(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "reviewdog-filter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	defer cd(tmp)()
+	src := `package a
+
+func F() int {
+	x := 1
+	f := func() int {
+		g := func() int {
+			return 2
+		}
+		return g()
+	}
+	return x + f()
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(tmp, "a.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := getDiff(t, `--- a/a.go
++++ b/a.go
+@@ -7,1 +7,1 @@
+-			return 0
++			return 2
+`)
+	df := NewDiffFilter(files, 1, "", ModeFunction)
+	var got []int
+	for l := 1; l <= 13; l++ {
+		if ok, _, _ := df.ShouldReport("a.go", l); ok {
+			got = append(got, l)
+		}
+	}
+	// The whole outermost function is reported, not only the innermost closure.
+	want := []int{3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("reported lines has diff:\n%s", diff)
+	}
+}
@@ -0,0 +1,609 @@
+package gitrepo
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/reviewdog/reviewdog/diff"
+)
+
+// File modes of tree entries.
+const (
+	modeTree       = 0040000
+	modeFile       = 0100644
+	modeExecutable = 0100755
+	modeSymlink    = 0120000
+	modeGitlink    = 0160000
+)
+
+// renameThreshold is the minimum similarity (%) to detect renames. It's the
+// default of `git diff --find-renames`.
+const renameThreshold = 50
+
+// maxRenameCandidates is the max number of added * deleted files to compute
+// similarity for inexact rename detection, like diff.renameLimit of git.
+const maxRenameCandidates = 1000 * 1000
+
+// treeEntry is an entry of a tree object.
+type treeEntry struct {
+	name string
+	mode int
+	hash Hash
+}
+
+func (r *Repository) readTree(h Hash) ([]treeEntry, error) {
+	typ, data, err := r.objects.read(h)
+	if err != nil {
+		return nil, err
+	}
+	if typ != ObjectTree {
+		return nil, fmt.Errorf("%s is a %s, not a tree", h, typ)
+	}
+	var entries []treeEntry
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if sp < 0 || nul < sp || len(data) < nul+1+len(Hash{}) {
+			return nil, fmt.Errorf("invalid tree object: %s", h)
+		}
+		mode, err := strconv.ParseInt(string(data[:sp]), 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tree object: %s", h)
+		}
+		e := treeEntry{name: string(data[sp+1 : nul]), mode: int(mode)}
+		copy(e.hash[:], data[nul+1:])
+		entries = append(entries, e)
+		data = data[nul+1+len(Hash{}):]
+	}
+	return entries, nil
+}
+
+// fileEntry is a non-tree entry with its full path.
+type fileEntry struct {
+	path string
+	mode int
+	hash Hash
+	// content is set for files in the working tree.
+	content []byte
+	hasData bool
+}
+
+// change is a changed file. from or to is nil for added or deleted files.
+type change struct {
+	from, to   *fileEntry
+	similarity int
+}
+
+// diffTrees returns changed files between two trees. It skips identical
+// subtrees without reading them.
+func (r *Repository) diffTrees(from, to Hash, prefix string) ([]*change, error) {
+	if from == to {
+		return nil, nil
+	}
+	var fromEntries, toEntries []treeEntry
+	var err error
+	if !from.IsZero() {
+		if fromEntries, err = r.readTree(from); err != nil {
+			return nil, err
+		}
+	}
+	if !to.IsZero() {
+		if toEntries, err = r.readTree(to); err != nil {
+			return nil, err
+		}
+	}
+	byName := make(map[string]*[2]*treeEntry)
+	var names []string
+	for i, es := range [][]treeEntry{fromEntries, toEntries} {
+		for j := range es {
+			e := &es[j]
+			pair, ok := byName[e.name]
+			if !ok {
+				pair = &[2]*treeEntry{}
+				byName[e.name] = pair
+				names = append(names, e.name)
+			}
+			pair[i] = e
+		}
+	}
+	sort.Strings(names)
+	var changes []*change
+	for _, name := range names {
+		pair := byName[name]
+		f, t := pair[0], pair[1]
+		path := prefix + name
+		if f != nil && t != nil && f.mode == t.mode && f.hash == t.hash {
+			continue
+		}
+		var fromTree, toTree Hash
+		var fromFile, toFile *fileEntry
+		if f != nil {
+			if f.mode == modeTree {
+				fromTree = f.hash
+			} else {
+				fromFile = &fileEntry{path: path, mode: f.mode, hash: f.hash}
+			}
+		}
+		if t != nil {
+			if t.mode == modeTree {
+				toTree = t.hash
+			} else {
+				toFile = &fileEntry{path: path, mode: t.mode, hash: t.hash}
+			}
+		}
+		if !fromTree.IsZero() || !toTree.IsZero() {
+			cs, err := r.diffTrees(fromTree, toTree, path+"/")
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, cs...)
+		}
+		switch {
+		case fromFile != nil && toFile != nil && sameType(fromFile.mode, toFile.mode):
+			changes = append(changes, &change{from: fromFile, to: toFile})
+		default:
+			// Type changes (e.g. file to symlink) are shown as delete and add.
+			if fromFile != nil {
+				changes = append(changes, &change{from: fromFile})
+			}
+			if toFile != nil {
+				changes = append(changes, &change{to: toFile})
+			}
+		}
+	}
+	return changes, nil
+}
+
+// flattenTree returns all non-tree entries of the tree.
+func (r *Repository) flattenTree(h Hash, prefix string, files map[string]*fileEntry) error {
+	entries, err := r.readTree(h)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		path := prefix + e.name
+		if e.mode == modeTree {
+			if err := r.flattenTree(e.hash, path+"/", files); err != nil {
+				return err
+			}
+			continue
+		}
+		files[path] = &fileEntry{path: path, mode: e.mode, hash: e.hash}
+	}
+	return nil
+}
+
+// Diff returns diff from commit `from` to commit `to`. It's equivalent to
+// `git diff --find-renames <from> <to>`.
+func (r *Repository) Diff(from, to Hash) ([]*diff.FileDiff, error) {
+	fromTree, err := r.commitTree(from)
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := r.commitTree(to)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := r.diffTrees(fromTree, toTree, "")
+	if err != nil {
+		return nil, err
+	}
+	return r.fileDiffs(changes)
+}
+
+// DiffWorkTree returns diff from commit `from` to the working tree. It's
+// equivalent to `git diff --find-renames <from>`. Files tracked in the index
+// are compared, so new files are included once they are staged or added with
+// `git add --intent-to-add`.
+func (r *Repository) DiffWorkTree(from Hash) ([]*diff.FileDiff, error) {
+	fromTree, err := r.commitTree(from)
+	if err != nil {
+		return nil, err
+	}
+	fromFiles := make(map[string]*fileEntry)
+	if err := r.flattenTree(fromTree, "", fromFiles); err != nil {
+		return nil, err
+	}
+	toFiles, err := r.workTreeFiles()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, files := range []map[string]*fileEntry{fromFiles, toFiles} {
+		for path := range files {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	var changes []*change
+	for i, path := range paths {
+		if i > 0 && paths[i-1] == path {
+			continue
+		}
+		f, t := fromFiles[path], toFiles[path]
+		switch {
+		case f != nil && t != nil:
+			if f.mode == t.mode && f.hash == t.hash {
+				continue
+			}
+			if sameType(f.mode, t.mode) {
+				changes = append(changes, &change{from: f, to: t})
+				continue
+			}
+			changes = append(changes, &change{from: f}, &change{to: t})
+		case f != nil:
+			changes = append(changes, &change{from: f})
+		default:
+			changes = append(changes, &change{to: t})
+		}
+	}
+	return r.fileDiffs(changes)
+}
+
+// workTreeFiles returns files in the working tree which are tracked by the
+// index, or by HEAD if the index doesn't exist. Files excluded by sparse
+// checkout are returned as they are in the index.
+func (r *Repository) workTreeFiles() (map[string]*fileEntry, error) {
+	entries, err := r.readIndex()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	tracked := make(map[string]*fileEntry)
+	skip := make(map[string]bool)
+	if entries == nil {
+		head, err := r.ResolveRevision("HEAD")
+		if err != nil {
+			return nil, err
+		}
+		headTree, err := r.commitTree(head)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.flattenTree(headTree, "", tracked); err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range entries {
+		if _, ok := tracked[e.path]; ok {
+			// Other stages of unmerged paths.
+			continue
+		}
+		if e.mode == modeTree {
+			// Directories outside of sparse checkout in sparse indexes.
+			dir := make(map[string]*fileEntry)
+			if err := r.flattenTree(e.hash, e.path, dir); err != nil {
+				return nil, err
+			}
+			for path, f := range dir {
+				tracked[path], skip[path] = f, true
+			}
+			continue
+		}
+		tracked[e.path] = &fileEntry{path: e.path, mode: e.mode, hash: e.hash}
+		skip[e.path] = e.skipWorktree
+	}
+	for path, e := range tracked {
+		if skip[path] {
+			continue
+		}
+		wt, err := r.workTreeEntry(path, e)
+		if err != nil {
+			return nil, err
+		}
+		if wt == nil {
+			delete(tracked, path)
+		} else {
+			tracked[path] = wt
+		}
+	}
+	return tracked, nil
+}
+
+// workTreeEntry returns the entry of the file in the working tree, or nil if
+// it's deleted. Submodules are not compared.
+func (r *Repository) workTreeEntry(path string, tracked *fileEntry) (*fileEntry, error) {
+	if tracked.mode == modeGitlink {
+		return tracked, nil
+	}
+	full := filepath.Join(r.workTree, filepath.FromSlash(path))
+	fi, err := os.Lstat(full)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	e := &fileEntry{path: path, mode: modeFile, hasData: true}
+	switch {
+	case fi.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(full)
+		if err != nil {
+			return nil, err
+		}
+		e.mode = modeSymlink
+		e.content = []byte(filepath.ToSlash(target))
+	case fi.IsDir():
+		return nil, nil
+	default:
+		if fi.Mode()&0111 != 0 {
+			e.mode = modeExecutable
+		}
+		if e.content, err = ioutil.ReadFile(full); err != nil {
+			return nil, err
+		}
+	}
+	e.hash = blobHash(e.content)
+	return e, nil
+}
+
+func (r *Repository) commitTree(h Hash) (Hash, error) {
+	c, err := r.Commit(h)
+	if err != nil {
+		return Hash{}, err
+	}
+	return c.Tree, nil
+}
+
+func (r *Repository) content(e *fileEntry) ([]byte, error) {
+	if e.hasData {
+		return e.content, nil
+	}
+	if e.mode == modeGitlink {
+		return []byte("Subproject commit " + e.hash.String() + "\n"), nil
+	}
+	_, data, err := r.objects.read(e.hash)
+	if err != nil {
+		return nil, err
+	}
+	e.content, e.hasData = data, true
+	return data, nil
+}
+
+// fileDiffs detects renames and returns file diffs of changes.
+func (r *Repository) fileDiffs(changes []*change) ([]*diff.FileDiff, error) {
+	changes, err := r.detectRenames(changes)
+	if err != nil {
+		return nil, err
+	}
+	fds := make([]*diff.FileDiff, 0, len(changes))
+	for _, c := range changes {
+		fd, err := r.fileDiff(c)
+		if err != nil {
+			return nil, err
+		}
+		fds = append(fds, fd)
+	}
+	return fds, nil
+}
+
+// detectRenames pairs deleted and added files which have the same or
+// similar content.
+func (r *Repository) detectRenames(changes []*change) ([]*change, error) {
+	var deleted, added []*change
+	var result []*change
+	for _, c := range changes {
+		switch {
+		case c.to == nil && c.from.mode != modeGitlink:
+			deleted = append(deleted, c)
+		case c.from == nil && c.to.mode != modeGitlink:
+			added = append(added, c)
+		default:
+			result = append(result, c)
+		}
+	}
+	if len(deleted) == 0 || len(added) == 0 {
+		return changes, nil
+	}
+	paired := make(map[*change]bool)
+	// Exact renames.
+	byHash := make(map[Hash][]*change)
+	for _, d := range deleted {
+		byHash[d.from.hash] = append(byHash[d.from.hash], d)
+	}
+	for _, a := range added {
+		ds := byHash[a.to.hash]
+		for len(ds) > 0 && paired[ds[0]] {
+			ds = ds[1:]
+		}
+		byHash[a.to.hash] = ds
+		if len(ds) == 0 {
+			continue
+		}
+		paired[ds[0]], paired[a] = true, true
+		result = append(result, &change{from: ds[0].from, to: a.to, similarity: 100})
+	}
+	// Inexact renames.
+	if len(deleted)*len(added) <= maxRenameCandidates {
+		type candidate struct {
+			d, a  *change
+			score int
+		}
+		var candidates []candidate
+		for _, d := range deleted {
+			if paired[d] {
+				continue
+			}
+			for _, a := range added {
+				if paired[a] {
+					continue
+				}
+				score, err := r.similarity(d.from, a.to)
+				if err != nil {
+					return nil, err
+				}
+				if score >= renameThreshold {
+					candidates = append(candidates, candidate{d: d, a: a, score: score})
+				}
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+		for _, c := range candidates {
+			if paired[c.d] || paired[c.a] {
+				continue
+			}
+			paired[c.d], paired[c.a] = true, true
+			result = append(result, &change{from: c.d.from, to: c.a.to, similarity: c.score})
+		}
+	}
+	for _, c := range append(deleted, added...) {
+		if !paired[c] {
+			result = append(result, c)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return changePath(result[i]) < changePath(result[j]) })
+	return result, nil
+}
+
+func changePath(c *change) string {
+	if c.to != nil {
+		return c.to.path
+	}
+	return c.from.path
+}
+
+// similarity returns similarity (%) of two files, which is the size of
+// common lines divided by the size of the larger file.
+func (r *Repository) similarity(a, b *fileEntry) (int, error) {
+	if !sameType(a.mode, b.mode) {
+		return 0, nil
+	}
+	ca, err := r.content(a)
+	if err != nil {
+		return 0, err
+	}
+	cb, err := r.content(b)
+	if err != nil {
+		return 0, err
+	}
+	maxSize := len(ca)
+	if len(cb) > maxSize {
+		maxSize = len(cb)
+	}
+	if maxSize == 0 {
+		// Empty files are not renames.
+		return 0, nil
+	}
+	lines := make(map[string]int)
+	for _, l := range bytes.SplitAfter(ca, []byte("\n")) {
+		lines[string(l)]++
+	}
+	common := 0
+	for _, l := range bytes.SplitAfter(cb, []byte("\n")) {
+		if lines[string(l)] > 0 {
+			lines[string(l)]--
+			common += len(l)
+		}
+	}
+	return common * 100 / maxSize, nil
+}
+
+// fileDiff returns diff of the change in the same format as git diff.
+func (r *Repository) fileDiff(c *change) (*diff.FileDiff, error) {
+	fromPath, toPath := changePath(c), changePath(c)
+	if c.from != nil {
+		fromPath = c.from.path
+	}
+	fd := &diff.FileDiff{PathOld: "/dev/null", PathNew: "/dev/null"}
+	fd.Extended = append(fd.Extended, fmt.Sprintf("diff --git %s %s", diff.QuotePath("a/"+fromPath), diff.QuotePath("b/"+toPath)))
+	var oldContent, newContent []byte
+	var err error
+	if c.from != nil {
+		fd.PathOld = "a/" + c.from.path
+		if oldContent, err = r.content(c.from); err != nil {
+			return nil, err
+		}
+	}
+	if c.to != nil {
+		fd.PathNew = "b/" + c.to.path
+		if newContent, err = r.content(c.to); err != nil {
+			return nil, err
+		}
+	}
+	// Typed fields are set only if corresponding headers are present, as
+	// diff.ParseMultiFile does.
+	switch {
+	case c.from == nil:
+		fd.IsNew, fd.NewMode = true, fmt.Sprintf("%06o", c.to.mode)
+		fd.Extended = append(fd.Extended, "new file mode "+fd.NewMode)
+	case c.to == nil:
+		fd.IsDeleted, fd.OldMode = true, fmt.Sprintf("%06o", c.from.mode)
+		fd.Extended = append(fd.Extended, "deleted file mode "+fd.OldMode)
+	default:
+		if c.from.mode != c.to.mode {
+			fd.OldMode, fd.NewMode = fmt.Sprintf("%06o", c.from.mode), fmt.Sprintf("%06o", c.to.mode)
+			fd.Extended = append(fd.Extended, "old mode "+fd.OldMode, "new mode "+fd.NewMode)
+		}
+		if c.from.path != c.to.path {
+			fd.IsRename, fd.Similarity = true, c.similarity
+			fd.Extended = append(fd.Extended, fmt.Sprintf("similarity index %d%%", c.similarity),
+				"rename from "+diff.QuotePath(c.from.path), "rename to "+diff.QuotePath(c.to.path))
+		}
+	}
+	fromHash, toHash := Hash{}, Hash{}
+	if c.from != nil {
+		fromHash = c.from.hash
+	}
+	if c.to != nil {
+		toHash = c.to.hash
+	}
+	if fromHash == toHash {
+		// Pure rename or mode change.
+		return fd, nil
+	}
+	index := fmt.Sprintf("index %s..%s", fromHash.String()[:7], toHash.String()[:7])
+	if c.from != nil && c.to != nil && c.from.mode == c.to.mode {
+		fd.OldMode, fd.NewMode = fmt.Sprintf("%06o", c.from.mode), fmt.Sprintf("%06o", c.to.mode)
+		index += " " + fd.NewMode
+	}
+	fd.Extended = append(fd.Extended, index)
+	if diff.IsBinary(oldContent) || diff.IsBinary(newContent) {
+		fd.IsBinary = true
+		fd.Extended = append(fd.Extended, fmt.Sprintf("Binary files %s and %s differ", diff.QuotePath(fd.PathOld), diff.QuotePath(fd.PathNew)))
+		return fd, nil
+	}
+	fd.SetHunks(oldContent, newContent, diff.DefaultContextLines)
+	setSections(fd.Hunks, oldContent)
+	return fd, nil
+}
+
+// maxSectionLen is the max length of hunk section headings.
+const maxSectionLen = 80
+
+// setSections sets section headings of hunks in the same way as the default
+// funcname pattern of git. It's the nearest line before the hunk which starts
+// with an alphabet, '_' or '$'.
+func setSections(hunks []*diff.Hunk, oldContent []byte) {
+	lines := bytes.Split(oldContent, []byte("\n"))
+	for _, h := range hunks {
+		// Index of the first line of the hunk in the old content.
+		start := h.StartLineOld - 1
+		if h.LineLengthOld == 0 {
+			start++
+		}
+		for i := start - 1; i >= 0; i-- {
+			if l := lines[i]; len(l) > 0 && isFuncLineStart(l[0]) {
+				if len(l) > maxSectionLen {
+					l = l[:maxSectionLen]
+				}
+				h.Section = strings.TrimRight(string(l), " \t\r\n\v\f")
+				break
+			}
+		}
+	}
+}
+
+func isFuncLineStart(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || c == '_' || c == '$'
+}
+
+// sameType reports whether both modes are the same type of entry (regular
+// file, symlink or submodule).
+func sameType(a, b int) bool {
+	return a>>12 == b>>12
+}
@@ -0,0 +1,162 @@
+package gitrepo
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/reviewdog/reviewdog/diff"
+)
+
+func TestRepository_Diff(t *testing.T) {
+	dir := setupRepo(t)
+	defer os.RemoveAll(dir)
+
+	var lines []string
+	for i := 0; i < 30; i++ {
+		lines = append(lines, strings.Repeat("line", i))
+	}
+	content := strings.Join(lines, "\n") + "\n"
+	writeFile(t, dir, "modified.txt", content)
+	writeFile(t, dir, "deleted.txt", "deleted\n")
+	writeFile(t, dir, "renamed.txt", content+"renamed\n")
+	writeFile(t, dir, "similar.txt", content)
+	writeFile(t, dir, "run.sh", "echo\n")
+	writeFile(t, dir, "binary.dat", "a\x00b")
+	writeFile(t, dir, "space name.txt", "a\n")
+	writeFile(t, dir, "nonewline.txt", "a\nb")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "base")
+
+	writeFile(t, dir, "modified.txt", strings.Replace(content, "lineline\n", "modified\n", 1)+"added\n")
+	git(t, dir, "rm", "-q", "deleted.txt")
+	git(t, dir, "mv", "renamed.txt", "sub/moved.txt")
+	git(t, dir, "mv", "similar.txt", "similar2.txt")
+	writeFile(t, dir, "similar2.txt", strings.Replace(content, "line\n", "x\n", 2))
+	git(t, dir, "update-index", "--chmod=+x", "run.sh")
+	writeFile(t, dir, "binary.dat", "a\x00c")
+	writeFile(t, dir, "space name.txt", "b\n")
+	writeFile(t, dir, "nonewline.txt", "a\nc")
+	writeFile(t, dir, "日本語.txt", "new\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "change")
+	if err := os.Chmod(filepath.Join(dir, "run.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, packed := range []bool{false, true} {
+		if packed {
+			git(t, dir, "gc", "-q")
+		}
+		repo, err := Open(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		from, _ := repo.ResolveRevision("HEAD~1")
+		to, _ := repo.ResolveRevision("HEAD")
+		filediffs, err := repo.Diff(from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertGitDiff(t, dir, filediffs, "HEAD~1", "HEAD")
+
+		// Changes in the working tree.
+		writeFile(t, dir, "space name.txt", "c\n")
+		if err := os.Remove(filepath.Join(dir, "a.txt")); err != nil {
+			t.Fatal(err)
+		}
+		filediffs, err = repo.DiffWorkTree(from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertGitDiff(t, dir, filediffs, "HEAD~1")
+		git(t, dir, "checkout", "--", ".")
+	}
+}
+
+func TestRepository_DiffWorkTree_index(t *testing.T) {
+	for _, version := range []string{"2", "3", "4"} {
+		t.Run("version "+version, func(t *testing.T) {
+			dir := setupRepo(t)
+			defer os.RemoveAll(dir)
+			writeFile(t, dir, "unstaged.txt", "unstaged\n")
+			git(t, dir, "add", "unstaged.txt")
+			git(t, dir, "commit", "-q", "-m", "add unstaged.txt")
+			git(t, dir, "update-index", "--index-version", version)
+
+			writeFile(t, dir, "staged.txt", "staged\n")
+			git(t, dir, "add", "staged.txt")
+			writeFile(t, dir, "sub/staged and modified.txt", "staged\n")
+			git(t, dir, "add", "sub/staged and modified.txt")
+			writeFile(t, dir, "sub/staged and modified.txt", "modified\n")
+			writeFile(t, dir, "intent.txt", "intent to add\n")
+			git(t, dir, "add", "--intent-to-add", "intent.txt")
+			writeFile(t, dir, "untracked.txt", "untracked\n")
+			// Files removed from the index are deleted even if they remain.
+			git(t, dir, "rm", "-q", "--cached", "unstaged.txt")
+
+			repo, err := Open(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			from, _ := repo.ResolveRevision("HEAD")
+			filediffs, err := repo.DiffWorkTree(from)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertGitDiff(t, dir, filediffs, "HEAD")
+		})
+	}
+}
+
+func TestRepository_DiffWorkTree_sparse(t *testing.T) {
+	dir := setupRepo(t)
+	defer os.RemoveAll(dir)
+	writeFile(t, dir, "other/c.txt", "c\n")
+	git(t, dir, "add", "other/c.txt")
+	git(t, dir, "commit", "-q", "-m", "add other")
+	git(t, dir, "sparse-checkout", "init", "--cone", "--sparse-index")
+	git(t, dir, "sparse-checkout", "set", "sub")
+	writeFile(t, dir, "sub/b.txt", "modified\n")
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, _ := repo.ResolveRevision("HEAD~1")
+	filediffs, err := repo.DiffWorkTree(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGitDiff(t, dir, filediffs, "HEAD~1")
+}
+
+func assertGitDiff(t *testing.T, dir string, filediffs []*diff.FileDiff, args ...string) {
+	t.Helper()
+	var got bytes.Buffer
+	if err := diff.Format(&got, filediffs); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("git", append([]string{"diff", "--find-renames", "--no-color", "--no-ext-diff"}, args...)...)
+	cmd.Dir = dir
+	want, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != string(want) {
+		t.Errorf("diff of %v differs from git diff:\ngot:\n%s\nwant:\n%s", args, got.String(), want)
+	}
+	wantFileDiffs, err := diff.ParseMultiFile(bytes.NewReader(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(wantFileDiffs, filediffs, cmpopts.EquateEmpty()); d != "" {
+		t.Errorf("file diffs of %v differ from parsed git diff:\n%s", args, d)
+	}
+}
@@ -0,0 +1,134 @@
+package gitrepo
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Flags of index entries.
+const (
+	indexFlagExtended     = 0x4000
+	indexFlagNameMask     = 0x0fff
+	indexFlagSkipWorktree = 0x4000 // in extended flags
+)
+
+// indexEntry is an entry of the index. Files added with `git add
+// --intent-to-add` have entries of the empty blob.
+type indexEntry struct {
+	path string
+	mode int
+	hash Hash
+	// skipWorktree is true for files excluded by sparse checkout.
+	skipWorktree bool
+}
+
+// readIndex returns entries of the index of the working tree. Unmerged paths
+// have one entry for each stage. It returns nil without error if the index
+// doesn't exist.
+func (r *Repository) readIndex() ([]*indexEntry, error) {
+	data, err := ioutil.ReadFile(filepath.Join(r.gitDir, "index"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseIndex(data)
+}
+
+// parseIndex parses the index file of version 2, 3 or 4.
+// https://git-scm.com/docs/index-format
+func parseIndex(data []byte) ([]*indexEntry, error) {
+	if len(data) < 12+sha1.Size || !bytes.Equal(data[:4], []byte("DIRC")) {
+		return nil, errors.New("invalid index file")
+	}
+	if sum := sha1.Sum(data[:len(data)-sha1.Size]); !bytes.Equal(sum[:], data[len(data)-sha1.Size:]) {
+		return nil, errors.New("invalid index file: checksum mismatch")
+	}
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("unsupported index version: %d", version)
+	}
+	n := int(binary.BigEndian.Uint32(data[8:12]))
+	body := data[12 : len(data)-sha1.Size]
+	off := 0
+	entries := make([]*indexEntry, 0, n)
+	prevPath := ""
+	for i := 0; i < n; i++ {
+		// ctime, mtime, dev, ino, mode, uid, gid, size, hash and flags.
+		const fixed = 40 + len(Hash{}) + 2
+		if len(body) < off+fixed {
+			return nil, errors.New("invalid index file: truncated entry")
+		}
+		e := &indexEntry{mode: int(binary.BigEndian.Uint32(body[off+24:]))}
+		copy(e.hash[:], body[off+40:])
+		flags := binary.BigEndian.Uint16(body[off+40+len(Hash{}):])
+		start := off
+		off += fixed
+		if flags&indexFlagExtended != 0 {
+			if version < 3 || len(body) < off+2 {
+				return nil, errors.New("invalid index file: unexpected extended flags")
+			}
+			ext := binary.BigEndian.Uint16(body[off:])
+			e.skipWorktree = ext&indexFlagSkipWorktree != 0
+			off += 2
+		}
+		if version == 4 {
+			// The path is prefix-compressed against the previous path.
+			strip, size := indexVarint(body[off:])
+			if size == 0 || strip > len(prevPath) {
+				return nil, errors.New("invalid index file: invalid path prefix")
+			}
+			off += size
+			nul := bytes.IndexByte(body[off:], 0)
+			if nul < 0 {
+				return nil, errors.New("invalid index file: unterminated path")
+			}
+			e.path = prevPath[:len(prevPath)-strip] + string(body[off:off+nul])
+			off += nul + 1
+		} else {
+			nul := bytes.IndexByte(body[off:], 0)
+			if nul < 0 {
+				return nil, errors.New("invalid index file: unterminated path")
+			}
+			if l := int(flags & indexFlagNameMask); l < indexFlagNameMask && l != nul {
+				return nil, errors.New("invalid index file: path length mismatch")
+			}
+			e.path = string(body[off : off+nul])
+			// Entries are padded with 1-8 NUL bytes to a multiple of 8 bytes.
+			off = start + (off+nul-start+8)/8*8
+			if off > len(body) {
+				return nil, errors.New("invalid index file: truncated entry")
+			}
+		}
+		prevPath = e.path
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// indexVarint decodes the variable-length integer of index version 4. It
+// returns the value and the number of bytes read, or 0 bytes for invalid
+// input.
+func indexVarint(b []byte) (int, int) {
+	val := 0
+	for i, c := range b {
+		if i > 0 {
+			val++
+		}
+		val = val<<7 | int(c&0x7f)
+		if c&0x80 == 0 {
+			return val, i + 1
+		}
+		if i >= 8 {
+			break
+		}
+	}
+	return 0, 0
+}
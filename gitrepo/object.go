@@ -0,0 +1,524 @@
+package gitrepo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1" // #nosec
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Hash is a SHA-1 object name.
+type Hash [20]byte
+
+// String returns the hex representation of the hash.
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// IsZero returns true if the hash is zero value.
+func (h Hash) IsZero() bool {
+	return h == Hash{}
+}
+
+// ParseHash parses the hex representation of a full object name.
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	if len(s) != 2*len(h) {
+		return h, fmt.Errorf("invalid object name: %q", s)
+	}
+	if _, err := hex.Decode(h[:], []byte(s)); err != nil {
+		return h, fmt.Errorf("invalid object name: %q", s)
+	}
+	return h, nil
+}
+
+// blobHash returns the object name of a blob which has the content.
+func blobHash(content []byte) Hash {
+	h := sha1.New() // #nosec
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	var r Hash
+	copy(r[:], h.Sum(nil))
+	return r
+}
+
+// ObjectType is the type of git object.
+type ObjectType int
+
+// Object types. The values are the same as type bits in pack files.
+const (
+	ObjectCommit ObjectType = 1
+	ObjectTree   ObjectType = 2
+	ObjectBlob   ObjectType = 3
+	ObjectTag    ObjectType = 4
+
+	objectOfsDelta = 6
+	objectRefDelta = 7
+)
+
+func (t ObjectType) String() string {
+	switch t {
+	case ObjectCommit:
+		return "commit"
+	case ObjectTree:
+		return "tree"
+	case ObjectBlob:
+		return "blob"
+	case ObjectTag:
+		return "tag"
+	}
+	return "unknown"
+}
+
+func parseObjectType(s string) (ObjectType, error) {
+	for _, t := range []ObjectType{ObjectCommit, ObjectTree, ObjectBlob, ObjectTag} {
+		if t.String() == s {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown object type: %q", s)
+}
+
+// ErrObjectNotFound represents error which an object is not found in the
+// repository. e.g. Missing history of shallow clones.
+var ErrObjectNotFound = errors.New("object not found")
+
+// objectStore reads loose objects and packed objects from objects
+// directories.
+type objectStore struct {
+	dirs []string
+
+	once  sync.Once
+	packs []*packFile
+	err   error
+}
+
+func newObjectStore(objectsDir string) *objectStore {
+	return &objectStore{dirs: append([]string{objectsDir}, alternates(objectsDir)...)}
+}
+
+// alternates returns alternate object directories.
+// https://git-scm.com/docs/gitrepository-layout#Documentation/gitrepository-layout.txt-objectsinfoalternates
+func alternates(objectsDir string) []string {
+	b, err := ioutil.ReadFile(filepath.Join(objectsDir, "info", "alternates"))
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(objectsDir, line)
+		}
+		dirs = append(dirs, line)
+	}
+	return dirs
+}
+
+func (s *objectStore) loadPacks() ([]*packFile, error) {
+	s.once.Do(func() {
+		for _, dir := range s.dirs {
+			idxs, err := filepath.Glob(filepath.Join(dir, "pack", "*.idx"))
+			if err != nil {
+				s.err = err
+				return
+			}
+			for _, idx := range idxs {
+				p, err := openPackFile(idx)
+				if err != nil {
+					s.err = err
+					return
+				}
+				s.packs = append(s.packs, p)
+			}
+		}
+	})
+	return s.packs, s.err
+}
+
+// read returns type and content of the object.
+func (s *objectStore) read(h Hash) (ObjectType, []byte, error) {
+	name := h.String()
+	for _, dir := range s.dirs {
+		typ, data, err := readLooseObject(filepath.Join(dir, name[:2], name[2:]))
+		if err == nil {
+			return typ, data, nil
+		}
+		if !os.IsNotExist(err) {
+			return 0, nil, fmt.Errorf("failed to read object %s: %w", name, err)
+		}
+	}
+	packs, err := s.loadPacks()
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, p := range packs {
+		if off, ok := p.find(h); ok {
+			typ, data, err := p.readAt(s, off)
+			if err != nil {
+				return 0, nil, fmt.Errorf("failed to read object %s: %w", name, err)
+			}
+			return typ, data, nil
+		}
+	}
+	return 0, nil, fmt.Errorf("%s: %w", name, ErrObjectNotFound)
+}
+
+// expand returns the full object name which starts with the given prefix of
+// hex representation.
+func (s *objectStore) expand(prefix string) (Hash, error) {
+	prefix = strings.ToLower(prefix)
+	found := make(map[Hash]bool)
+	for _, dir := range s.dirs {
+		names, _ := filepath.Glob(filepath.Join(dir, prefix[:2], prefix[2:]+"*"))
+		for _, n := range names {
+			if h, err := ParseHash(prefix[:2] + filepath.Base(n)); err == nil {
+				found[h] = true
+			}
+		}
+	}
+	packs, err := s.loadPacks()
+	if err != nil {
+		return Hash{}, err
+	}
+	for _, p := range packs {
+		for _, h := range p.findPrefix(prefix) {
+			found[h] = true
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Hash{}, fmt.Errorf("%s: %w", prefix, ErrObjectNotFound)
+	case 1:
+		for h := range found {
+			return h, nil
+		}
+	}
+	return Hash{}, fmt.Errorf("short object name %s is ambiguous", prefix)
+}
+
+func readLooseObject(path string) (ObjectType, []byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer zr.Close()
+	br := bufio.NewReader(zr)
+	header, err := br.ReadString(0)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid object header: %w", err)
+	}
+	fields := strings.SplitN(strings.TrimSuffix(header, "\x00"), " ", 2)
+	if len(fields) != 2 {
+		return 0, nil, fmt.Errorf("invalid object header: %q", header)
+	}
+	typ, err := parseObjectType(fields[0])
+	if err != nil {
+		return 0, nil, err
+	}
+	size, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid object header: %q", header)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(br, data); err != nil {
+		return 0, nil, err
+	}
+	return typ, data, nil
+}
+
+// packFile reads objects from a pack file with its index (version 2).
+// https://git-scm.com/docs/pack-format
+type packFile struct {
+	path    string
+	hashes  []Hash
+	offsets []int64
+
+	mu        sync.Mutex
+	f         *os.File
+	size      int64
+	cache     map[int64]cachedObject
+	cacheSize int
+}
+
+type cachedObject struct {
+	typ  ObjectType
+	data []byte
+}
+
+// maxPackCacheSize is the max total size of cached delta base objects per
+// pack file.
+const maxPackCacheSize = 32 << 20
+
+func openPackFile(idxPath string) (*packFile, error) {
+	b, err := ioutil.ReadFile(idxPath)
+	if err != nil {
+		return nil, err
+	}
+	const headerSize = 8 + 256*4
+	if len(b) < headerSize || !bytes.Equal(b[:4], []byte("\377tOc")) || binary.BigEndian.Uint32(b[4:8]) != 2 {
+		return nil, fmt.Errorf("%s: unsupported pack index version", idxPath)
+	}
+	n := int(binary.BigEndian.Uint32(b[headerSize-4 : headerSize]))
+	hashesAt := headerSize
+	offsetsAt := hashesAt + n*20 + n*4 // skip CRC32s
+	largeOffsetsAt := offsetsAt + n*4
+	if len(b) < largeOffsetsAt {
+		return nil, fmt.Errorf("%s: truncated pack index", idxPath)
+	}
+	p := &packFile{
+		path:    strings.TrimSuffix(idxPath, ".idx") + ".pack",
+		hashes:  make([]Hash, n),
+		offsets: make([]int64, n),
+	}
+	for i := 0; i < n; i++ {
+		copy(p.hashes[i][:], b[hashesAt+i*20:])
+		off := binary.BigEndian.Uint32(b[offsetsAt+i*4:])
+		if off&0x80000000 != 0 {
+			at := largeOffsetsAt + int(off&0x7fffffff)*8
+			if len(b) < at+8 {
+				return nil, fmt.Errorf("%s: truncated pack index", idxPath)
+			}
+			p.offsets[i] = int64(binary.BigEndian.Uint64(b[at:]))
+		} else {
+			p.offsets[i] = int64(off)
+		}
+	}
+	return p, nil
+}
+
+func (p *packFile) find(h Hash) (int64, bool) {
+	i := sort.Search(len(p.hashes), func(i int) bool { return bytes.Compare(p.hashes[i][:], h[:]) >= 0 })
+	if i < len(p.hashes) && p.hashes[i] == h {
+		return p.offsets[i], true
+	}
+	return 0, false
+}
+
+func (p *packFile) findPrefix(prefix string) []Hash {
+	i := sort.Search(len(p.hashes), func(i int) bool { return p.hashes[i].String() >= prefix })
+	var hs []Hash
+	for ; i < len(p.hashes) && strings.HasPrefix(p.hashes[i].String(), prefix); i++ {
+		hs = append(hs, p.hashes[i])
+	}
+	return hs
+}
+
+// readAt reads the object at the offset, resolving deltas.
+func (p *packFile) readAt(s *objectStore, off int64) (ObjectType, []byte, error) {
+	p.mu.Lock()
+	if c, ok := p.cache[off]; ok {
+		p.mu.Unlock()
+		return c.typ, c.data, nil
+	}
+	if p.f == nil {
+		f, err := os.Open(p.path)
+		if err != nil {
+			p.mu.Unlock()
+			return 0, nil, err
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			f.Close()
+			p.mu.Unlock()
+			return 0, nil, err
+		}
+		p.f, p.size = f, fi.Size()
+	}
+	f, size := p.f, p.size
+	p.mu.Unlock()
+
+	br := bufio.NewReader(io.NewSectionReader(f, off, size-off))
+	typ, size, err := readPackObjectHeader(br)
+	if err != nil {
+		return 0, nil, err
+	}
+	var baseType ObjectType
+	var base []byte
+	switch typ {
+	case objectOfsDelta:
+		rel, err := readOffsetDelta(br)
+		if err != nil {
+			return 0, nil, err
+		}
+		baseType, base, err = p.readAt(s, off-rel)
+		if err != nil {
+			return 0, nil, err
+		}
+	case objectRefDelta:
+		var h Hash
+		if _, err := io.ReadFull(br, h[:]); err != nil {
+			return 0, nil, err
+		}
+		baseType, base, err = s.read(h)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+	data, err := readZlib(br, size)
+	if err != nil {
+		return 0, nil, err
+	}
+	if base != nil {
+		typ = baseType
+		if data, err = applyDelta(base, data); err != nil {
+			return 0, nil, err
+		}
+	}
+	p.store(off, typ, data)
+	return typ, data, nil
+}
+
+func (p *packFile) store(off int64, typ ObjectType, data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cache == nil || p.cacheSize+len(data) > maxPackCacheSize {
+		p.cache = make(map[int64]cachedObject)
+		p.cacheSize = 0
+	}
+	p.cache[off] = cachedObject{typ: typ, data: data}
+	p.cacheSize += len(data)
+}
+
+func readPackObjectHeader(br *bufio.Reader) (ObjectType, int64, error) {
+	c, err := br.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	typ := ObjectType((c >> 4) & 7)
+	size := int64(c & 0x0f)
+	shift := uint(4)
+	for c&0x80 != 0 {
+		if c, err = br.ReadByte(); err != nil {
+			return 0, 0, err
+		}
+		size |= int64(c&0x7f) << shift
+		shift += 7
+	}
+	return typ, size, nil
+}
+
+func readOffsetDelta(br *bufio.Reader) (int64, error) {
+	c, err := br.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	off := int64(c & 0x7f)
+	for c&0x80 != 0 {
+		if c, err = br.ReadByte(); err != nil {
+			return 0, err
+		}
+		off = ((off + 1) << 7) | int64(c&0x7f)
+	}
+	return off, nil
+}
+
+func readZlib(r io.Reader, size int64) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data := make([]byte, size)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// applyDelta applies git delta data to the base object.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	errInvalid := errors.New("invalid delta")
+	readSize := func() (int, bool) {
+		size, shift := 0, uint(0)
+		for {
+			if len(delta) == 0 {
+				return 0, false
+			}
+			c := delta[0]
+			delta = delta[1:]
+			size |= int(c&0x7f) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				return size, true
+			}
+		}
+	}
+	srcSize, ok := readSize()
+	if !ok || srcSize != len(base) {
+		return nil, errInvalid
+	}
+	dstSize, ok := readSize()
+	if !ok {
+		return nil, errInvalid
+	}
+	out := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+		switch {
+		case op&0x80 != 0:
+			// Copy from base.
+			var off, size int
+			for i := uint(0); i < 4; i++ {
+				if op&(1<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errInvalid
+					}
+					off |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			for i := uint(0); i < 3; i++ {
+				if op&(1<<(4+i)) != 0 {
+					if len(delta) == 0 {
+						return nil, errInvalid
+					}
+					size |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if off+size > len(base) {
+				return nil, errInvalid
+			}
+			out = append(out, base[off:off+size]...)
+		case op != 0:
+			// Insert new data.
+			n := int(op)
+			if n > len(delta) {
+				return nil, errInvalid
+			}
+			out = append(out, delta[:n]...)
+			delta = delta[n:]
+		default:
+			return nil, errInvalid
+		}
+	}
+	if len(out) != dstSize {
+		return nil, errInvalid
+	}
+	return out, nil
+}
@@ -0,0 +1,469 @@
+// Package gitrepo provides a minimal read-only git repository reader which
+// works without git command. It resolves revisions, finds merge-base commits
+// and generates diff of commits and the working tree.
+package gitrepo
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Repository is a git repository.
+type Repository struct {
+	// gitDir is the .git directory of the working tree.
+	gitDir string
+	// commonDir is the directory which has objects and refs. It's different
+	// from gitDir for linked worktrees.
+	commonDir string
+	workTree  string
+
+	objects *objectStore
+}
+
+// Open opens the git repository which contains dir. It looks for .git in dir
+// and its parent directories as git does.
+func Open(dir string) (*Repository, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		gitDir, err := findGitDir(d)
+		if err != nil {
+			return nil, err
+		}
+		if gitDir != "" {
+			return openGitDir(gitDir, d)
+		}
+		if filepath.Dir(d) == d {
+			return nil, fmt.Errorf("not a git repository (or any of the parent directories): %s", dir)
+		}
+	}
+}
+
+// findGitDir returns the git directory of the working tree dir, or empty
+// string if dir is not the top of a working tree.
+func findGitDir(dir string) (string, error) {
+	dotgit := filepath.Join(dir, ".git")
+	fi, err := os.Stat(dotgit)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if fi.IsDir() {
+		return dotgit, nil
+	}
+	// .git file of linked worktrees and submodules. e.g. "gitdir: ../.git/worktrees/a"
+	b, err := ioutil.ReadFile(dotgit)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(b))
+	if !strings.HasPrefix(line, "gitdir: ") {
+		return "", fmt.Errorf("invalid .git file: %s", dotgit)
+	}
+	gitDir := strings.TrimPrefix(line, "gitdir: ")
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+	return gitDir, nil
+}
+
+func openGitDir(gitDir, workTree string) (*Repository, error) {
+	commonDir := gitDir
+	if b, err := ioutil.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = strings.TrimSpace(string(b))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(commonDir, "objects")); err != nil {
+		return nil, fmt.Errorf("invalid git directory %s: %w", gitDir, err)
+	}
+	return &Repository{
+		gitDir:    gitDir,
+		commonDir: commonDir,
+		workTree:  workTree,
+		objects:   newObjectStore(filepath.Join(commonDir, "objects")),
+	}, nil
+}
+
+// WorkTree returns the top directory of the working tree.
+func (r *Repository) WorkTree() string {
+	return r.workTree
+}
+
+// RelWorkdir returns the path of dir relative to the top of the working tree
+// with trailing slash, or empty string if dir is the top. It's equivalent to
+// `git rev-parse --show-prefix`.
+func (r *Repository) RelWorkdir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(r.workTree, abs)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		return "", nil
+	}
+	if strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is outside of the working tree %s", dir, r.workTree)
+	}
+	return filepath.ToSlash(rel) + "/", nil
+}
+
+// IsShallow returns true if the repository is a shallow clone.
+func (r *Repository) IsShallow() bool {
+	_, err := os.Stat(filepath.Join(r.commonDir, "shallow"))
+	return err == nil
+}
+
+var (
+	hexRe    = regexp.MustCompile(`^[0-9a-fA-F]{4,40}$`)
+	suffixRe = regexp.MustCompile(`(\^[0-9]*|~[0-9]*)$`)
+)
+
+// ResolveRevision returns the commit which the revision points to. It
+// supports full and short object names, HEAD, branches, remote-tracking
+// branches, tags and "~<n>" and "^<n>" suffixes.
+func (r *Repository) ResolveRevision(rev string) (Hash, error) {
+	if m := suffixRe.FindString(rev); m != "" && m != rev {
+		h, err := r.ResolveRevision(strings.TrimSuffix(rev, m))
+		if err != nil {
+			return Hash{}, err
+		}
+		n := 1
+		if len(m) > 1 {
+			if n, err = strconv.Atoi(m[1:]); err != nil {
+				return Hash{}, err
+			}
+		}
+		if m[0] == '^' {
+			return r.nthParent(h, n, rev)
+		}
+		for i := 0; i < n; i++ {
+			if h, err = r.nthParent(h, 1, rev); err != nil {
+				return Hash{}, err
+			}
+		}
+		return h, nil
+	}
+	h, err := r.resolveName(rev)
+	if err != nil {
+		return Hash{}, err
+	}
+	return r.peelToCommit(h)
+}
+
+func (r *Repository) nthParent(h Hash, n int, rev string) (Hash, error) {
+	if n == 0 {
+		return h, nil
+	}
+	c, err := r.Commit(h)
+	if err != nil {
+		return Hash{}, err
+	}
+	if len(c.Parents) < n {
+		return Hash{}, fmt.Errorf("revision %s not found", rev)
+	}
+	return c.Parents[n-1], nil
+}
+
+func (r *Repository) resolveName(name string) (Hash, error) {
+	// The same order as git rev-parse.
+	// https://git-scm.com/docs/gitrevisions#Documentation/gitrevisions.txt-emltrefnamegtemegemmasterememheadsmasterememrefsheadsmasterem
+	for _, ref := range []string{name, "refs/" + name, "refs/tags/" + name, "refs/heads/" + name,
+		"refs/remotes/" + name, "refs/remotes/" + name + "/HEAD"} {
+		if h, ok, err := r.readRef(ref, 0); err != nil {
+			return Hash{}, err
+		} else if ok {
+			return h, nil
+		}
+	}
+	if hexRe.MatchString(name) {
+		if len(name) == 40 {
+			return ParseHash(strings.ToLower(name))
+		}
+		return r.objects.expand(name)
+	}
+	return Hash{}, fmt.Errorf("unknown revision: %s", name)
+}
+
+// readRef reads a ref, following symbolic refs.
+func (r *Repository) readRef(name string, depth int) (Hash, bool, error) {
+	if depth > 5 {
+		return Hash{}, false, fmt.Errorf("too deep symbolic ref: %s", name)
+	}
+	if name != "HEAD" && !strings.HasPrefix(name, "refs/") && !isPseudoRef(name) {
+		return Hash{}, false, nil
+	}
+	// HEAD and pseudo refs are per worktree.
+	dir := r.commonDir
+	if !strings.HasPrefix(name, "refs/") {
+		dir = r.gitDir
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err == nil {
+		content := strings.TrimSpace(string(b))
+		if strings.HasPrefix(content, "ref: ") {
+			return r.readRef(strings.TrimPrefix(content, "ref: "), depth+1)
+		}
+		// FETCH_HEAD may have multiple lines with description.
+		if i := strings.IndexAny(content, " \t\n"); i >= 0 {
+			content = content[:i]
+		}
+		h, err := ParseHash(content)
+		return h, err == nil, err
+	}
+	if !os.IsNotExist(err) && !isDirError(err) {
+		return Hash{}, false, err
+	}
+	return r.readPackedRef(name)
+}
+
+// SymbolicRef returns the target of the symbolic ref (e.g.
+// refs/remotes/origin/HEAD).
+func (r *Repository) SymbolicRef(name string) (string, error) {
+	dir := r.commonDir
+	if !strings.HasPrefix(name, "refs/") {
+		dir = r.gitDir
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		return "", err
+	}
+	content := strings.TrimSpace(string(b))
+	if !strings.HasPrefix(content, "ref: ") {
+		return "", fmt.Errorf("%s is not a symbolic ref", name)
+	}
+	return strings.TrimPrefix(content, "ref: "), nil
+}
+
+func isPseudoRef(name string) bool {
+	return name == strings.ToUpper(name) && strings.HasSuffix(name, "HEAD")
+}
+
+func isDirError(err error) bool {
+	var pathErr *os.PathError
+	return errors.As(err, &pathErr) && strings.Contains(pathErr.Err.Error(), "directory")
+}
+
+func (r *Repository) readPackedRef(name string) (Hash, bool, error) {
+	b, err := ioutil.ReadFile(filepath.Join(r.commonDir, "packed-refs"))
+	if os.IsNotExist(err) {
+		return Hash{}, false, nil
+	}
+	if err != nil {
+		return Hash{}, false, err
+	}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "^") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == name {
+			h, err := ParseHash(fields[0])
+			return h, err == nil, err
+		}
+	}
+	return Hash{}, false, s.Err()
+}
+
+// peelToCommit peels annotated tags.
+func (r *Repository) peelToCommit(h Hash) (Hash, error) {
+	for i := 0; i < 10; i++ {
+		typ, data, err := r.objects.read(h)
+		if err != nil {
+			return Hash{}, err
+		}
+		switch typ {
+		case ObjectCommit:
+			return h, nil
+		case ObjectTag:
+			obj := headerValue(data, "object")
+			if h, err = ParseHash(obj); err != nil {
+				return Hash{}, err
+			}
+		default:
+			return Hash{}, fmt.Errorf("%s is a %s, not a commit", h, typ)
+		}
+	}
+	return Hash{}, fmt.Errorf("too deep tag: %s", h)
+}
+
+// Commit represents a commit object.
+type Commit struct {
+	Hash    Hash
+	Tree    Hash
+	Parents []Hash
+	// CommitTime is the committer time in Unix time.
+	CommitTime int64
+}
+
+// Commit reads the commit object.
+func (r *Repository) Commit(h Hash) (*Commit, error) {
+	typ, data, err := r.objects.read(h)
+	if err != nil {
+		return nil, err
+	}
+	if typ != ObjectCommit {
+		return nil, fmt.Errorf("%s is a %s, not a commit", h, typ)
+	}
+	c := &Commit{Hash: h}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			// End of headers.
+			break
+		}
+		key, value := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			key, value = line[:i], line[i+1:]
+		}
+		switch key {
+		case "tree":
+			if c.Tree, err = ParseHash(value); err != nil {
+				return nil, err
+			}
+		case "parent":
+			p, err := ParseHash(value)
+			if err != nil {
+				return nil, err
+			}
+			c.Parents = append(c.Parents, p)
+		case "committer":
+			// "committer Name <email> 1600000000 +0900"
+			fields := strings.Fields(value)
+			if len(fields) >= 2 {
+				c.CommitTime, _ = strconv.ParseInt(fields[len(fields)-2], 10, 64)
+			}
+		}
+	}
+	if c.Tree.IsZero() {
+		return nil, fmt.Errorf("commit %s has no tree", h)
+	}
+	return c, nil
+}
+
+func headerValue(data []byte, key string) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, key+" ") {
+			return strings.TrimPrefix(line, key+" ")
+		}
+	}
+	return ""
+}
+
+// MergeBase returns a best common ancestor of commits a and b. If there are
+// multiple best common ancestors (criss-cross merges), it returns the most
+// recent one. It returns an error wrapping ErrObjectNotFound if the history
+// is not available. e.g. shallow clones.
+func (r *Repository) MergeBase(a, b Hash) (Hash, error) {
+	ancestorsA, err := r.ancestors(a, nil)
+	if err != nil {
+		return Hash{}, err
+	}
+	// Walk ancestors of b and stop at common ancestors.
+	var candidates []*Commit
+	seen := map[Hash]bool{b: true}
+	queue := []Hash{b}
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+		c, err := r.Commit(h)
+		if err != nil {
+			if errors.Is(err, ErrObjectNotFound) && r.IsShallow() {
+				continue
+			}
+			return Hash{}, err
+		}
+		if ancestorsA[h] {
+			candidates = append(candidates, c)
+			continue
+		}
+		for _, p := range c.Parents {
+			if !seen[p] {
+				seen[p] = true
+				queue = append(queue, p)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		if r.IsShallow() {
+			return Hash{}, fmt.Errorf("no merge base of %s and %s in shallow clone: %w", a, b, ErrObjectNotFound)
+		}
+		return Hash{}, fmt.Errorf("no merge base of %s and %s", a, b)
+	}
+	// Remove candidates which are ancestors of other candidates.
+	var best *Commit
+	for _, c := range candidates {
+		redundant := false
+		for _, other := range candidates {
+			if other.Hash == c.Hash {
+				continue
+			}
+			as, err := r.ancestors(other.Hash, map[Hash]bool{})
+			if err != nil {
+				return Hash{}, err
+			}
+			if as[c.Hash] {
+				redundant = true
+				break
+			}
+		}
+		if !redundant && (best == nil || c.CommitTime > best.CommitTime) {
+			best = c
+		}
+	}
+	return best.Hash, nil
+}
+
+// ancestors returns the set of h and its ancestors. Missing parents of
+// shallow commits are ignored.
+func (r *Repository) ancestors(h Hash, set map[Hash]bool) (map[Hash]bool, error) {
+	if set == nil {
+		set = make(map[Hash]bool)
+	}
+	stack := []Hash{h}
+	set[h] = true
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		c, err := r.Commit(h)
+		if err != nil {
+			if errors.Is(err, ErrObjectNotFound) && r.IsShallow() {
+				continue
+			}
+			return nil, err
+		}
+		for _, p := range c.Parents {
+			if !set[p] {
+				set[p] = true
+				stack = append(stack, p)
+			}
+		}
+	}
+	return set, nil
+}
@@ -0,0 +1,121 @@
+package gitrepo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com",
+		"-c", "init.defaultBranch=main", "-c", "core.autocrlf=false"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimRight(string(out), "\n")
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setupRepo creates a repository which has main branch and feature branch
+// diverged from main, and returns its path.
+func setupRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	dir, err := ioutil.TempDir("", "reviewdog-gitrepo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "init", "-q")
+	writeFile(t, dir, "a.txt", "a\n")
+	writeFile(t, dir, "sub/b.txt", "b\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "initial")
+	git(t, dir, "tag", "-a", "-m", "v1", "v1")
+	git(t, dir, "checkout", "-q", "-b", "feature")
+	writeFile(t, dir, "feature.txt", "feature\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "feature")
+	git(t, dir, "checkout", "-q", "main")
+	writeFile(t, dir, "main.txt", "main\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "main")
+	return dir
+}
+
+func TestRepository(t *testing.T) {
+	dir := setupRepo(t)
+	defer os.RemoveAll(dir)
+
+	for _, packed := range []bool{false, true} {
+		if packed {
+			git(t, dir, "gc", "-q")
+		}
+		repo, err := Open(filepath.Join(dir, "sub"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rev := range []string{"HEAD", "main", "feature", "refs/heads/feature", "v1", "HEAD~1", "feature^", "HEAD~1^0"} {
+			got, err := repo.ResolveRevision(rev)
+			if err != nil {
+				t.Errorf("ResolveRevision(%q) failed: %v", rev, err)
+				continue
+			}
+			if want := git(t, dir, "rev-parse", rev+"^{commit}"); got.String() != want {
+				t.Errorf("ResolveRevision(%q) = %s, want %s", rev, got, want)
+			}
+		}
+		short := git(t, dir, "rev-parse", "--short", "HEAD")
+		if got, err := repo.ResolveRevision(short); err != nil || got.String() != git(t, dir, "rev-parse", "HEAD") {
+			t.Errorf("ResolveRevision(%q) = %s, %v", short, got, err)
+		}
+		if _, err := repo.ResolveRevision("unknown"); err == nil {
+			t.Error("ResolveRevision(unknown) got no error")
+		}
+
+		a, _ := repo.ResolveRevision("main")
+		b, _ := repo.ResolveRevision("feature")
+		mb, err := repo.MergeBase(a, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := git(t, dir, "merge-base", "main", "feature"); mb.String() != want {
+			t.Errorf("MergeBase() = %s, want %s", mb, want)
+		}
+	}
+}
+
+func TestRepository_RelWorkdir(t *testing.T) {
+	dir := setupRepo(t)
+	defer os.RemoveAll(dir)
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []string{"", "sub"} {
+		got, err := repo.RelWorkdir(filepath.Join(dir, d))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := git(t, filepath.Join(dir, d), "rev-parse", "--show-prefix"); got != want {
+			t.Errorf("RelWorkdir(%q) = %q, want %q", d, got, want)
+		}
+	}
+}
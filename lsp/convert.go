@@ -0,0 +1,145 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/reviewdog/reviewdog/lsp/protocol"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+// sources reads lines of files lazily to convert byte columns of
+// diagnostics to UTF-16 characters of LSP.
+type sources map[string][]string
+
+func (s sources) line(path string, lnum int) string {
+	lines, ok := s[path]
+	if !ok {
+		if b, err := ioutil.ReadFile(path); err == nil {
+			lines = strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+			for i, l := range lines {
+				lines[i] = strings.TrimSuffix(l, "\r")
+			}
+		}
+		s[path] = lines
+	}
+	if lnum < 1 || lnum > len(lines) {
+		return ""
+	}
+	return lines[lnum-1]
+}
+
+// toRange converts a range of rdf to LSP. Ranges whose start and end don't
+// have columns are linewise and they end at the end of the end line.
+func toRange(r *rdf.Range, path string, src sources) protocol.Range {
+	start, end := r.GetStart(), r.GetEnd()
+	if start.GetLine() == 0 {
+		return protocol.Range{}
+	}
+	if end.GetLine() == 0 {
+		end = &rdf.Position{Line: start.GetLine(), Column: start.GetColumn()}
+		if start.GetColumn() > 0 {
+			// zero-length range.
+			p := position(start, path, src)
+			return protocol.Range{Start: p, End: p}
+		}
+	}
+	if start.GetColumn() == 0 && end.GetColumn() == 0 {
+		lnum := int(end.GetLine())
+		return protocol.Range{
+			Start: protocol.Position{Line: int(start.GetLine()) - 1},
+			End:   protocol.Position{Line: lnum - 1, Character: utf16Len(src.line(path, lnum))},
+		}
+	}
+	return protocol.Range{Start: position(start, path, src), End: position(end, path, src)}
+}
+
+func position(p *rdf.Position, path string, src sources) protocol.Position {
+	lnum := int(p.GetLine())
+	return protocol.Position{Line: lnum - 1, Character: character(src.line(path, lnum), int(p.GetColumn()))}
+}
+
+// character converts a 1-based column in bytes of the line to a 0-based
+// character in UTF-16 code units. Columns after the end of the line are
+// counted as one character per byte.
+func character(line string, column int) int {
+	if column <= 1 {
+		return 0
+	}
+	b := column - 1
+	if b > len(line) {
+		return utf16Len(line) + b - len(line)
+	}
+	return utf16Len(line[:b])
+}
+
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// toDiagnostic converts a diagnostic of the tool to LSP. level is the report
+// level of the tool for diagnostics without severity.
+func toDiagnostic(d *rdf.Diagnostic, tool, level, path string, src sources) protocol.Diagnostic {
+	ld := protocol.Diagnostic{
+		Range:    toRange(d.GetLocation().GetRange(), path, src),
+		Severity: toSeverity(d.GetSeverity(), level),
+		Source:   tool,
+		Message:  d.GetMessage(),
+	}
+	if name := d.GetSource().GetName(); name != "" {
+		ld.Source = name
+	}
+	if code := d.GetCode().GetValue(); code != "" {
+		ld.Code, _ = json.Marshal(code)
+		if url := d.GetCode().GetUrl(); url != "" {
+			ld.CodeDescription = &protocol.CodeDescription{Href: url}
+		}
+	}
+	return ld
+}
+
+func toSeverity(s rdf.Severity, level string) protocol.DiagnosticSeverity {
+	switch s {
+	case rdf.Severity_ERROR:
+		return protocol.SeverityError
+	case rdf.Severity_WARNING:
+		return protocol.SeverityWarning
+	case rdf.Severity_INFO:
+		return protocol.SeverityInformation
+	}
+	switch level {
+	case "error":
+		return protocol.SeverityError
+	case "warning":
+		return protocol.SeverityWarning
+	case "info":
+		return protocol.SeverityInformation
+	}
+	return 0
+}
+
+// toCodeActions converts suggestions of the diagnostic to quick fixes of the
+// document.
+func toCodeActions(d *rdf.Diagnostic, ld protocol.Diagnostic, uri, path string, src sources) []protocol.CodeAction {
+	var actions []protocol.CodeAction
+	for i, s := range d.GetSuggestions() {
+		title := fmt.Sprintf("Apply suggestion of %s", ld.Source)
+		if len(d.GetSuggestions()) > 1 {
+			title += fmt.Sprintf(" (%d/%d)", i+1, len(d.GetSuggestions()))
+		}
+		actions = append(actions, protocol.CodeAction{
+			Title:       title,
+			Kind:        protocol.CodeActionKindQuickFix,
+			Diagnostics: []protocol.Diagnostic{ld},
+			Edit: &protocol.WorkspaceEdit{
+				Changes: map[string][]protocol.TextEdit{
+					uri: {{Range: toRange(s.GetRange(), path, src), NewText: s.GetText()}},
+				},
+			},
+		})
+	}
+	return actions
+}
@@ -0,0 +1,61 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/reviewdog/reviewdog/lsp/protocol"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+func TestToRange(t *testing.T) {
+	src := sources{"a.txt": {"a𐐀b", "line 2", "line 3"}}
+	pos := func(line, col int32) *rdf.Position { return &rdf.Position{Line: line, Column: col} }
+	tests := []struct {
+		name string
+		in   *rdf.Range
+		want protocol.Range
+	}{
+		{
+			name: "no line",
+			in:   &rdf.Range{},
+			want: protocol.Range{},
+		},
+		{
+			name: "line",
+			in:   &rdf.Range{Start: pos(2, 0)},
+			want: protocol.Range{Start: protocol.Position{Line: 1}, End: protocol.Position{Line: 1, Character: 6}},
+		},
+		{
+			name: "lines",
+			in:   &rdf.Range{Start: pos(1, 0), End: pos(3, 0)},
+			want: protocol.Range{Start: protocol.Position{Line: 0}, End: protocol.Position{Line: 2, Character: 6}},
+		},
+		{
+			name: "surrogate pair",
+			in:   &rdf.Range{Start: pos(1, 2), End: pos(1, 7)},
+			want: protocol.Range{Start: protocol.Position{Line: 0, Character: 1}, End: protocol.Position{Line: 0, Character: 4}},
+		},
+		{
+			name: "zero-length",
+			in:   &rdf.Range{Start: pos(1, 6)},
+			want: protocol.Range{Start: protocol.Position{Line: 0, Character: 3}, End: protocol.Position{Line: 0, Character: 3}},
+		},
+		{
+			name: "next line",
+			in:   &rdf.Range{Start: pos(2, 6), End: pos(3, 1)},
+			want: protocol.Range{Start: protocol.Position{Line: 1, Character: 5}, End: protocol.Position{Line: 2, Character: 0}},
+		},
+		{
+			name: "after the end of line",
+			in:   &rdf.Range{Start: pos(2, 9)},
+			want: protocol.Range{Start: protocol.Position{Line: 1, Character: 8}, End: protocol.Position{Line: 1, Character: 8}},
+		},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, toRange(tt.in, "a.txt", src)); diff != "" {
+			t.Errorf("[%s] toRange() has diff:\n%s", tt.name, diff)
+		}
+	}
+}
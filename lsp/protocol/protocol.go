@@ -0,0 +1,201 @@
+// Package protocol provides types of Language Server Protocol which reviewdog
+// uses.
+//
+// Specification: https://microsoft.github.io/language-server-protocol/specifications/specification-3-16/
+package protocol
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Position in a text document expressed as zero-based line and zero-based
+// character offset in UTF-16 code units.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range in a text document. The end position is exclusive.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location represents a location inside a resource.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// DiagnosticSeverity is the severity of Diagnostic.
+type DiagnosticSeverity int
+
+// Severities of Diagnostic.
+const (
+	SeverityError       DiagnosticSeverity = 1
+	SeverityWarning     DiagnosticSeverity = 2
+	SeverityInformation DiagnosticSeverity = 3
+	SeverityHint        DiagnosticSeverity = 4
+)
+
+// CodeDescription is a structure to capture a description for an error code.
+type CodeDescription struct {
+	Href string `json:"href"`
+}
+
+// DiagnosticRelatedInformation represents a related message and source code
+// location for a diagnostic.
+type DiagnosticRelatedInformation struct {
+	Location Location `json:"location"`
+	Message  string   `json:"message"`
+}
+
+// Diagnostic represents a diagnostic, such as a compiler error or warning.
+type Diagnostic struct {
+	Range    Range              `json:"range"`
+	Severity DiagnosticSeverity `json:"severity,omitempty"`
+	// Code is a number or a string.
+	Code               json.RawMessage                `json:"code,omitempty"`
+	CodeDescription    *CodeDescription               `json:"codeDescription,omitempty"`
+	Source             string                         `json:"source,omitempty"`
+	Message            string                         `json:"message"`
+	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
+}
+
+// PublishDiagnosticsParams is the params of textDocument/publishDiagnostics
+// notification.
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// TextDocumentIdentifier identifies a text document.
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+// TextEdit is a textual edit applicable to a text document.
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// WorkspaceEdit represents changes to many resources.
+type WorkspaceEdit struct {
+	Changes map[string][]TextEdit `json:"changes"`
+}
+
+// CodeActionKindQuickFix is the kind of code actions which fix problems.
+const CodeActionKindQuickFix = "quickfix"
+
+// CodeAction represents a change that can be performed in code.
+type CodeAction struct {
+	Title       string         `json:"title"`
+	Kind        string         `json:"kind,omitempty"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	Edit        *WorkspaceEdit `json:"edit,omitempty"`
+}
+
+// CodeActionParams is the params of textDocument/codeAction request.
+type CodeActionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+// DidSaveTextDocumentParams is the params of textDocument/didSave
+// notification.
+type DidSaveTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// InitializeParams is the params of initialize request.
+type InitializeParams struct {
+	RootURI  string `json:"rootUri,omitempty"`
+	RootPath string `json:"rootPath,omitempty"`
+}
+
+// InitializeResult is the result of initialize request.
+type InitializeResult struct {
+	Capabilities ServerCapabilities `json:"capabilities"`
+	ServerInfo   *ServerInfo        `json:"serverInfo,omitempty"`
+}
+
+// ServerInfo is information about the server.
+type ServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// ServerCapabilities defines capabilities provided by the server.
+type ServerCapabilities struct {
+	TextDocumentSync   *TextDocumentSyncOptions `json:"textDocumentSync,omitempty"`
+	CodeActionProvider *CodeActionOptions       `json:"codeActionProvider,omitempty"`
+}
+
+// TextDocumentSyncKind defines how text documents are synced.
+type TextDocumentSyncKind int
+
+// TextDocumentSyncKindNone means documents are not synced.
+const TextDocumentSyncKindNone TextDocumentSyncKind = 0
+
+// TextDocumentSyncOptions is options of text document synchronization.
+type TextDocumentSyncOptions struct {
+	OpenClose bool                 `json:"openClose"`
+	Change    TextDocumentSyncKind `json:"change"`
+	Save      *SaveOptions         `json:"save,omitempty"`
+}
+
+// SaveOptions is options of textDocument/didSave notification.
+type SaveOptions struct {
+	IncludeText bool `json:"includeText"`
+}
+
+// CodeActionOptions is options of code actions.
+type CodeActionOptions struct {
+	CodeActionKinds []string `json:"codeActionKinds,omitempty"`
+}
+
+// MessageType is the type of window/showMessage and window/logMessage.
+type MessageType int
+
+// Message types.
+const (
+	MessageTypeError   MessageType = 1
+	MessageTypeWarning MessageType = 2
+	MessageTypeInfo    MessageType = 3
+	MessageTypeLog     MessageType = 4
+)
+
+// ShowMessageParams is the params of window/showMessage notification.
+type ShowMessageParams struct {
+	Type    MessageType `json:"type"`
+	Message string      `json:"message"`
+}
+
+// PathToURI returns the file URI of the absolute path.
+func PathToURI(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		// e.g. C:/path on Windows.
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// URIToPath returns the path of the file URI. It returns empty string if uri
+// is not a file URI.
+func URIToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	p := u.Path
+	if runtime.GOOS == "windows" {
+		p = strings.TrimPrefix(p, "/")
+	}
+	return filepath.FromSlash(p)
+}
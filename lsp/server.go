@@ -0,0 +1,325 @@
+// Package lsp provides a language server which runs reviewdog runners of the
+// project config on save and publishes the results as diagnostics.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/filter"
+	"github.com/reviewdog/reviewdog/lsp/protocol"
+	"github.com/reviewdog/reviewdog/project"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+// Option is an option of Server.
+type Option struct {
+	// LoadConfig loads the project config. It's called on each run to pick up
+	// changes of the config.
+	LoadConfig func() (*project.Config, error)
+	// Runners to run. Empty runs all runners.
+	Runners map[string]bool
+	// NewDiffService returns a diff service to filter results. It's called on
+	// each run because the diff changes on save.
+	NewDiffService func() (reviewdog.DiffService, error)
+	FilterMode     filter.Mode
+	// FilterOptions returns options of the filter. It's called on each run
+	// (e.g. to blame changed files again). Optional.
+	FilterOptions func(ctx context.Context) ([]filter.Option, error)
+	// Version of the server.
+	Version string
+}
+
+// Server is a language server which runs runners of the project config on
+// save and publishes the results as textDocument/publishDiagnostics.
+// Suggestions are provided as quick fixes of textDocument/codeAction.
+type Server struct {
+	conn *conn
+	opt  *Option
+
+	// runc triggers runs. Triggers while running are coalesced into one.
+	runc chan struct{}
+
+	mu sync.Mutex
+	// published diagnostics of the last run per document URI.
+	published map[string][]*published
+}
+
+type published struct {
+	diagnostic *rdf.Diagnostic
+	lsp        protocol.Diagnostic
+}
+
+// NewServer returns a new Server which reads requests from in and writes
+// responses to out.
+func NewServer(in io.Reader, out io.Writer, opt *Option) *Server {
+	return &Server{
+		conn:      newConn(in, out),
+		opt:       opt,
+		runc:      make(chan struct{}, 1),
+		published: make(map[string][]*published),
+	}
+}
+
+// Serve serves requests until the exit notification or the end of input.
+func (s *Server) Serve(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.runLoop(ctx)
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	shutdown := false
+	for {
+		msg, err := s.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			var rerr *rpcError
+			if errors.As(err, &rerr) {
+				// The ID of the response to invalid JSON is null.
+				null := json.RawMessage("null")
+				if err := s.conn.reply(&null, nil, rerr); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+		if msg.Method == "exit" {
+			if !shutdown {
+				return errors.New("exit without shutdown")
+			}
+			return nil
+		}
+		if msg.Method == "shutdown" {
+			shutdown = true
+		}
+		result, rerr := s.handle(ctx, msg)
+		if msg.ID == nil {
+			// Notifications don't have responses.
+			if rerr != nil {
+				log.Printf("reviewdog: lsp: %s: %v", msg.Method, rerr)
+			}
+			continue
+		}
+		if err := s.conn.reply(msg.ID, result, rerr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(ctx context.Context, msg *message) (interface{}, *rpcError) {
+	switch msg.Method {
+	case "initialize":
+		var params protocol.InitializeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		if err := chdirRoot(&params); err != nil {
+			return nil, &rpcError{Code: codeInternalError, Message: err.Error()}
+		}
+		return &protocol.InitializeResult{
+			Capabilities: protocol.ServerCapabilities{
+				TextDocumentSync: &protocol.TextDocumentSyncOptions{
+					OpenClose: true,
+					Change:    protocol.TextDocumentSyncKindNone,
+					Save:      &protocol.SaveOptions{},
+				},
+				CodeActionProvider: &protocol.CodeActionOptions{
+					CodeActionKinds: []string{protocol.CodeActionKindQuickFix},
+				},
+			},
+			ServerInfo: &protocol.ServerInfo{Name: "reviewdog", Version: s.opt.Version},
+		}, nil
+	case "initialized", "textDocument/didSave":
+		s.trigger()
+		return nil, nil
+	case "textDocument/codeAction":
+		var params protocol.CodeActionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return s.codeActions(&params), nil
+	case "shutdown":
+		return nil, nil
+	}
+	if msg.ID == nil {
+		// Ignore other notifications (e.g. textDocument/didOpen and $/cancelRequest).
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+}
+
+// chdirRoot changes the working directory to the workspace root to run
+// runners there.
+func chdirRoot(params *protocol.InitializeParams) error {
+	root := params.RootPath
+	if params.RootURI != "" {
+		root = protocol.URIToPath(params.RootURI)
+	}
+	if root == "" {
+		return nil
+	}
+	return os.Chdir(root)
+}
+
+// trigger triggers a run. It doesn't block.
+func (s *Server) trigger() {
+	select {
+	case s.runc <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Server) runLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.runc:
+			if err := s.check(ctx); err != nil && ctx.Err() == nil {
+				s.showMessage(protocol.MessageTypeError, fmt.Sprintf("reviewdog: %v", err))
+			}
+		}
+	}
+}
+
+// check runs runners and publishes filtered results. It also clears
+// diagnostics of documents which don't have results anymore.
+func (s *Server) check(ctx context.Context) error {
+	conf, err := s.opt.LoadConfig()
+	if err != nil {
+		return err
+	}
+	results, err := project.RunAndParse(ctx, conf, s.opt.Runners, "", false)
+	if err != nil {
+		return err
+	}
+	d, err := s.opt.NewDiffService()
+	if err != nil {
+		return err
+	}
+	filediffs, err := reviewdog.FileDiffs(ctx, d)
+	if err != nil {
+		return err
+	}
+	var filterOpts []filter.Option
+	if s.opt.FilterOptions != nil {
+		if filterOpts, err = s.opt.FilterOptions(ctx); err != nil {
+			return err
+		}
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	src := make(sources)
+	docs := make(map[string][]*published)
+	var failures []string
+	results.Range(func(tool string, result *reviewdog.Result) {
+		if err := result.CheckUnexpectedFailure(); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", tool, err))
+			return
+		}
+		checks := filter.FilterCheck(result.Diagnostics, filediffs, d.Strip(), cwd, s.opt.FilterMode, filterOpts...)
+		for _, c := range checks {
+			if !c.ShouldReport {
+				continue
+			}
+			path := c.Diagnostic.GetLocation().GetPath()
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(cwd, path)
+			}
+			uri := protocol.PathToURI(path)
+			docs[uri] = append(docs[uri], &published{
+				diagnostic: c.Diagnostic,
+				lsp:        toDiagnostic(c.Diagnostic, tool, result.Level, path, src),
+			})
+		}
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for uri := range s.published {
+		if _, ok := docs[uri]; !ok {
+			docs[uri] = nil
+		}
+	}
+	uris := make([]string, 0, len(docs))
+	for uri := range docs {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		ps := docs[uri]
+		sort.SliceStable(ps, func(i, j int) bool {
+			a, b := ps[i].lsp, ps[j].lsp
+			if a.Range.Start.Line != b.Range.Start.Line {
+				return a.Range.Start.Line < b.Range.Start.Line
+			}
+			return a.Source < b.Source
+		})
+		diagnostics := make([]protocol.Diagnostic, 0, len(ps))
+		for _, p := range docs[uri] {
+			diagnostics = append(diagnostics, p.lsp)
+		}
+		params := &protocol.PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics}
+		if err := s.conn.notify("textDocument/publishDiagnostics", params); err != nil {
+			return err
+		}
+		if len(docs[uri]) == 0 {
+			delete(s.published, uri)
+		} else {
+			s.published[uri] = docs[uri]
+		}
+	}
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return fmt.Errorf("runners failed:\n%s", strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// codeActions returns quick fixes of suggestions of diagnostics whose lines
+// overlap the requested range.
+func (s *Server) codeActions(params *protocol.CodeActionParams) []protocol.CodeAction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	uri := params.TextDocument.URI
+	path := protocol.URIToPath(uri)
+	src := make(sources)
+	actions := make([]protocol.CodeAction, 0)
+	for _, p := range s.published[uri] {
+		r := p.lsp.Range
+		if r.End.Line < params.Range.Start.Line || r.Start.Line > params.Range.End.Line {
+			continue
+		}
+		actions = append(actions, toCodeActions(p.diagnostic, p.lsp, uri, path, src)...)
+	}
+	return actions
+}
+
+func (s *Server) showMessage(typ protocol.MessageType, msg string) {
+	if err := s.conn.notify("window/showMessage", &protocol.ShowMessageParams{Type: typ, Message: msg}); err != nil {
+		log.Printf("reviewdog: lsp: %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/filter"
+	"github.com/reviewdog/reviewdog/lsp/protocol"
+	"github.com/reviewdog/reviewdog/project"
+)
+
+const testResult = `{
+  "diagnostics": [
+    {
+      "message": "use world",
+      "location": {"path": "a.txt", "range": {"start": {"line": 1, "column": 8}, "end": {"line": 1, "column": 14}}},
+      "severity": "WARNING",
+      "code": {"value": "W1", "url": "https://example.com/W1"},
+      "suggestions": [{"range": {"start": {"line": 1, "column": 8}, "end": {"line": 1, "column": 14}}, "text": "world"}]
+    },
+    {
+      "message": "line 2",
+      "location": {"path": "a.txt", "range": {"start": {"line": 2}}}
+    },
+    {
+      "message": "filtered",
+      "location": {"path": "b.txt", "range": {"start": {"line": 1}}}
+    }
+  ]
+}`
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reviewdog-lsp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	// The resolved path of the temp dir (e.g. /private/var on macOS).
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+	writeFile := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("a.txt", "héllo wörld\nline 2\n")
+	writeFile("result.json", testResult)
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	s := NewServer(inR, outW, &Option{
+		LoadConfig: func() (*project.Config, error) {
+			return project.Parse([]byte("runner:\n  tool:\n    cmd: cat result.json\n    format: rdjson\n    level: info\n"))
+		},
+		NewDiffService: func() (reviewdog.DiffService, error) {
+			return &fakeDiff{diff: "--- a/a.txt\n+++ b/a.txt\n@@ -1,0 +1,2 @@\n+héllo wörld\n+line 2\n"}, nil
+		},
+		FilterMode: filter.ModeAdded,
+	})
+	done := make(chan error)
+	go func() {
+		done <- s.Serve(context.Background())
+		outW.Close()
+	}()
+	client := newConn(outR, inW)
+	request := func(id int, method string, params interface{}) {
+		b, _ := json.Marshal(params)
+		rawID := json.RawMessage(fmt.Sprint(id))
+		if err := client.write(&message{ID: &rawID, Method: method, Params: b}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(v interface{}) *message {
+		msg, err := client.read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := msg.Params
+		if msg.Method == "" {
+			b, _ = json.Marshal(msg.Result)
+		}
+		if err := json.Unmarshal(b, v); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+	uri := protocol.PathToURI(filepath.Join(dir, "a.txt"))
+
+	request(1, "initialize", &protocol.InitializeParams{RootURI: protocol.PathToURI(dir)})
+	var initResult protocol.InitializeResult
+	read(&initResult)
+	if initResult.Capabilities.CodeActionProvider == nil {
+		t.Errorf("codeActionProvider is not provided: %+v", initResult)
+	}
+
+	if err := client.notify("initialized", struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	var params protocol.PublishDiagnosticsParams
+	if msg := read(&params); msg.Method != "textDocument/publishDiagnostics" {
+		t.Fatalf("got %s, want textDocument/publishDiagnostics", msg.Method)
+	}
+	code, _ := json.Marshal("W1")
+	diagnostic := protocol.Diagnostic{
+		Range:           protocol.Range{Start: protocol.Position{Line: 0, Character: 6}, End: protocol.Position{Line: 0, Character: 11}},
+		Severity:        protocol.SeverityWarning,
+		Code:            code,
+		CodeDescription: &protocol.CodeDescription{Href: "https://example.com/W1"},
+		Source:          "tool",
+		Message:         "use world",
+	}
+	want := protocol.PublishDiagnosticsParams{
+		URI: uri,
+		Diagnostics: []protocol.Diagnostic{
+			diagnostic,
+			{
+				Range:    protocol.Range{Start: protocol.Position{Line: 1}, End: protocol.Position{Line: 1, Character: 6}},
+				Severity: protocol.SeverityInformation,
+				Source:   "tool",
+				Message:  "line 2",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, params); diff != "" {
+		t.Errorf("publishDiagnostics has diff:\n%s", diff)
+	}
+
+	request(2, "textDocument/codeAction", &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        protocol.Range{Start: protocol.Position{Line: 0, Character: 7}, End: protocol.Position{Line: 0, Character: 7}},
+	})
+	var actions []protocol.CodeAction
+	read(&actions)
+	wantActions := []protocol.CodeAction{
+		{
+			Title:       "Apply suggestion of tool",
+			Kind:        protocol.CodeActionKindQuickFix,
+			Diagnostics: []protocol.Diagnostic{diagnostic},
+			Edit: &protocol.WorkspaceEdit{Changes: map[string][]protocol.TextEdit{
+				uri: {{Range: diagnostic.Range, NewText: "world"}},
+			}},
+		},
+	}
+	if diff := cmp.Diff(wantActions, actions); diff != "" {
+		t.Errorf("codeAction has diff:\n%s", diff)
+	}
+
+	// Diagnostics are cleared when they are fixed.
+	writeFile("result.json", `{"diagnostics": []}`)
+	if err := client.notify("textDocument/didSave", &protocol.DidSaveTextDocumentParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	read(&params)
+	if diff := cmp.Diff(protocol.PublishDiagnosticsParams{URI: uri, Diagnostics: []protocol.Diagnostic{}}, params); diff != "" {
+		t.Errorf("publishDiagnostics has diff:\n%s", diff)
+	}
+
+	request(3, "shutdown", nil)
+	var result interface{}
+	read(&result)
+	if err := client.notify("exit", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Serve() returns error: %v", err)
+	}
+}
+
+type fakeDiff struct {
+	diff string
+}
+
+func (f *fakeDiff) Diff(_ context.Context) ([]byte, error) {
+	return []byte(f.diff), nil
+}
+
+func (f *fakeDiff) Strip() int {
+	return 1
+}
@@ -0,0 +1,234 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/reviewdog/reviewdog/lsp/protocol"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+var _ Parser = &LSPParser{}
+
+// LSPParser is parser for JSON of LSP diagnostics. It accepts a stream of
+// JSON values which are PublishDiagnosticsParams, Diagnostic, or arrays of
+// them. Paths of diagnostics are their `uri` or `file` fields (e.g. pyright
+// --outputjson) or the `uri` of PublishDiagnosticsParams.
+//
+// https://microsoft.github.io/language-server-protocol/specifications/specification-3-16/#diagnostic
+type LSPParser struct{}
+
+// NewLSPParser returns a new LSPParser.
+func NewLSPParser() *LSPParser {
+	return &LSPParser{}
+}
+
+// lspDiagnostics is PublishDiagnosticsParams or the output of pyright
+// --outputjson.
+type lspDiagnostics struct {
+	URI                string            `json:"uri"`
+	Diagnostics        []json.RawMessage `json:"diagnostics"`
+	GeneralDiagnostics []json.RawMessage `json:"generalDiagnostics"`
+}
+
+// lspDiagnostic is Diagnostic with non-standard fields of some tools.
+type lspDiagnostic struct {
+	protocol.Diagnostic
+	// Severity is a number or a string (e.g. "error" of pyright).
+	Severity json.RawMessage `json:"severity"`
+	URI      string          `json:"uri"`
+	File     string          `json:"file"`
+	// Rule is the code of pyright.
+	Rule string `json:"rule"`
+}
+
+// Parse parses JSON of LSP diagnostics.
+func (p *LSPParser) Parse(r io.Reader) ([]*rdf.Diagnostic, error) {
+	lp := &lspParser{sources: make(map[string][]string)}
+	dec := json.NewDecoder(r)
+	for {
+		var v json.RawMessage
+		if err := dec.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse LSP diagnostics: %w", err)
+		}
+		if err := lp.parseValue(v, ""); err != nil {
+			return nil, err
+		}
+	}
+	return lp.diagnostics, nil
+}
+
+type lspParser struct {
+	diagnostics []*rdf.Diagnostic
+	// lines of source files to convert characters in UTF-16 to columns in
+	// bytes.
+	sources map[string][]string
+}
+
+// parseValue parses a JSON value. path is the path of diagnostics without
+// paths.
+func (lp *lspParser) parseValue(v json.RawMessage, path string) error {
+	v = bytes.TrimSpace(v)
+	if len(v) > 0 && v[0] == '[' {
+		var vs []json.RawMessage
+		if err := json.Unmarshal(v, &vs); err != nil {
+			return fmt.Errorf("failed to parse LSP diagnostics: %w", err)
+		}
+		for _, e := range vs {
+			if err := lp.parseValue(e, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(v, &fields); err != nil {
+		return fmt.Errorf("failed to parse LSP diagnostics: %w", err)
+	}
+	_, hasDiagnostics := fields["diagnostics"]
+	_, hasGeneralDiagnostics := fields["generalDiagnostics"]
+	if hasDiagnostics || hasGeneralDiagnostics {
+		var ds lspDiagnostics
+		if err := json.Unmarshal(v, &ds); err != nil {
+			return fmt.Errorf("failed to parse LSP diagnostics: %w", err)
+		}
+		if ds.URI != "" {
+			path = uriToPath(ds.URI)
+		}
+		for _, d := range append(ds.Diagnostics, ds.GeneralDiagnostics...) {
+			if err := lp.parseValue(d, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var d lspDiagnostic
+	if err := json.Unmarshal(v, &d); err != nil {
+		return fmt.Errorf("failed to parse LSP diagnostic: %w", err)
+	}
+	if d.URI != "" {
+		path = uriToPath(d.URI)
+	} else if d.File != "" {
+		path = d.File
+	}
+	lp.diagnostics = append(lp.diagnostics, lp.toDiagnostic(&d, path, string(v)))
+	return nil
+}
+
+func (lp *lspParser) toDiagnostic(d *lspDiagnostic, path, original string) *rdf.Diagnostic {
+	rd := &rdf.Diagnostic{
+		Message: d.Message,
+		Location: &rdf.Location{
+			Path: path,
+			Range: &rdf.Range{
+				Start: lp.position(path, d.Range.Start),
+				End:   lp.position(path, d.Range.End),
+			},
+		},
+		Severity:       lspSeverity(d.Severity),
+		OriginalOutput: original,
+	}
+	if d.Source != "" {
+		rd.Source = &rdf.Source{Name: d.Source}
+	}
+	code := d.Rule
+	if len(d.Code) > 0 {
+		var s string
+		if err := json.Unmarshal(d.Code, &s); err == nil {
+			code = s
+		} else {
+			// A number.
+			code = string(d.Code)
+		}
+	}
+	if code != "" {
+		rd.Code = &rdf.Code{Value: code}
+		if d.CodeDescription != nil {
+			rd.Code.Url = d.CodeDescription.Href
+		}
+	}
+	for _, info := range d.RelatedInformation {
+		loc := info.Location
+		rd.Message += fmt.Sprintf("\n%s:%d:%d: %s", uriToPath(loc.URI),
+			loc.Range.Start.Line+1, loc.Range.Start.Character+1, info.Message)
+	}
+	return rd
+}
+
+// position converts a position of LSP (0-based line and character in UTF-16)
+// to rdf (1-based line and column in bytes). The character is counted as
+// bytes if the file cannot be read.
+func (lp *lspParser) position(path string, p protocol.Position) *rdf.Position {
+	return &rdf.Position{
+		Line:   int32(p.Line + 1),
+		Column: int32(byteColumn(lp.line(path, p.Line+1), p.Character)),
+	}
+}
+
+func (lp *lspParser) line(path string, lnum int) string {
+	lines, ok := lp.sources[path]
+	if !ok && path != "" {
+		if b, err := ioutil.ReadFile(path); err == nil {
+			lines = strings.Split(string(b), "\n")
+		}
+		lp.sources[path] = lines
+	}
+	if lnum < 1 || lnum > len(lines) {
+		return ""
+	}
+	return lines[lnum-1]
+}
+
+// byteColumn converts a 0-based character in UTF-16 code units of the line
+// to a 1-based column in bytes. Characters after the end of the line are
+// counted as one byte per character.
+func byteColumn(line string, character int) int {
+	units := 0
+	for i, r := range line {
+		if units >= character {
+			return i + 1
+		}
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return len(line) + character - units + 1
+}
+
+func lspSeverity(v json.RawMessage) rdf.Severity {
+	var n int
+	if err := json.Unmarshal(v, &n); err == nil {
+		switch protocol.DiagnosticSeverity(n) {
+		case protocol.SeverityError:
+			return rdf.Severity_ERROR
+		case protocol.SeverityWarning:
+			return rdf.Severity_WARNING
+		case protocol.SeverityInformation, protocol.SeverityHint:
+			return rdf.Severity_INFO
+		}
+		return rdf.Severity_UNKNOWN_SEVERITY
+	}
+	var s string
+	if err := json.Unmarshal(v, &s); err != nil {
+		return rdf.Severity_UNKNOWN_SEVERITY
+	}
+	switch strings.ToLower(s) {
+	case "information", "hint":
+		return rdf.Severity_INFO
+	}
+	return severity(s)
+}
+
+// uriToPath returns the path of the file URI or uri as is if it's not a file
+// URI.
+func uriToPath(uri string) string {
+	if p := protocol.URIToPath(uri); p != "" {
+		return p
+	}
+	return uri
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func ExampleLSPParser() {
+	const sample = `{
+  "uri": "file:///src/main.go",
+  "diagnostics": [
+    {
+      "range": {"start": {"line": 12, "character": 1}, "end": {"line": 12, "character": 5}},
+      "severity": 2,
+      "code": "SA4006",
+      "codeDescription": {"href": "https://staticcheck.io/docs/checks#SA4006"},
+      "source": "staticcheck",
+      "message": "this value of x is never used",
+      "relatedInformation": [
+        {"location": {"uri": "file:///src/main.go", "range": {"start": {"line": 10, "character": 1}, "end": {"line": 10, "character": 2}}}, "message": "x is defined here"}
+      ]
+    }
+  ]
+}
+[{"file": "/src/a.py", "severity": "information", "rule": "reportMissingImports", "message": "Import could not be resolved", "range": {"start": {"line": 0, "character": 7}, "end": {"line": 0, "character": 10}}}]`
+	p := NewLSPParser()
+	diagnostics, err := p.Parse(strings.NewReader(sample))
+	if err != nil {
+		panic(err)
+	}
+	for _, d := range diagnostics {
+		d.OriginalOutput = ""
+		rdjson, _ := protojson.MarshalOptions{Indent: "  "}.Marshal(d)
+		var out bytes.Buffer
+		json.Indent(&out, rdjson, "", "  ")
+		fmt.Println(out.String())
+	}
+	// Output:
+	// {
+	//   "message": "this value of x is never used\n/src/main.go:11:2: x is defined here",
+	//   "location": {
+	//     "path": "/src/main.go",
+	//     "range": {
+	//       "start": {
+	//         "line": 13,
+	//         "column": 2
+	//       },
+	//       "end": {
+	//         "line": 13,
+	//         "column": 6
+	//       }
+	//     }
+	//   },
+	//   "severity": "WARNING",
+	//   "source": {
+	//     "name": "staticcheck"
+	//   },
+	//   "code": {
+	//     "value": "SA4006",
+	//     "url": "https://staticcheck.io/docs/checks#SA4006"
+	//   }
+	// }
+	// {
+	//   "message": "Import could not be resolved",
+	//   "location": {
+	//     "path": "/src/a.py",
+	//     "range": {
+	//       "start": {
+	//         "line": 1,
+	//         "column": 8
+	//       },
+	//       "end": {
+	//         "line": 1,
+	//         "column": 11
+	//       }
+	//     }
+	//   },
+	//   "severity": "INFO",
+	//   "code": {
+	//     "value": "reportMissingImports"
+	//   }
+	// }
+}
+
+func TestByteColumn(t *testing.T) {
+	tests := []struct {
+		line      string
+		character int
+		want      int
+	}{
+		{line: "abc", character: 0, want: 1},
+		{line: "abc", character: 2, want: 3},
+		// 'a𐐀b': 𐐀 is 2 code units in UTF-16 and 4 bytes in UTF-8.
+		{line: "a𐐀b", character: 1, want: 2},
+		{line: "a𐐀b", character: 3, want: 6},
+		{line: "héllo", character: 2, want: 4},
+		{line: "abc", character: 5, want: 6},
+	}
+	for _, tt := range tests {
+		if got := byteColumn(tt.line, tt.character); got != tt.want {
+			t.Errorf("byteColumn(%q, %d) = %d, want %d", tt.line, tt.character, got, tt.want)
+		}
+	}
+}
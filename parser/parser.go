@@ -41,6 +41,8 @@ func New(opt *Option) (Parser, error) {
 		return NewRDJSONParser(), nil
 	case "diff":
 		return NewDiffParser(opt.DiffStrip), nil
+	case "lsp":
+		return NewLSPParser(), nil
 	}
 
 	// use defined errorformat
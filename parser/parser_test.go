@@ -23,6 +23,12 @@ func TestNewParser(t *testing.T) {
 			},
 			typ: &RDJSONLParser{},
 		},
+		{
+			in: &Option{
+				FormatName: "lsp",
+			},
+			typ: &LSPParser{},
+		},
 		{
 			in: &Option{
 				FormatName: "golint",
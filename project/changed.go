@@ -0,0 +1,79 @@
+package project
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ChangedRunners returns names of runners which are triggered by changes of
+// files. Paths of files are slash-separated and relative to the project root.
+// Runners without when_changed globs are triggered by any change.
+func (c *Config) ChangedRunners(files []string) map[string]bool {
+	runners := make(map[string]bool)
+	for key, runner := range c.Runner {
+		if runner.matchChanged(files) {
+			runners[getRunnerName(key, runner)] = true
+		}
+	}
+	return runners
+}
+
+func (r *Runner) matchChanged(files []string) bool {
+	if len(files) == 0 {
+		return false
+	}
+	if len(r.WhenChanged) == 0 {
+		return true
+	}
+	for _, f := range files {
+		for _, glob := range r.WhenChanged {
+			if matchGlob(glob, f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchGlob reports whether the slash-separated path name matches glob. The
+// syntax of glob is the one of path.Match, and "**" matches zero or more
+// directories. A glob without "/" matches the base name at any depth like
+// .gitignore. (e.g. `*.go` matches `a/b.go`)
+func matchGlob(glob, name string) bool {
+	glob = strings.TrimPrefix(glob, "./")
+	if !strings.Contains(glob, "/") {
+		glob = "**/" + glob
+	}
+	return matchSegments(strings.Split(glob, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(globs, names []string) bool {
+	for len(globs) > 0 {
+		if globs[0] == "**" {
+			for i := 0; i <= len(names); i++ {
+				if matchSegments(globs[1:], names[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(names) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(globs[0], names[0]); !ok {
+			return false
+		}
+		globs, names = globs[1:], names[1:]
+	}
+	return len(names) == 0
+}
+
+func validateGlob(glob string) error {
+	for _, seg := range strings.Split(glob, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", glob, err)
+		}
+	}
+	return nil
+}
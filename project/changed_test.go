@@ -0,0 +1,74 @@
+package project
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConfig_ChangedRunners(t *testing.T) {
+	conf, err := Parse([]byte(`
+runner:
+  golint:
+    cmd: golint ./...
+    when_changed: ["*.go", go.mod]
+  eslint:
+    cmd: eslint .
+    when_changed: ["web/**/*.js", "web/package.json"]
+  named:
+    cmd: echo
+    name: misspell
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		files []string
+		want  map[string]bool
+	}{
+		{files: nil, want: map[string]bool{}},
+		{files: []string{"README.md"}, want: map[string]bool{"misspell": true}},
+		{files: []string{"a/b/c.go"}, want: map[string]bool{"golint": true, "misspell": true}},
+		{files: []string{"go.mod"}, want: map[string]bool{"golint": true, "misspell": true}},
+		{files: []string{"a/go.mod.orig"}, want: map[string]bool{"misspell": true}},
+		{files: []string{"web/index.js"}, want: map[string]bool{"eslint": true, "misspell": true}},
+		{files: []string{"web/src/app/index.js", "x.go"}, want: map[string]bool{"eslint": true, "golint": true, "misspell": true}},
+		{files: []string{"src/web/index.js"}, want: map[string]bool{"misspell": true}},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, conf.ChangedRunners(tt.files)); diff != "" {
+			t.Errorf("ChangedRunners(%q) has diff:\n%s", tt.files, diff)
+		}
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		glob string
+		name string
+		want bool
+	}{
+		{glob: "*.go", name: "main.go", want: true},
+		{glob: "*.go", name: "cmd/main.go", want: true},
+		{glob: "./*.go", name: "cmd/main.go", want: true},
+		{glob: "cmd/*.go", name: "cmd/main.go", want: true},
+		{glob: "cmd/*.go", name: "cmd/sub/main.go", want: false},
+		{glob: "cmd/**", name: "cmd/sub/main.go", want: true},
+		{glob: "cmd/**/*.go", name: "cmd/main.go", want: true},
+		{glob: "**/testdata/*", name: "a/testdata/x.txt", want: true},
+		{glob: "**/testdata/*", name: "a/testdata", want: false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.glob, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.glob, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParse_invalidWhenChanged(t *testing.T) {
+	_, err := Parse([]byte("runner:\n  golint:\n    cmd: golint\n    when_changed: ['[a-']\n"))
+	if want := "runner golint: when_changed: invalid glob"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Parse() got error %v, want %q", err, want)
+	}
+}
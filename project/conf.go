@@ -2,11 +2,23 @@
 // config.
 package project
 
-import "gopkg.in/yaml.v2"
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
 
 // Config represents reviewdog config.
 type Config struct {
 	Runner map[string]*Runner
+	// Optional. Rules to rewrite diagnostics before reporting, which are
+	// applied in order.
+	Rewrite []*RewriteRule
+	// Optional. Deduplicate identical diagnostics reported by multiple runners
+	// if it's set.
+	Dedupe *Dedupe
+	// Optional. Templates of comment bodies per reporter and tool.
+	Templates []*Template
 }
 
 // Runner represents config for a runner.
@@ -21,6 +33,23 @@ type Runner struct {
 	Errorformat []string
 	// Report Level for this runner. ("info", "warning", "error")
 	Level string
+	// Optional. Globs of files which trigger the runner in watch mode. (e.g.
+	// `**/*.go`) The runner is triggered by any change if it's empty.
+	WhenChanged []string `yaml:"when_changed"`
+}
+
+// Dedupe represents config to deduplicate identical diagnostics reported by
+// multiple runners. Diagnostics at the same line of the same file are
+// identical if their normalized messages are the same or their codes are the
+// same or aliases.
+type Dedupe struct {
+	// Runner names in priority order. The diagnostic of the runner which comes
+	// first is kept and the others are merged into it. Runners which are not
+	// listed come after them in name order.
+	Priority []string
+	// Groups of diagnostic codes which represent the same rule across tools.
+	// (e.g. [["SA4006", "ineffassign"]])
+	Aliases [][]string
 }
 
 // Parse parses reviewdog config in yaml format.
@@ -34,6 +63,27 @@ func Parse(yml []byte) (*Config, error) {
 		if runner.Name == "" {
 			runner.Name = name
 		}
+		for _, glob := range runner.WhenChanged {
+			if err := validateGlob(glob); err != nil {
+				return nil, fmt.Errorf("runner %s: when_changed: %w", name, err)
+			}
+		}
+	}
+	for i, rule := range out.Rewrite {
+		if rule == nil {
+			return nil, fmt.Errorf("rewrite[%d]: empty rule", i)
+		}
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("rewrite[%d]: %w", i, err)
+		}
+	}
+	for i, t := range out.Templates {
+		if t == nil {
+			return nil, fmt.Errorf("templates[%d]: empty template", i)
+		}
+		if err := t.compile(); err != nil {
+			return nil, fmt.Errorf("templates[%d]: %w", i, err)
+		}
 	}
 	return out, nil
 }
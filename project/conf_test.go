@@ -20,11 +20,17 @@ runner:
     cmd: go tool vet -all -shadowstrict .
     format: govet
     level: warning
+    when_changed: ["*.go"]
   namekey:
     cmd: echo 'name'
     name: nameoverwritten
     format: checkstyle
     level: error
+
+dedupe:
+  priority: [govet, golint]
+  aliases:
+    - [SA4006, ineffassign]
 `
 
 	want := &Config{
@@ -36,10 +42,11 @@ runner:
 				Level:       "info",
 			},
 			"govet": {
-				Cmd:    "go tool vet -all -shadowstrict .",
-				Format: "govet",
-				Name:   "govet",
-				Level:  "warning",
+				Cmd:         "go tool vet -all -shadowstrict .",
+				Format:      "govet",
+				Name:        "govet",
+				Level:       "warning",
+				WhenChanged: []string{"*.go"},
 			},
 			"namekey": {
 				Cmd:    "echo 'name'",
@@ -48,6 +55,10 @@ runner:
 				Level:  "error",
 			},
 		},
+		Dedupe: &Dedupe{
+			Priority: []string{"govet", "golint"},
+			Aliases:  [][]string{{"SA4006", "ineffassign"}},
+		},
 	}
 
 	got, err := Parse([]byte(yml))
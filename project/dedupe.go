@@ -0,0 +1,151 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+// dedupeResults returns results where identical diagnostics reported by
+// multiple runners are merged into the diagnostic of the runner with the
+// highest priority. The other runners are listed in Result.AlsoReportedBy of
+// the merged diagnostic and its message is kept as is. Diagnostics of the same runner are not merged.
+func dedupeResults(results *reviewdog.ResultMap, conf *Dedupe) *reviewdog.ResultMap {
+	aliases := make(map[string]string) // code to the first code of its group.
+	for _, group := range conf.Aliases {
+		for _, code := range group {
+			aliases[code] = group[0]
+		}
+	}
+
+	type merged struct {
+		index   int // index in kept.
+		runner  string
+		d       *rdf.Diagnostic
+		sources []string
+	}
+	var kept []*merged
+	byKey := make(map[string]*merged)
+	deduped := new(reviewdog.ResultMap)
+	dedupedResults := make(map[string]*reviewdog.Result)
+	for _, name := range runnersByPriority(results, conf.Priority) {
+		result, _ := results.Load(name)
+		diagnostics := make([]*rdf.Diagnostic, 0, len(result.Diagnostics))
+		for _, d := range result.Diagnostics {
+			keys := dedupeKeys(d, aliases)
+			// Merge into the diagnostic with the highest priority.
+			var m *merged
+			for _, k := range keys {
+				if found, ok := byKey[k]; ok && found.runner != name && (m == nil || found.index < m.index) {
+					m = found
+				}
+			}
+			if m != nil {
+				if !containsString(m.sources, name) {
+					m.sources = append(m.sources, name)
+				}
+			} else {
+				sources := append([]string(nil), result.AlsoReportedBy[d]...)
+				d = proto.Clone(d).(*rdf.Diagnostic)
+				m = &merged{index: len(kept), runner: name, d: d, sources: sources}
+				kept = append(kept, m)
+				diagnostics = append(diagnostics, d)
+			}
+			// Keys of merged diagnostics match as well (e.g. a code which the
+			// kept diagnostic doesn't have).
+			for _, k := range keys {
+				if _, ok := byKey[k]; !ok {
+					byKey[k] = m
+				}
+			}
+		}
+		r := *result
+		r.Diagnostics = diagnostics
+		r.AlsoReportedBy = nil
+		deduped.Store(name, &r)
+		dedupedResults[name] = &r
+	}
+	for _, m := range kept {
+		if len(m.sources) == 0 {
+			continue
+		}
+		r := dedupedResults[m.runner]
+		if r.AlsoReportedBy == nil {
+			r.AlsoReportedBy = make(map[*rdf.Diagnostic][]string)
+		}
+		r.AlsoReportedBy[m.d] = m.sources
+	}
+	return deduped
+}
+
+// runnersByPriority returns runner names of results in the priority order.
+// Runners which are not in priority come after them in name order.
+func runnersByPriority(results *reviewdog.ResultMap, priority []string) []string {
+	rank := make(map[string]int, len(priority))
+	for i, name := range priority {
+		if _, ok := rank[name]; !ok {
+			rank[name] = i
+		}
+	}
+	var names []string
+	results.Range(func(name string, _ *reviewdog.Result) {
+		names = append(names, name)
+	})
+	sort.Slice(names, func(i, j int) bool {
+		ri, iok := rank[names[i]]
+		rj, jok := rank[names[j]]
+		if iok != jok {
+			return iok
+		}
+		if iok && ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// dedupeKeys returns keys of the diagnostic. Diagnostics which share any key
+// are identical.
+func dedupeKeys(d *rdf.Diagnostic, aliases map[string]string) []string {
+	loc := d.GetLocation()
+	prefix := fmt.Sprintf("%s:%d:", filepath.ToSlash(filepath.Clean(loc.GetPath())), loc.GetRange().GetStart().GetLine())
+	var keys []string
+	if msg := normalizeMessage(d.GetMessage()); msg != "" {
+		keys = append(keys, prefix+"message:"+msg)
+	}
+	if code := d.GetCode().GetValue(); code != "" {
+		if alias, ok := aliases[code]; ok {
+			code = alias
+		}
+		keys = append(keys, prefix+"code:"+code)
+	}
+	return keys
+}
+
+// normalizeMessage normalizes the diagnostic message to compare messages of
+// different tools. It ignores case, spaces, a trailing period and a trailing
+// parenthesized linter name or code (e.g. "(ineffassign)", "(SA4006)").
+func normalizeMessage(msg string) string {
+	msg = strings.Join(strings.Fields(msg), " ")
+	if i := strings.LastIndex(msg, " ("); i >= 0 && strings.HasSuffix(msg, ")") &&
+		!strings.ContainsAny(msg[i+2:len(msg)-1], " ()") {
+		msg = msg[:i]
+	}
+	return strings.ToLower(strings.TrimSuffix(msg, "."))
+}
+
+func containsString(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package project
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+func TestDedupeResults(t *testing.T) {
+	newDiagnostic := func(path string, line int32, msg, code string) *rdf.Diagnostic {
+		d := &rdf.Diagnostic{
+			Location: &rdf.Location{Path: path, Range: &rdf.Range{Start: &rdf.Position{Line: line}}},
+			Message:  msg,
+		}
+		if code != "" {
+			d.Code = &rdf.Code{Value: code}
+		}
+		return d
+	}
+	results := new(reviewdog.ResultMap)
+	results.Store("golangci-lint", &reviewdog.Result{Name: "golangci-lint", Diagnostics: []*rdf.Diagnostic{
+		newDiagnostic("a.go", 1, "ineffectual assignment to x (ineffassign)", "ineffassign"),
+		newDiagnostic("a.go", 2, "Error return value is not checked (errcheck)", ""),
+		newDiagnostic("a.go", 2, "error return value is not checked", ""),
+	}})
+	results.Store("staticcheck", &reviewdog.Result{Name: "staticcheck", Diagnostics: []*rdf.Diagnostic{
+		newDiagnostic("a.go", 1, "this value of x is never used", "SA4006"),
+		newDiagnostic("./a.go", 2, "error return value is not checked.", ""),
+		newDiagnostic("a.go", 3, "error return value is not checked", ""),
+	}})
+	results.Store("govet", &reviewdog.Result{Name: "govet", Diagnostics: []*rdf.Diagnostic{
+		newDiagnostic("a.go", 1, "this value of x is never used", ""),
+		newDiagnostic("b.go", 1, "unreachable code", ""),
+	}})
+
+	conf := &Dedupe{
+		Priority: []string{"golangci-lint", "staticcheck"},
+		Aliases:  [][]string{{"SA4006", "ineffassign"}},
+	}
+	toStrings := func(results *reviewdog.ResultMap) map[string][]string {
+		got := make(map[string][]string)
+		results.Range(func(name string, r *reviewdog.Result) {
+			got[name] = []string{}
+			for _, d := range r.Diagnostics {
+				s := d.GetLocation().GetPath() + ": " + d.GetMessage()
+				if sources := r.AlsoReportedBy[d]; len(sources) > 0 {
+					s += " " + fmt.Sprint(sources)
+				}
+				got[name] = append(got[name], s)
+			}
+		})
+		return got
+	}
+	deduped := dedupeResults(results, conf)
+	got := toStrings(deduped)
+	want := map[string][]string{
+		"golangci-lint": {
+			"a.go: ineffectual assignment to x (ineffassign) [staticcheck govet]",
+			"a.go: Error return value is not checked (errcheck) [staticcheck]",
+			// Diagnostics of the same runner are not merged.
+			"a.go: error return value is not checked",
+		},
+		"govet": {"b.go: unreachable code"},
+		"staticcheck": {
+			"a.go: error return value is not checked",
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("dedupeResults() has diff:\n%s", diff)
+	}
+	// Original results are not modified.
+	r, _ := results.Load("golangci-lint")
+	if msg := r.Diagnostics[0].GetMessage(); msg != "ineffectual assignment to x (ineffassign)" {
+		t.Errorf("original message is modified: %q", msg)
+	}
+	// Messages of merged diagnostics are kept as is, so deduping them again
+	// gives the same result.
+	if diff := cmp.Diff(want, toStrings(dedupeResults(deduped, conf))); diff != "" {
+		t.Errorf("dedupeResults() of deduped results has diff:\n%s", diff)
+	}
+}
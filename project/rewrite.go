@@ -0,0 +1,146 @@
+package project
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+// RewriteRule represents a rule to rewrite diagnostics before reporting. It
+// applies to diagnostics which match all of Runners, Code and Message.
+type RewriteRule struct {
+	// Optional. Runner names which the rule applies to. It applies to all
+	// runners if empty.
+	Runners []string
+	// Optional. Regular expression which matches whole codes.
+	Code string
+	// Optional. Regular expression which matches messages. If it has a named
+	// group "code" (e.g. `\((?P<code>SA\d+)\)$`), the code of diagnostics
+	// without code is set to the submatch.
+	Message string
+
+	// Optional. Replacement of matches of Message (e.g. "$1"). Messages are not
+	// replaced if it's not set.
+	Replace *string
+	// Optional. Template of the code URL (e.g.
+	// "https://staticcheck.io/docs/checks#{{.Code}}"). {{.Code}} and
+	// {{.Runner}} are available. It doesn't override URLs which tools report.
+	CodeURL string `yaml:"code_url"`
+	// Optional. Severity to override ("error", "warning", "info").
+	Severity string
+	// Optional. Text to prepend to messages as is (e.g. team guidance).
+	Prepend string
+
+	code     *regexp.Regexp
+	message  *regexp.Regexp
+	codeURL  *template.Template
+	severity rdf.Severity
+}
+
+// compile compiles regular expressions and the template of the rule.
+func (r *RewriteRule) compile() error {
+	var err error
+	if r.Code != "" {
+		if r.code, err = regexp.Compile("^(?:" + r.Code + ")$"); err != nil {
+			return fmt.Errorf("invalid code: %w", err)
+		}
+	}
+	if r.Message != "" {
+		if r.message, err = regexp.Compile(r.Message); err != nil {
+			return fmt.Errorf("invalid message: %w", err)
+		}
+	}
+	if r.Replace != nil && r.message == nil {
+		return fmt.Errorf("replace requires message")
+	}
+	if r.CodeURL != "" {
+		if r.codeURL, err = template.New("code_url").Option("missingkey=error").Parse(r.CodeURL); err != nil {
+			return fmt.Errorf("invalid code_url: %w", err)
+		}
+	}
+	switch strings.ToLower(r.Severity) {
+	case "":
+	case "error":
+		r.severity = rdf.Severity_ERROR
+	case "warning":
+		r.severity = rdf.Severity_WARNING
+	case "info":
+		r.severity = rdf.Severity_INFO
+	default:
+		return fmt.Errorf("invalid severity: %q", r.Severity)
+	}
+	return nil
+}
+
+func (r *RewriteRule) match(runner string, d *rdf.Diagnostic) bool {
+	if len(r.Runners) > 0 && !containsString(r.Runners, runner) {
+		return false
+	}
+	if r.code != nil && !r.code.MatchString(d.GetCode().GetValue()) {
+		return false
+	}
+	return r.message == nil || r.message.MatchString(d.GetMessage())
+}
+
+// apply rewrites the diagnostic of the runner if it matches the rule.
+func (r *RewriteRule) apply(runner string, d *rdf.Diagnostic) error {
+	if !r.match(runner, d) {
+		return nil
+	}
+	if r.message != nil && d.GetCode().GetValue() == "" {
+		if i := r.message.SubexpIndex("code"); i > 0 {
+			if m := r.message.FindStringSubmatch(d.GetMessage()); m != nil && m[i] != "" {
+				d.Code = &rdf.Code{Value: m[i]}
+			}
+		}
+	}
+	if r.Replace != nil {
+		d.Message = r.message.ReplaceAllString(d.GetMessage(), *r.Replace)
+	}
+	if r.codeURL != nil && d.GetCode().GetValue() != "" && d.GetCode().GetUrl() == "" {
+		var sb strings.Builder
+		data := struct{ Code, Runner string }{Code: d.GetCode().GetValue(), Runner: runner}
+		if err := r.codeURL.Execute(&sb, data); err != nil {
+			return fmt.Errorf("failed to build code_url: %w", err)
+		}
+		d.Code.Url = sb.String()
+	}
+	if r.severity != rdf.Severity_UNKNOWN_SEVERITY {
+		d.Severity = r.severity
+	}
+	if r.Prepend != "" {
+		d.Message = r.Prepend + d.GetMessage()
+	}
+	return nil
+}
+
+// rewriteResults rewrites diagnostics of results in place with rules in
+// order.
+func rewriteResults(results *reviewdog.ResultMap, rules []*RewriteRule) error {
+	var err error
+	results.Range(func(name string, result *reviewdog.Result) {
+		if e := Rewrite(name, result.Diagnostics, rules); e != nil && err == nil {
+			err = e
+		}
+	})
+	return err
+}
+
+// Rewrite rewrites diagnostics of the runner in place with rules in order.
+// It's for diagnostics which are not reported by runners of the config (e.g.
+// diagnostics given by stdin).
+func Rewrite(runner string, diagnostics []*rdf.Diagnostic, rules []*RewriteRule) error {
+	var err error
+	for _, d := range diagnostics {
+		for _, rule := range rules {
+			if e := rule.apply(runner, d); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package project
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+func TestRewriteResults(t *testing.T) {
+	conf, err := Parse([]byte(`
+rewrite:
+  - runners: [staticcheck]
+    message: ' \((?P<code>SA\d+)\)$'
+    replace: ""
+  - code: SA.*
+    code_url: "https://staticcheck.io/docs/checks#{{.Code}}"
+  - code: SA4006
+    severity: info
+  - runners: [golint]
+    message: 'should have comment'
+    prepend: "**[Go style]** "
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := new(reviewdog.ResultMap)
+	results.Store("staticcheck", &reviewdog.Result{Name: "staticcheck", Diagnostics: []*rdf.Diagnostic{
+		{Message: "this value of x is never used (SA4006)", Severity: rdf.Severity_ERROR},
+		{Message: "should use time.Since (S1012)"},
+		{Message: "tool reported code", Code: &rdf.Code{Value: "SA1000", Url: "https://example.com"}},
+	}})
+	results.Store("golint", &reviewdog.Result{Name: "golint", Diagnostics: []*rdf.Diagnostic{
+		{Message: "exported function F should have comment or be unexported"},
+		{Message: "this value of x is never used (SA4006)"},
+	}})
+	if err := rewriteResults(results, conf.Rewrite); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]*rdf.Diagnostic{
+		"staticcheck": {
+			{
+				Message:  "this value of x is never used",
+				Code:     &rdf.Code{Value: "SA4006", Url: "https://staticcheck.io/docs/checks#SA4006"},
+				Severity: rdf.Severity_INFO,
+			},
+			{Message: "should use time.Since (S1012)"},
+			{Message: "tool reported code", Code: &rdf.Code{Value: "SA1000", Url: "https://example.com"}},
+		},
+		"golint": {
+			{Message: "**[Go style]** exported function F should have comment or be unexported"},
+			{Message: "this value of x is never used (SA4006)"},
+		},
+	}
+	got := make(map[string][]*rdf.Diagnostic)
+	results.Range(func(name string, r *reviewdog.Result) {
+		got[name] = r.Diagnostics
+	})
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("rewriteResults() has diff:\n%s", diff)
+	}
+}
+
+func TestParse_invalidRewriteRule(t *testing.T) {
+	tests := []struct {
+		yml  string
+		want string
+	}{
+		{yml: "rewrite: [{code: '('}]", want: "rewrite[0]: invalid code"},
+		{yml: "rewrite: [{message: 'a'}, {message: '('}]", want: "rewrite[1]: invalid message"},
+		{yml: "rewrite: [{replace: 'a'}]", want: "replace requires message"},
+		{yml: "rewrite: [{code_url: '{{.Code'}]", want: "invalid code_url"},
+		{yml: "rewrite: [{severity: critical}]", want: "invalid severity"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.yml)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) = %v, want error %q", tt.yml, err, tt.want)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package project
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -13,12 +12,14 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/reviewdog/reviewdog"
-	"github.com/reviewdog/reviewdog/diff"
 	"github.com/reviewdog/reviewdog/filter"
 	"github.com/reviewdog/reviewdog/parser"
+	"github.com/reviewdog/reviewdog/proto/rdf"
 )
 
 // RunAndParse runs commands and parse results. Returns map of tool name to check results.
+// Diagnostics are rewritten by conf.Rewrite rules, and then identical
+// diagnostics reported by multiple runners are merged if conf.Dedupe is set.
 func RunAndParse(ctx context.Context, conf *Config, runners map[string]bool, defaultLevel string, teeMode bool) (*reviewdog.ResultMap, error) {
 	var results reviewdog.ResultMap
 	// environment variables for each commands
@@ -87,11 +88,17 @@ func RunAndParse(ctx context.Context, conf *Config, runners map[string]bool, def
 	if err := checkUnknownRunner(runners, usedRunners); err != nil {
 		return nil, err
 	}
+	if err := rewriteResults(&results, conf.Rewrite); err != nil {
+		return nil, err
+	}
+	if conf.Dedupe != nil {
+		return dedupeResults(&results, conf.Dedupe), nil
+	}
 	return &results, nil
 }
 
 // Run runs reviewdog tasks based on Config.
-func Run(ctx context.Context, conf *Config, runners map[string]bool, c reviewdog.CommentService, d reviewdog.DiffService, teeMode bool, filterMode filter.Mode, failOnError bool) error {
+func Run(ctx context.Context, conf *Config, runners map[string]bool, c reviewdog.CommentService, d reviewdog.DiffService, teeMode bool, filterMode filter.Mode, failOnError bool, filterOpts ...filter.Option) error {
 	results, err := RunAndParse(ctx, conf, runners, "", teeMode) // Level is not used.
 	if err != nil {
 		return err
@@ -100,25 +107,60 @@ func Run(ctx context.Context, conf *Config, runners map[string]bool, c reviewdog
 		return nil
 	}
 
-	b, err := d.Diff(ctx)
+	filediffs, err := reviewdog.FileDiffs(ctx, d)
 	if err != nil {
 		return err
 	}
-	filediffs, err := diff.ParseMultiFile(bytes.NewReader(b))
-	if err != nil {
-		return err
+	// Runners only post comments and the comment service is flushed once after
+	// all runners finish, so that it handles comments of the run as a whole
+	// regardless of the order of runners finishing.
+	cs := c
+	bulk, isBulk := c.(reviewdog.BulkCommentService)
+	if isBulk {
+		cs = commentPoster{c}
 	}
 	var g errgroup.Group
 	results.Range(func(toolname string, result *reviewdog.Result) {
 		ds := result.Diagnostics
+		rcs := cs
+		if len(result.AlsoReportedBy) > 0 {
+			rcs = &alsoReportedBySetter{cs: cs, sources: result.AlsoReportedBy}
+		}
 		g.Go(func() error {
 			if err := result.CheckUnexpectedFailure(); err != nil {
 				return err
 			}
-			return reviewdog.RunFromResult(ctx, c, ds, filediffs, d.Strip(), toolname, filterMode, failOnError)
+			return reviewdog.RunFromResult(ctx, rcs, ds, filediffs, d.Strip(), toolname, filterMode, failOnError, filterOpts...)
 		})
 	})
-	return g.Wait()
+	err = g.Wait()
+	if isBulk {
+		if err := bulk.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// commentPoster hides Flush of a BulkCommentService.
+type commentPoster struct {
+	reviewdog.CommentService
+}
+
+// alsoReportedBySetter sets Comment.AlsoReportedBy from
+// Result.AlsoReportedBy.
+type alsoReportedBySetter struct {
+	cs      reviewdog.CommentService
+	sources map[*rdf.Diagnostic][]string
+}
+
+func (s *alsoReportedBySetter) Post(ctx context.Context, c *reviewdog.Comment) error {
+	if sources, ok := s.sources[c.Result.Diagnostic]; ok {
+		cc := *c
+		cc.AlsoReportedBy = sources
+		c = &cc
+	}
+	return s.cs.Post(ctx, c)
 }
 
 var secretEnvs = [...]string{
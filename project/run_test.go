@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/reviewdog/reviewdog"
 	"github.com/reviewdog/reviewdog/filter"
 )
@@ -34,6 +38,15 @@ func (f *fakeCommentService) Post(_ context.Context, c *reviewdog.Comment) error
 	return f.FakePost(c)
 }
 
+type fakeBulkCommentService struct {
+	fakeCommentService
+	FakeFlush func() error
+}
+
+func (f *fakeBulkCommentService) Flush(_ context.Context) error {
+	return f.FakeFlush()
+}
+
 func TestRun(t *testing.T) {
 	ctx := context.Background()
 
@@ -257,6 +270,92 @@ func TestRun(t *testing.T) {
 		}
 	})
 
+	t.Run("flush once", func(t *testing.T) {
+		ds := &fakeDiffService{
+			FakeDiff: func() ([]byte, error) {
+				return []byte(""), nil
+			},
+		}
+		var mu sync.Mutex
+		posted, flushed := 0, 0
+		cs := &fakeBulkCommentService{
+			fakeCommentService: fakeCommentService{
+				FakePost: func(c *reviewdog.Comment) error {
+					mu.Lock()
+					defer mu.Unlock()
+					posted++
+					return nil
+				},
+			},
+			FakeFlush: func() error {
+				mu.Lock()
+				defer mu.Unlock()
+				if posted != 2 {
+					t.Errorf("flushed after %d comments are posted, want 2", posted)
+				}
+				flushed++
+				return nil
+			},
+		}
+		conf := &Config{
+			Runner: map[string]*Runner{
+				"test1": {
+					Name:        "test1",
+					Cmd:         "echo 'a.go:1:1:test1'",
+					Errorformat: []string{`%f:%l:%c:%m`},
+				},
+				"test2": {
+					Name:        "test2",
+					Cmd:         "echo 'a.go:1:1:test2'",
+					Errorformat: []string{`%f:%l:%c:%m`},
+				},
+			},
+		}
+		if err := Run(ctx, conf, nil, cs, ds, false, filter.ModeNoFilter, false); err != nil {
+			t.Error(err)
+		}
+		if flushed != 1 {
+			t.Errorf("Flush called %d times, want 1 time", flushed)
+		}
+	})
+
+	t.Run("dedupe", func(t *testing.T) {
+		ds := &fakeDiffService{
+			FakeDiff: func() ([]byte, error) {
+				return []byte(""), nil
+			},
+		}
+		var got []string
+		cs := &fakeCommentService{
+			FakePost: func(c *reviewdog.Comment) error {
+				got = append(got, fmt.Sprintf("%s: %s %v", c.ToolName, c.Result.Diagnostic.GetMessage(), c.AlsoReportedBy))
+				return nil
+			},
+		}
+		conf := &Config{
+			Runner: map[string]*Runner{
+				"test1": {
+					Name:        "test1",
+					Cmd:         "echo 'a.go:1:1:msg'",
+					Errorformat: []string{`%f:%l:%c:%m`},
+				},
+				"test2": {
+					Name:        "test2",
+					Cmd:         "echo 'a.go:1:1:msg'",
+					Errorformat: []string{`%f:%l:%c:%m`},
+				},
+			},
+			Dedupe: &Dedupe{Priority: []string{"test2"}},
+		}
+		if err := Run(ctx, conf, nil, cs, ds, false, filter.ModeNoFilter, false); err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"test2: msg [test1]"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("posted comments have diff:\n%s", diff)
+		}
+	})
+
 	t.Run("unknown runners", func(t *testing.T) {
 		ds := &fakeDiffService{
 			FakeDiff: func() ([]byte, error) {
@@ -0,0 +1,62 @@
+package project
+
+import (
+	"errors"
+
+	"github.com/reviewdog/reviewdog/service/commentutil"
+)
+
+// Template represents a Go text/template of comment bodies for a reporter
+// and a tool. See commentutil.TemplateData for available data.
+type Template struct {
+	// Optional. Reporter name. (e.g. `github-pr-review`) Empty matches all
+	// reporters.
+	Reporter string
+	// Optional. Tool name. (e.g. `golint`) Empty matches all tools.
+	Tool string
+	// Template of comment bodies.
+	Body string
+}
+
+func (t *Template) compile() error {
+	if t.Body == "" {
+		return errors.New("body is required")
+	}
+	_, err := commentutil.ParseTemplate(t.Tool, t.Body)
+	return err
+}
+
+// CommentTemplate returns the template of comment bodies for the reporter. A
+// template for the tool takes precedence over one for all tools and a
+// template for the reporter takes precedence over one for all reporters. It
+// returns nil if there are no templates for the reporter.
+func (c *Config) CommentTemplate(reporter string) (*commentutil.Template, error) {
+	var def *Template
+	tools := make(map[string]*Template)
+	for _, t := range c.Templates {
+		if t.Reporter != "" && t.Reporter != reporter {
+			continue
+		}
+		if t.Tool == "" {
+			if def == nil || (def.Reporter == "" && t.Reporter != "") {
+				def = t
+			}
+			continue
+		}
+		if cur, ok := tools[t.Tool]; !ok || (cur.Reporter == "" && t.Reporter != "") {
+			tools[t.Tool] = t
+		}
+	}
+	if def == nil && len(tools) == 0 {
+		return nil, nil
+	}
+	defBody := ""
+	if def != nil {
+		defBody = def.Body
+	}
+	bodies := make(map[string]string, len(tools))
+	for tool, t := range tools {
+		bodies[tool] = t.Body
+	}
+	return commentutil.NewTemplate(defBody, bodies)
+}
@@ -0,0 +1,79 @@
+package project
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/filter"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+func TestConfig_CommentTemplate(t *testing.T) {
+	conf, err := Parse([]byte(`
+templates:
+  - body: "all: {{.Message}}"
+  - reporter: github-pr-review
+    body: "github: {{.Message}}"
+  - tool: golint
+    body: "golint: {{.Message}}"
+  - reporter: gitlab-mr-discussion
+    tool: golint
+    body: "gitlab golint: {{.Message}}"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		reporter string
+		tool     string
+		want     string
+	}{
+		{reporter: "github-pr-review", tool: "govet", want: "github: msg"},
+		{reporter: "github-pr-review", tool: "golint", want: "golint: msg"},
+		{reporter: "gitlab-mr-discussion", tool: "govet", want: "all: msg"},
+		{reporter: "gitlab-mr-discussion", tool: "golint", want: "gitlab golint: msg"},
+	}
+	for _, tt := range tests {
+		tmpl, err := conf.CommentTemplate(tt.reporter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := &reviewdog.Comment{
+			ToolName: tt.tool,
+			Result:   &filter.FilteredDiagnostic{Diagnostic: &rdf.Diagnostic{Message: "msg"}},
+		}
+		if got, _ := tmpl.Render(c, "default", ""); got != tt.want {
+			t.Errorf("[%s, %s] got %q, want %q", tt.reporter, tt.tool, got, tt.want)
+		}
+	}
+
+	conf, err = Parse([]byte(`
+templates:
+  - reporter: github-pr-review
+    body: "github: {{.Message}}"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl, err := conf.CommentTemplate("gitlab-mr-discussion"); err != nil || tmpl != nil {
+		t.Errorf("CommentTemplate() = %v, %v, want nil", tmpl, err)
+	}
+}
+
+func TestParse_invalidTemplate(t *testing.T) {
+	tests := []struct {
+		yml  string
+		want string
+	}{
+		{yml: "templates:\n  - tool: golint\n", want: "templates[0]: body is required"},
+		{yml: "templates:\n  - body: '{{.Message'\n", want: "templates[0]: invalid comment template"},
+		{yml: "templates:\n  -\n", want: "templates[0]: empty template"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.yml))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) got error %v, want %q", tt.yml, err, tt.want)
+		}
+	}
+}
@@ -19,6 +19,11 @@ type Result struct {
 	Level       string
 	Diagnostics []*rdf.Diagnostic
 
+	// Optional. Names of other runners which reported the same diagnostics,
+	// keyed by diagnostics in Diagnostics (e.g. merged by dedupe of the
+	// project config).
+	AlsoReportedBy map[*rdf.Diagnostic][]string
+
 	// Optional. Report an error of the command execution.
 	// Non-nil CmdErr doesn't mean failure and Diagnostics still may have
 	// results.
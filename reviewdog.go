@@ -1,7 +1,6 @@
 package reviewdog
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -23,23 +22,38 @@ type Reviewdog struct {
 	d           DiffService
 	filterMode  filter.Mode
 	failOnError bool
+	filterOpts  []filter.Option
 }
 
-// NewReviewdog returns a new Reviewdog.
-func NewReviewdog(toolname string, p parser.Parser, c CommentService, d DiffService, filterMode filter.Mode, failOnError bool) *Reviewdog {
-	return &Reviewdog{p: p, c: c, d: d, toolname: toolname, filterMode: filterMode, failOnError: failOnError}
+// NewReviewdog returns a new Reviewdog. filterOpts are passed to
+// filter.FilterCheck (e.g. filter.WithBlamer for filter.ModeBlame).
+func NewReviewdog(toolname string, p parser.Parser, c CommentService, d DiffService, filterMode filter.Mode, failOnError bool, filterOpts ...filter.Option) *Reviewdog {
+	return &Reviewdog{p: p, c: c, d: d, toolname: toolname, filterMode: filterMode, failOnError: failOnError, filterOpts: filterOpts}
 }
 
 // RunFromResult creates a new Reviewdog and runs it with check results.
 func RunFromResult(ctx context.Context, c CommentService, results []*rdf.Diagnostic,
-	filediffs []*diff.FileDiff, strip int, toolname string, filterMode filter.Mode, failOnError bool) error {
-	return (&Reviewdog{c: c, toolname: toolname, filterMode: filterMode, failOnError: failOnError}).runFromResult(ctx, results, filediffs, strip, failOnError)
+	filediffs []*diff.FileDiff, strip int, toolname string, filterMode filter.Mode, failOnError bool, filterOpts ...filter.Option) error {
+	return (&Reviewdog{c: c, toolname: toolname, filterMode: filterMode, failOnError: failOnError, filterOpts: filterOpts}).runFromResult(ctx, results, filediffs, strip, failOnError)
 }
 
 // Comment represents a reported result as a comment.
 type Comment struct {
 	Result   *filter.FilteredDiagnostic
 	ToolName string
+
+	// SummaryOnly is true if the comment should be reported only in the
+	// summary comment (e.g. infos routed by RouteBySeverity).
+	SummaryOnly bool
+
+	// Overflow is true if the comment exceeds limits of LimitComments.
+	// Reporters should not post it individually but list it in an overflow
+	// summary (e.g. a review body or a summary comment).
+	Overflow bool
+
+	// AlsoReportedBy is names of other tools which reported the same
+	// diagnostic. Reporters show them apart from the message.
+	AlsoReportedBy []string
 }
 
 // CommentService is an interface which posts Comment.
@@ -67,7 +81,7 @@ func (w *Reviewdog) runFromResult(ctx context.Context, results []*rdf.Diagnostic
 		return err
 	}
 
-	checks := filter.FilterCheck(results, filediffs, strip, wd, w.filterMode)
+	checks := filter.FilterCheck(results, filediffs, strip, wd, w.filterMode, w.filterOpts...)
 	hasViolations := false
 
 	for _, check := range checks {
@@ -104,14 +118,9 @@ func (w *Reviewdog) Run(ctx context.Context, r io.Reader) error {
 		return fmt.Errorf("parse error: %w", err)
 	}
 
-	d, err := w.d.Diff(ctx)
+	filediffs, err := FileDiffs(ctx, w.d)
 	if err != nil {
-		return fmt.Errorf("fail to get diff: %w", err)
-	}
-
-	filediffs, err := diff.ParseMultiFile(bytes.NewReader(d))
-	if err != nil {
-		return fmt.Errorf("fail to parse diff: %w", err)
+		return err
 	}
 
 	return w.runFromResult(ctx, results, filediffs, w.d.Strip(), w.failOnError)
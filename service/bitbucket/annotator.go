@@ -12,6 +12,7 @@ import (
 	bbapi "github.com/reviewdog/go-bitbucket"
 	"github.com/reviewdog/reviewdog"
 	"github.com/reviewdog/reviewdog/proto/rdf"
+	"github.com/reviewdog/reviewdog/service/commentutil"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -39,11 +40,16 @@ type ReportAnnotator struct {
 	// store annotations in map per tool name
 	// so we can create report per tool
 	annotations map[string][]bbapi.ReportAnnotation
+	// number of comments per tool which exceed limits of comments and are
+	// not annotated.
+	overflow    map[string]int
 	severityMap map[rdf.Severity]string
 
 	// wd is working directory relative to root of repository.
 	wd         string
 	duplicates map[string]struct{}
+
+	tmpl *commentutil.Template
 }
 
 // NewReportAnnotator creates new Bitbucket Report Annotator
@@ -54,6 +60,7 @@ func NewReportAnnotator(cli *bbapi.APIClient, owner, repo, sha string, runners [
 		owner:       owner,
 		repo:        repo,
 		annotations: make(map[string][]bbapi.ReportAnnotation, len(runners)),
+		overflow:    make(map[string]int),
 		severityMap: map[rdf.Severity]string{
 			rdf.Severity_INFO:    annotationSeverityLow,
 			rdf.Severity_WARNING: annotationSeverityMedium,
@@ -70,12 +77,17 @@ func NewReportAnnotator(cli *bbapi.APIClient, owner, repo, sha string, runners [
 		}
 		r.annotations[runner] = []bbapi.ReportAnnotation{}
 		// create Pending report for each tool
-		_ = r.createOrUpdateReport(context.Background(), reportID(runner, reporter), reportTitle(runner, reporter), reportResultPending)
+		_ = r.createOrUpdateReport(context.Background(), reportID(runner, reporter), reportTitle(runner, reporter), reportResultPending, 0)
 	}
 
 	return r
 }
 
+// SetTemplate sets the template of annotation details.
+func (r *ReportAnnotator) SetTemplate(t *commentutil.Template) {
+	r.tmpl = t
+}
+
 // Post accepts a comment and holds it. Flush method actually posts comments to
 // Bitbucket in batch.
 func (r *ReportAnnotator) Post(_ context.Context, c *reviewdog.Comment) error {
@@ -84,6 +96,15 @@ func (r *ReportAnnotator) Post(_ context.Context, c *reviewdog.Comment) error {
 	r.muAnnotations.Lock()
 	defer r.muAnnotations.Unlock()
 
+	if c.Overflow {
+		// It's counted in the report details instead of annotations.
+		if _, ok := r.annotations[c.ToolName]; !ok {
+			r.annotations[c.ToolName] = []bbapi.ReportAnnotation{}
+		}
+		r.overflow[c.ToolName]++
+		return nil
+	}
+
 	anot := r.annotationFromReviewDogComment(*c)
 
 	// deduplicate event, because some reporters might report
@@ -106,9 +127,9 @@ func (r *ReportAnnotator) Flush(ctx context.Context) error {
 	for tool, annotations := range r.annotations {
 		reportID := reportID(tool, reporter)
 		title := reportTitle(tool, reporter)
-		if len(annotations) == 0 {
+		if len(annotations) == 0 && r.overflow[tool] == 0 {
 			// if no annotation, create Passed report
-			if err := r.createOrUpdateReport(ctx, reportID, title, reportResultPassed); err != nil {
+			if err := r.createOrUpdateReport(ctx, reportID, title, reportResultPassed, 0); err != nil {
 				return err
 			}
 			// and move one
@@ -116,7 +137,7 @@ func (r *ReportAnnotator) Flush(ctx context.Context) error {
 		}
 
 		// create report or update report first, with the failed status
-		if err := r.createOrUpdateReport(ctx, reportID, title, reportResultFailed); err != nil {
+		if err := r.createOrUpdateReport(ctx, reportID, title, reportResultFailed, r.overflow[tool]); err != nil {
 			return err
 		}
 
@@ -150,7 +171,8 @@ func (r *ReportAnnotator) annotationFromReviewDogComment(c reviewdog.Comment) bb
 	// hash the output of linter and use it as external id
 	a.SetExternalId(externalIDFromDiagnostic(c.Result.Diagnostic))
 	a.SetSummary(c.Result.Diagnostic.GetMessage())
-	a.SetDetails(fmt.Sprintf(`[%s] %s`, c.ToolName, c.Result.Diagnostic.GetMessage()))
+	details, _ := r.tmpl.Render(&c, fmt.Sprintf(`[%s] %s`, c.ToolName, c.Result.Diagnostic.GetMessage()), "")
+	a.SetDetails(details)
 	a.SetLine(c.Result.Diagnostic.GetLocation().GetRange().GetStart().GetLine())
 	a.SetPath(c.Result.Diagnostic.GetLocation().GetPath())
 	if v, ok := r.severityMap[c.Result.Diagnostic.GetSeverity()]; ok {
@@ -163,7 +185,9 @@ func (r *ReportAnnotator) annotationFromReviewDogComment(c reviewdog.Comment) bb
 	return *a
 }
 
-func (r *ReportAnnotator) createOrUpdateReport(ctx context.Context, id, title, reportStatus string) error {
+// createOrUpdateReport creates or updates the report. overflow is the number
+// of results which are not annotated because they exceed limits of comments.
+func (r *ReportAnnotator) createOrUpdateReport(ctx context.Context, id, title, reportStatus string, overflow int) error {
 	var report = bbapi.NewReport()
 	report.SetTitle(title)
 	// TODO: different report types?
@@ -177,7 +201,11 @@ func (r *ReportAnnotator) createOrUpdateReport(ctx context.Context, id, title, r
 	case reportResultPending:
 		report.SetDetails("Please wait for Reviewdog to finish checking your code for issues.")
 	default:
-		report.SetDetails("Woof-Woof! This report generated for you by reviewdog.")
+		details := "Woof-Woof! This report generated for you by reviewdog."
+		if overflow > 0 {
+			details += fmt.Sprintf(" %d more results are not annotated because they exceed limits of comments.", overflow)
+		}
+		report.SetDetails(details)
 	}
 
 	_, resp, err := r.cli.ReportsApi.CreateOrUpdateReport(ctx, r.owner, r.repo, r.sha, id).Body(*report).Execute()
@@ -91,6 +91,25 @@ func TestAnnotator(t *testing.T) {
 				reportID("runner2", reporter): {reportResultPending, reportResultPassed},
 			},
 		},
+		{
+			name:        "Predefined runners list, and overflow comments",
+			runnersList: []string{"runner1", "runner2"},
+			comments: []*reviewdog.Comment{
+				newComment("runner2", "main.go", "test", 1),
+				newOverflowComment("runner2", "main.go", "test", 2),
+				newOverflowComment("runner3", "main.go", "test", 3),
+			},
+			expectedAnnotations: map[string]int{
+				reportID("runner2", reporter): 1,
+			},
+			expectedReportCalls: map[string][]string{
+				reportID("runner1", reporter): {reportResultPending, reportResultPassed},
+				reportID("runner2", reporter): {reportResultPending, reportResultFailed},
+				// runner3 has only overflow comments, so report will be marked as
+				// "failed" without annotations
+				reportID("runner3", reporter): {reportResultFailed},
+			},
+		},
 	}
 
 	username := "test_user"
@@ -188,3 +207,9 @@ func newComment(toolName, file, message string, line int32) *reviewdog.Comment {
 		},
 	}
 }
+
+func newOverflowComment(toolName, file, message string, line int32) *reviewdog.Comment {
+	c := newComment(toolName, file, message, line)
+	c.Overflow = true
+	return c
+}
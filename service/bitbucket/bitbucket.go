@@ -25,30 +25,29 @@ const (
 	httpTimeout  = time.Second * 10
 )
 
-// NewAPIClient creates Bitbucket API client
-func NewAPIClient(isInPipeline bool, isInPipe bool) *bbapi.APIClient {
-	httpClient := &http.Client{
-		Timeout: httpTimeout,
-	}
+// NewAPIClient creates Bitbucket API client with the given http client. In
+// Bitbucket Pipelines, the client must send requests through the proxy
+// returned by PipelineProxy.
+func NewAPIClient(client *http.Client, isInPipeline bool) *bbapi.APIClient {
+	httpClient := *client
+	httpClient.Timeout = httpTimeout
 	server := httpsServer()
-
 	if isInPipeline {
-		var proxyURL *url.URL
-		if isInPipe {
-			// if we are executing a pipe within a pipeline, use docker endpoint
-			// and proxy
-			proxyURL, _ = url.Parse(pipeProxyURL)
-		} else {
-			// if we are on the Bitbucket Pipeline, use HTTP endpoint
-			// and proxy
-			proxyURL, _ = url.Parse(pipelineProxyURL)
-		}
 		server = httpServer()
-		httpClient.Transport = &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
-		}
 	}
-	return NewAPIClientWithConfigurations(httpClient, server)
+	return NewAPIClientWithConfigurations(&httpClient, server)
+}
+
+// PipelineProxy returns the proxy of the Reports-API available in Bitbucket
+// Pipelines.
+func PipelineProxy(isInPipe bool) func(*http.Request) (*url.URL, error) {
+	// if we are executing a pipe within a pipeline, use docker endpoint
+	// and proxy
+	proxyURL, _ := url.Parse(pipelineProxyURL)
+	if isInPipe {
+		proxyURL, _ = url.Parse(pipeProxyURL)
+	}
+	return http.ProxyURL(proxyURL)
 }
 
 // NewAPIClientWithConfigurations allows to create new Bitbucket API client with
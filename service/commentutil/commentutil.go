@@ -14,7 +14,7 @@ type PostedComments map[string]map[int][]string
 
 // IsPosted returns true if a given comment has been posted in code review service already,
 // otherwise returns false. It sees comments with same path, same position,
-// and same body as same comments.
+// and same body or same fingerprint (see Template.RenderMarkdown) as same comments.
 func (p PostedComments) IsPosted(c *reviewdog.Comment, lineNum int, body string) bool {
 	path := c.Result.Diagnostic.GetLocation().GetPath()
 	if _, ok := p[path]; !ok {
@@ -24,8 +24,9 @@ func (p PostedComments) IsPosted(c *reviewdog.Comment, lineNum int, body string)
 	if !ok {
 		return false
 	}
+	fp := fingerprint(c)
 	for _, b := range bodies {
-		if b == body {
+		if b == body || bodyFingerprint(b) == fp {
 			return true
 		}
 	}
@@ -74,6 +75,9 @@ func MarkdownComment(c *reviewdog.Comment) string {
 	}
 	sb.WriteString(BodyPrefix)
 	sb.WriteString(c.Result.Diagnostic.GetMessage())
+	if len(c.AlsoReportedBy) > 0 {
+		sb.WriteString(fmt.Sprintf(" (also reported by %s)", strings.Join(c.AlsoReportedBy, ", ")))
+	}
 	return sb.String()
 }
 
@@ -98,6 +98,20 @@ func TestCommentBody(t *testing.T) {
 			},
 			want: `
 **[tool-name]** <[CODE14](https://example.com/#CODE14)> <sub>reported by [reviewdog](https://github.com/reviewdog/reviewdog) :dog:</sub><br>test message 6 (code with URL)
+`,
+		},
+		{
+			in: &reviewdog.Comment{
+				ToolName: "tool-name",
+				Result: &filter.FilteredDiagnostic{
+					Diagnostic: &rdf.Diagnostic{
+						Message: "test message 7 (also reported)",
+					},
+				},
+				AlsoReportedBy: []string{"staticcheck", "govet"},
+			},
+			want: `
+**[tool-name]** <sub>reported by [reviewdog](https://github.com/reviewdog/reviewdog) :dog:</sub><br>test message 7 (also reported) (also reported by staticcheck, govet)
 `,
 		},
 	}
@@ -0,0 +1,62 @@
+package commentutil
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+// NonLineBasedSuggestionText returns whole lines of the range of the
+// suggestion after replacing the range with the suggestion text. Code review
+// services replace whole lines with suggestions, so text before the start
+// column and after the end column is taken from source lines of the comment.
+// Columns after the end of lines (e.g. insertion at the end of line) are
+// clamped to the end of lines.
+func NonLineBasedSuggestionText(c *reviewdog.Comment, s *rdf.Suggestion) (string, error) {
+	sourceLines := c.Result.SourceLines
+	if len(sourceLines) == 0 {
+		return "", errors.New("source lines are not available")
+	}
+	start := s.GetRange().GetStart()
+	end := s.GetRange().GetEnd()
+	if end == nil {
+		end = start
+	}
+	startLineContent, err := sourceLine(sourceLines, int(start.GetLine()))
+	if err != nil {
+		return "", err
+	}
+	endLineContent, err := sourceLine(sourceLines, int(end.GetLine()))
+	if err != nil {
+		return "", err
+	}
+	startCol := clampColumn(start.GetColumn(), startLineContent)
+	endCol := clampColumn(end.GetColumn(), endLineContent)
+	if start.GetLine() == end.GetLine() && startCol > endCol {
+		return "", fmt.Errorf("suggestion range is invalid: start column %d is after end column %d (L=%d)",
+			start.GetColumn(), end.GetColumn(), start.GetLine())
+	}
+	return startLineContent[:startCol] + s.GetText() + endLineContent[endCol:], nil
+}
+
+func sourceLine(sourceLines map[int]string, line int) (string, error) {
+	lineContent, ok := sourceLines[line]
+	if !ok {
+		return "", fmt.Errorf("source line (L=%d) is not available for this suggestion", line)
+	}
+	return lineContent, nil
+}
+
+// clampColumn returns the byte offset of the 1-based column in the line.
+func clampColumn(col int32, line string) int {
+	offset := int(col) - 1
+	if offset < 0 {
+		return 0
+	}
+	if offset > len(line) {
+		return len(line)
+	}
+	return offset
+}
@@ -0,0 +1,55 @@
+package commentutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/filter"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+func TestNonLineBasedSuggestionText(t *testing.T) {
+	c := &reviewdog.Comment{Result: &filter.FilteredDiagnostic{
+		SourceLines: map[int]string{1: "foo := bar", 2: "baz"},
+	}}
+	suggestion := func(sl, sc, el, ec int32, text string) *rdf.Suggestion {
+		return &rdf.Suggestion{
+			Range: &rdf.Range{
+				Start: &rdf.Position{Line: sl, Column: sc},
+				End:   &rdf.Position{Line: el, Column: ec},
+			},
+			Text: text,
+		}
+	}
+	tests := []struct {
+		name    string
+		in      *rdf.Suggestion
+		want    string
+		wantErr string
+	}{
+		{name: "replace", in: suggestion(1, 8, 1, 11, "qux"), want: "foo := qux"},
+		{name: "multiline", in: suggestion(1, 5, 2, 2, "= "), want: "foo = az"},
+		{name: "insert at the end of line", in: suggestion(1, 11, 1, 11, "()"), want: "foo := bar()"},
+		{name: "column out of range", in: suggestion(1, 20, 1, 30, ";"), want: "foo := bar;"},
+		{name: "end column out of range", in: suggestion(1, 8, 1, 30, "qux"), want: "foo := qux"},
+		{name: "inverted range", in: suggestion(1, 8, 1, 2, "x"), wantErr: "suggestion range is invalid"},
+		{name: "no source line", in: suggestion(3, 1, 3, 2, "x"), wantErr: "source line (L=3) is not available"},
+	}
+	for _, tt := range tests {
+		got, err := NonLineBasedSuggestionText(c, tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("[%s] got error %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%s] got unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("[%s] got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
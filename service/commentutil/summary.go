@@ -0,0 +1,185 @@
+package commentutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+// Summary represents results of a tool which are reported as a summary
+// comment on the conversation tab of Pull Requests or Merge Requests.
+type Summary struct {
+	ToolName string
+	// All reported comments of the tool.
+	Comments []*reviewdog.Comment
+	// Comments which cannot be posted as inline comments because they are
+	// outside diff.
+	OutsideDiff []*reviewdog.Comment
+	// Comments which are reported only in the summary (i.e.
+	// Comment.SummaryOnly).
+	SummaryOnly []*reviewdog.Comment
+	// Comments which are not posted as inline comments to avoid rate limit.
+	Overflow []*reviewdog.Comment
+}
+
+// BuildSummaries groups comments by tool name and returns summaries sorted by
+// tool name. isOutsideDiff reports whether the comment cannot be posted as an
+// inline comment. It's not called for Comment.SummaryOnly comments. overflow
+// is a list of comments which are not posted to avoid rate limit.
+func BuildSummaries(comments []*reviewdog.Comment, isOutsideDiff func(*reviewdog.Comment) bool, overflow []*reviewdog.Comment) []*Summary {
+	summaries := make(map[string]*Summary)
+	get := func(c *reviewdog.Comment) *Summary {
+		tool := summaryToolName(c)
+		if _, ok := summaries[tool]; !ok {
+			summaries[tool] = &Summary{ToolName: tool}
+		}
+		return summaries[tool]
+	}
+	for _, c := range comments {
+		s := get(c)
+		s.Comments = append(s.Comments, c)
+		if c.SummaryOnly {
+			s.SummaryOnly = append(s.SummaryOnly, c)
+		} else if isOutsideDiff(c) {
+			s.OutsideDiff = append(s.OutsideDiff, c)
+		}
+	}
+	for _, c := range overflow {
+		s := get(c)
+		s.Overflow = append(s.Overflow, c)
+	}
+	result := make([]*Summary, 0, len(summaries))
+	for _, s := range summaries {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ToolName < result[j].ToolName })
+	return result
+}
+
+// EmptySummaries returns summaries without results of tools which don't have
+// summaries in summaries. Reporters update existing summary comments of such
+// tools (i.e. all results of them are fixed) to zero counts.
+func EmptySummaries(summaries []*Summary, tools []string) []*Summary {
+	has := make(map[string]bool)
+	for _, s := range summaries {
+		has[s.ToolName] = true
+	}
+	var result []*Summary
+	for _, tool := range tools {
+		if tool == "" || has[tool] {
+			continue
+		}
+		has[tool] = true
+		result = append(result, &Summary{ToolName: tool})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ToolName < result[j].ToolName })
+	return result
+}
+
+func summaryToolName(c *reviewdog.Comment) string {
+	if c.ToolName != "" {
+		return c.ToolName
+	}
+	return "reviewdog"
+}
+
+// SummaryMarker returns a hidden marker to locate the summary comment of the
+// given tool.
+func SummaryMarker(toolName string) string {
+	return fmt.Sprintf("<!-- reviewdog:summary:%s -->", toolName)
+}
+
+// IsSummaryComment returns true if the given comment body is the summary
+// comment of the tool.
+func IsSummaryComment(body, toolName string) bool {
+	return strings.HasPrefix(body, SummaryMarker(toolName))
+}
+
+// Markdown returns markdown of the summary comment body. The body starts with
+// SummaryMarker. formatDiagnostic formats each listed diagnostic (e.g. with a
+// link to the location) and PlainMarkdownDiagnostic is used if it's nil.
+func (s *Summary) Markdown(formatDiagnostic func(*rdf.Diagnostic) string) string {
+	return s.markdown(formatDiagnostic, false)
+}
+
+// markdown returns markdown of the summary comment body. If plain is true, it
+// doesn't use emoji nor BodyPrefix.
+func (s *Summary) markdown(formatDiagnostic func(*rdf.Diagnostic) string, plain bool) string {
+	if formatDiagnostic == nil {
+		formatDiagnostic = PlainMarkdownDiagnostic
+	}
+	var sb strings.Builder
+	sb.WriteString(SummaryMarker(s.ToolName))
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("### **[%s]** reviewdog summary\n", s.ToolName))
+	sb.WriteString("\n")
+	if !plain {
+		sb.WriteString(BodyPrefix)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("| Severity | Count |\n")
+	sb.WriteString("| -------- | ----- |\n")
+	for _, sc := range s.severityCounts(plain) {
+		sb.WriteString(fmt.Sprintf("| %s | %d |\n", sc.name, sc.count))
+	}
+	writeDetails := func(title string, comments []*reviewdog.Comment) {
+		if len(comments) == 0 {
+			return
+		}
+		sb.WriteString("\n")
+		sb.WriteString("<details>\n")
+		sb.WriteString(fmt.Sprintf("<summary>%s (%d)</summary>\n", title, len(comments)))
+		sb.WriteString("\n")
+		for _, c := range comments {
+			sb.WriteString("- ")
+			sb.WriteString(formatDiagnostic(c.Result.Diagnostic))
+			sb.WriteString("\n")
+		}
+		sb.WriteString("</details>\n")
+	}
+	writeDetails("Findings outside the diff", s.OutsideDiff)
+	writeDetails("Findings reported only in this summary", s.SummaryOnly)
+	writeDetails("Remaining findings which were not posted as inline comments", s.Overflow)
+	return sb.String()
+}
+
+type severityCount struct {
+	name  string
+	count int
+}
+
+func (s *Summary) severityCounts(plain bool) []severityCount {
+	counts := make(map[rdf.Severity]int)
+	for _, c := range s.Comments {
+		counts[c.Result.Diagnostic.GetSeverity()]++
+	}
+	result := []severityCount{
+		{name: "🚫 Error", count: counts[rdf.Severity_ERROR]},
+		{name: "⚠️ Warning", count: counts[rdf.Severity_WARNING]},
+		{name: "📝 Info", count: counts[rdf.Severity_INFO]},
+	}
+	if plain {
+		result[0].name, result[1].name, result[2].name = "Error", "Warning", "Info"
+	}
+	if n := counts[rdf.Severity_UNKNOWN_SEVERITY]; n > 0 {
+		result = append(result, severityCount{name: "Unknown", count: n})
+	}
+	return result
+}
+
+// PlainMarkdownDiagnostic returns Markdown string of the location and the
+// message of the diagnostic without a link.
+func PlainMarkdownDiagnostic(d *rdf.Diagnostic) string {
+	loc := d.GetLocation()
+	path := loc.GetPath()
+	if path == "" {
+		return d.GetMessage()
+	}
+	if lnum := loc.GetRange().GetStart().GetLine(); lnum > 0 {
+		path = fmt.Sprintf("%s:%d", path, lnum)
+	}
+	return fmt.Sprintf("`%s` %s", path, d.GetMessage())
+}
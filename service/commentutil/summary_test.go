@@ -0,0 +1,118 @@
+package commentutil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/filter"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+func TestSummary_Markdown(t *testing.T) {
+	newComment := func(tool, msg string, line int32, sev rdf.Severity, inDiff bool) *reviewdog.Comment {
+		return &reviewdog.Comment{
+			ToolName: tool,
+			Result: &filter.FilteredDiagnostic{
+				Diagnostic: &rdf.Diagnostic{
+					Location: &rdf.Location{Path: "file.go", Range: &rdf.Range{Start: &rdf.Position{Line: line}}},
+					Message:  msg,
+					Severity: sev,
+				},
+				InDiffContext: inDiff,
+			},
+		}
+	}
+	info := newComment("tool", "info only in summary", 4, rdf.Severity_INFO, false)
+	info.SummaryOnly = true
+	comments := []*reviewdog.Comment{
+		newComment("tool", "error", 1, rdf.Severity_ERROR, true),
+		newComment("tool", "warning outside diff", 2, rdf.Severity_WARNING, false),
+		newComment("another-tool", "no severity", 3, rdf.Severity_UNKNOWN_SEVERITY, true),
+		info,
+	}
+	overflow := []*reviewdog.Comment{comments[0]}
+	summaries := BuildSummaries(comments, func(c *reviewdog.Comment) bool {
+		return !c.Result.InDiffContext
+	}, overflow)
+	if len(summaries) != 2 {
+		t.Fatalf("got %d summaries, want 2", len(summaries))
+	}
+
+	want := `<!-- reviewdog:summary:another-tool -->
+### **[another-tool]** reviewdog summary
+
+<sub>reported by [reviewdog](https://github.com/reviewdog/reviewdog) :dog:</sub><br>
+
+| Severity | Count |
+| -------- | ----- |
+| 🚫 Error | 0 |
+| ⚠️ Warning | 0 |
+| 📝 Info | 0 |
+| Unknown | 1 |
+`
+	if diff := cmp.Diff(summaries[0].Markdown(nil), want); diff != "" {
+		t.Error(diff)
+	}
+
+	want = `<!-- reviewdog:summary:tool -->
+### **[tool]** reviewdog summary
+
+<sub>reported by [reviewdog](https://github.com/reviewdog/reviewdog) :dog:</sub><br>
+
+| Severity | Count |
+| -------- | ----- |
+| 🚫 Error | 1 |
+| ⚠️ Warning | 1 |
+| 📝 Info | 1 |
+
+<details>
+<summary>Findings outside the diff (1)</summary>
+
+- ` + "`file.go:2`" + ` warning outside diff
+</details>
+
+<details>
+<summary>Findings reported only in this summary (1)</summary>
+
+- ` + "`file.go:4`" + ` info only in summary
+</details>
+
+<details>
+<summary>Remaining findings which were not posted as inline comments (1)</summary>
+
+- ` + "`file.go:1`" + ` error
+</details>
+`
+	got := summaries[1].Markdown(nil)
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+	if !IsSummaryComment(got, "tool") || IsSummaryComment(got, "another-tool") {
+		t.Error("IsSummaryComment doesn't match the summary comment of the tool")
+	}
+
+	// Summaries don't use emoji nor the banner if templates are set.
+	tmpl, err := NewTemplate("{{.Message}}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `<!-- reviewdog:summary:another-tool -->
+### **[another-tool]** reviewdog summary
+
+| Severity | Count |
+| -------- | ----- |
+| Error | 0 |
+| Warning | 0 |
+| Info | 0 |
+| Unknown | 1 |
+`
+	if diff := cmp.Diff(tmpl.SummaryMarkdown(summaries[0], nil), want); diff != "" {
+		t.Error(diff)
+	}
+	var nilTmpl *Template
+	if diff := cmp.Diff(nilTmpl.SummaryMarkdown(summaries[0], nil), summaries[0].Markdown(nil)); diff != "" {
+		t.Errorf("SummaryMarkdown() of nil Template has diff:\n%s", diff)
+	}
+}
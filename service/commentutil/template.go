@@ -0,0 +1,154 @@
+package commentutil
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+// Template renders comment bodies with Go text/template instead of the
+// default format. A template for the tool of a comment takes precedence over
+// the default template. Tools are looked up by Comment.ToolName (i.e. runner
+// names) and then by source names of diagnostics.
+type Template struct {
+	def   *template.Template
+	tools map[string]*template.Template
+}
+
+// TemplateData is the data to render comment templates.
+type TemplateData struct {
+	Diagnostic *rdf.Diagnostic
+	ToolName   string
+	// Severity name (e.g. "ERROR"). Empty if it's unknown.
+	Severity string
+	Code     string
+	CodeURL  string
+	Message  string
+	// Names of other tools which reported the same diagnostic (see
+	// Comment.AlsoReportedBy).
+	AlsoReportedBy []string
+	// Suggestions rendered by the reporter (e.g. suggestion blocks of GitHub).
+	// Raw suggestions are available as .Diagnostic.Suggestions.
+	Suggestions string
+	// Body in the default format of the reporter.
+	Body string
+}
+
+// NewTemplate parses the default template def and templates per tool name.
+// def can be empty to use the default format for tools without templates.
+func NewTemplate(def string, tools map[string]string) (*Template, error) {
+	t := &Template{tools: make(map[string]*template.Template)}
+	if def != "" {
+		tmpl, err := ParseTemplate("default", def)
+		if err != nil {
+			return nil, err
+		}
+		t.def = tmpl
+	}
+	for tool, text := range tools {
+		tmpl, err := ParseTemplate(tool, text)
+		if err != nil {
+			return nil, err
+		}
+		t.tools[tool] = tmpl
+	}
+	return t, nil
+}
+
+// ParseTemplate parses a comment template.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Render renders the body of the comment and returns true if it's rendered
+// with a template. body is the body in the default format and suggestions are
+// suggestions rendered by the reporter. It returns body as is if t is nil or
+// there are no templates for the comment. It also falls back to body if it
+// fails to render the template.
+func (t *Template) Render(c *reviewdog.Comment, body, suggestions string) (string, bool) {
+	if t == nil {
+		return body, false
+	}
+	tool := toolName(c)
+	tmpl, ok := t.tools[c.ToolName]
+	if !ok {
+		if tmpl, ok = t.tools[tool]; !ok {
+			tmpl = t.def
+		}
+	}
+	if tmpl == nil {
+		return body, false
+	}
+	d := c.Result.Diagnostic
+	data := &TemplateData{
+		Diagnostic:     d,
+		ToolName:       tool,
+		Code:           d.GetCode().GetValue(),
+		CodeURL:        d.GetCode().GetUrl(),
+		Message:        d.GetMessage(),
+		AlsoReportedBy: c.AlsoReportedBy,
+		Suggestions:    suggestions,
+		Body:           body,
+	}
+	if s := d.GetSeverity(); s != rdf.Severity_UNKNOWN_SEVERITY {
+		data.Severity = s.String()
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		log.Printf("reviewdog: failed to render comment template of %s: %v", tool, err)
+		return body, false
+	}
+	return sb.String(), true
+}
+
+// RenderMarkdown is same as Render but it appends a hidden marker which
+// identifies the diagnostic to bodies rendered with a template.
+// PostedComments.IsPosted sees comments with the same marker as same comments
+// even if their bodies are rendered by different templates.
+func (t *Template) RenderMarkdown(c *reviewdog.Comment, body, suggestions string) string {
+	rendered, ok := t.Render(c, body, suggestions)
+	if !ok {
+		return body
+	}
+	return fmt.Sprintf("%s\n<!-- reviewdog:fingerprint:%s -->", rendered, fingerprint(c))
+}
+
+// SummaryMarkdown is same as Summary.Markdown but it renders the summary
+// without emoji nor the reviewdog banner if t is not nil, so that summary
+// comments follow comment bodies rendered with templates (e.g. templates to
+// remove emoji).
+func (t *Template) SummaryMarkdown(s *Summary, formatDiagnostic func(*rdf.Diagnostic) string) string {
+	return s.markdown(formatDiagnostic, t != nil)
+}
+
+var fingerprintRe = regexp.MustCompile(`<!-- reviewdog:fingerprint:([0-9a-f]+) -->`)
+
+// fingerprint returns an identifier of the diagnostic of the comment which
+// doesn't depend on the format of comment bodies. Comments are also
+// identified by their paths and lines.
+func fingerprint(c *reviewdog.Comment) string {
+	d := c.Result.Diagnostic
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", toolName(c), d.GetCode().GetValue(), d.GetMessage())
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
+}
+
+// bodyFingerprint returns the fingerprint in the marker of the body or empty
+// string if there are no markers.
+func bodyFingerprint(body string) string {
+	m := fingerprintRe.FindStringSubmatch(body)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
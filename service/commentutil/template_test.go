@@ -0,0 +1,112 @@
+package commentutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/filter"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+func TestTemplate_Render(t *testing.T) {
+	tmpl, err := NewTemplate("{{.Severity}} [{{.ToolName}}] {{.Message}}\n---\nLegal footer", map[string]string{
+		"golint": "{{.Code}}: {{.Message}} ({{.CodeURL}})\n{{.Suggestions}}",
+		"broken": "{{.Unknown}}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	comment := func(tool, source string) *reviewdog.Comment {
+		return &reviewdog.Comment{
+			ToolName: tool,
+			Result: &filter.FilteredDiagnostic{
+				Diagnostic: &rdf.Diagnostic{
+					Message:  "msg",
+					Severity: rdf.Severity_WARNING,
+					Code:     &rdf.Code{Value: "C1", Url: "https://example.com/C1"},
+					Source:   &rdf.Source{Name: source},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name     string
+		c        *reviewdog.Comment
+		want     string
+		rendered bool
+	}{
+		{name: "default", c: comment("vet", ""), want: "WARNING [vet] msg\n---\nLegal footer", rendered: true},
+		{name: "tool", c: comment("golint", ""), want: "C1: msg (https://example.com/C1)\nsuggestions", rendered: true},
+		{name: "source name", c: comment("lint", "golint"), want: "C1: msg (https://example.com/C1)\nsuggestions", rendered: true},
+		{name: "fallback", c: comment("broken", ""), want: "body", rendered: false},
+	}
+	for _, tt := range tests {
+		got, rendered := tmpl.Render(tt.c, "body", "suggestions")
+		if got != tt.want || rendered != tt.rendered {
+			t.Errorf("[%s] Render() = %q, %v, want %q, %v", tt.name, got, rendered, tt.want, tt.rendered)
+		}
+	}
+
+	var nilTmpl *Template
+	if got, rendered := nilTmpl.Render(comment("vet", ""), "body", ""); got != "body" || rendered {
+		t.Errorf("Render() of nil Template = %q, %v, want body as is", got, rendered)
+	}
+}
+
+func TestTemplate_RenderMarkdown_isPosted(t *testing.T) {
+	c := &reviewdog.Comment{
+		ToolName: "tool",
+		Result: &filter.FilteredDiagnostic{
+			Diagnostic: &rdf.Diagnostic{
+				Message:  "msg",
+				Location: &rdf.Location{Path: "a.go"},
+			},
+		},
+	}
+	t1, err := NewTemplate("v1: {{.Message}}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t2, err := NewTemplate("v2: {{.Message}}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body1 := t1.RenderMarkdown(c, MarkdownComment(c), "")
+	if !strings.HasPrefix(body1, "v1: msg\n<!-- reviewdog:fingerprint:") {
+		t.Errorf("RenderMarkdown() = %q, want body with fingerprint", body1)
+	}
+	posted := make(PostedComments)
+	posted.AddPostedComment("a.go", 1, body1)
+
+	// It's posted even if the template changes.
+	body2 := t2.RenderMarkdown(c, MarkdownComment(c), "")
+	if body1 == body2 || !posted.IsPosted(c, 1, body2) {
+		t.Errorf("IsPosted(%q) = false, want true", body2)
+	}
+	other := &reviewdog.Comment{
+		ToolName: "tool",
+		Result: &filter.FilteredDiagnostic{
+			Diagnostic: &rdf.Diagnostic{
+				Message:  "other msg",
+				Location: &rdf.Location{Path: "a.go"},
+			},
+		},
+	}
+	if body := t2.RenderMarkdown(other, MarkdownComment(other), ""); posted.IsPosted(other, 1, body) {
+		t.Errorf("IsPosted(%q) = true, want false", body)
+	}
+
+	// Other tools which reported the same diagnostic don't change the
+	// fingerprint.
+	t3, err := NewTemplate("{{.Message}} {{.AlsoReportedBy}}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := *c
+	merged.AlsoReportedBy = []string{"other-tool"}
+	body3 := t3.RenderMarkdown(&merged, MarkdownComment(&merged), "")
+	if !strings.HasPrefix(body3, "msg [other-tool]\n") || !posted.IsPosted(&merged, 1, body3) {
+		t.Errorf("IsPosted(%q) = false, want true", body3)
+	}
+}
@@ -0,0 +1,86 @@
+// Package commitstatus provides shared logic of reporters which report
+// results of each runner as a commit status.
+package commitstatus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// State is a state of a commit status.
+type State string
+
+const (
+	// Success means no results are reported.
+	Success State = "success"
+	// Failure means results are reported with "error" level.
+	Failure State = "failure"
+	// Neutral means results are reported with "info" or "warning" level. Each
+	// service maps it to its non-blocking state.
+	Neutral State = "neutral"
+)
+
+// Status represents a commit status of a runner.
+type Status struct {
+	// Name of the runner. Each service builds the status context from it.
+	Name        string
+	State       State
+	Description string
+	// Optional.
+	TargetURL string
+}
+
+// Setter sets a commit status.
+type Setter interface {
+	SetStatus(ctx context.Context, s *Status) error
+}
+
+// Context returns a commit status context for the given runner name.
+func Context(name string) string {
+	return "reviewdog/" + name
+}
+
+// New returns Status of the runner based on the level and the number of
+// reported results. inDiff is true if results are filtered by diff and is
+// used to build the description.
+func New(name, level string, count int, inDiff bool, targetURL string) *Status {
+	return &Status{
+		Name:        name,
+		State:       state(level, count),
+		Description: description(level, count, inDiff),
+		TargetURL:   targetURL,
+	}
+}
+
+func state(level string, count int) State {
+	if count == 0 {
+		return Success
+	}
+	switch strings.ToLower(level) {
+	case "info", "warning":
+		return Neutral
+	}
+	return Failure
+}
+
+func description(level string, count int, inDiff bool) string {
+	where := "found"
+	if inDiff {
+		where = "in diff"
+	}
+	if count == 0 {
+		return "No results " + where
+	}
+	noun := "error"
+	switch strings.ToLower(level) {
+	case "info":
+		noun = "result"
+	case "warning":
+		noun = "warning"
+	}
+	if count > 1 {
+		noun += "s"
+	}
+	return fmt.Sprintf("%d %s %s", count, noun, where)
+}
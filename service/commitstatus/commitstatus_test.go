@@ -0,0 +1,43 @@
+package commitstatus
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		level  string
+		count  int
+		inDiff bool
+		want   *Status
+	}{
+		{
+			level: "error", count: 0, inDiff: true,
+			want: &Status{Name: "tool", State: Success, Description: "No results in diff", TargetURL: "url"},
+		},
+		{
+			level: "", count: 3, inDiff: true,
+			want: &Status{Name: "tool", State: Failure, Description: "3 errors in diff", TargetURL: "url"},
+		},
+		{
+			level: "error", count: 1, inDiff: false,
+			want: &Status{Name: "tool", State: Failure, Description: "1 error found", TargetURL: "url"},
+		},
+		{
+			level: "Warning", count: 2, inDiff: true,
+			want: &Status{Name: "tool", State: Neutral, Description: "2 warnings in diff", TargetURL: "url"},
+		},
+		{
+			level: "info", count: 1, inDiff: true,
+			want: &Status{Name: "tool", State: Neutral, Description: "1 result in diff", TargetURL: "url"},
+		},
+	}
+	for _, tt := range tests {
+		got := New("tool", tt.level, tt.count, tt.inDiff, "url")
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Errorf("New(%q, %d, %v) has diff:\n%s", tt.level, tt.count, tt.inDiff, diff)
+		}
+	}
+}
@@ -3,8 +3,6 @@ package gerrit
 import (
 	"context"
 	"fmt"
-	"os/exec"
-	"strings"
 
 	"golang.org/x/build/gerrit"
 
@@ -58,17 +56,8 @@ func (g *ChangeDiff) Diff(ctx context.Context) ([]byte, error) {
 	return g.gitDiff(ctx, change.CurrentRevision, g.branch)
 }
 
-func (g *ChangeDiff) gitDiff(_ context.Context, baseSha, targetSha string) ([]byte, error) {
-	b, err := exec.Command("git", "merge-base", targetSha, baseSha).Output() // #nosec
-	if err != nil {
-		return nil, fmt.Errorf("failed to get merge-base commit: %w", err)
-	}
-	mergeBase := strings.Trim(string(b), "\n")
-	bytes, err := exec.Command("git", "diff", "--find-renames", mergeBase, baseSha).Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to run git diff: %w", err)
-	}
-	return bytes, nil
+func (g *ChangeDiff) gitDiff(ctx context.Context, baseSha, targetSha string) ([]byte, error) {
+	return serviceutil.GitDiffFromMergeBase(ctx, targetSha, baseSha)
 }
 
 // Strip returns 1 as a strip of git diff.
@@ -4,49 +4,91 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"sync"
 
-	"golang.org/x/build/gerrit"
-
 	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+	"github.com/reviewdog/reviewdog/service/commentutil"
 	"github.com/reviewdog/reviewdog/service/serviceutil"
 )
 
 var _ reviewdog.CommentService = &ChangeReviewCommenter{}
 
-// ChangeReviewCommenter is a comment service for Gerrit Change Review
+// reviewTag is a tag of reviews posted by reviewdog. Gerrit UI can filter out
+// reviews with "autogenerated:" prefix tag.
+const reviewTag = "autogenerated:reviewdog"
+
+// ChangeReviewCommenter is a comment service for Gerrit Change Review.
+// It posts results as robot comments with fix suggestions.
 // API:
 // 	https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#set-review
 // 	POST /changes/{change-id}/revisions/{revision-id}/review
 type ChangeReviewCommenter struct {
-	cli        *gerrit.Client
+	cli        *Client
 	changeID   string
 	revisionID string
+	runID      string
+	vote       *LabelVote
 
 	muComments   sync.Mutex
 	postComments []*reviewdog.Comment
 
+	limiter *reviewdog.CommentLimiter
+	tmpl    *commentutil.Template
+
 	// wd is working directory relative to root of repository.
 	wd string
 }
 
+// LabelVote represents a label vote which ChangeReviewCommenter casts along
+// with robot comments (e.g. Verified -1 when errors exist), so that Gerrit
+// submit rules can gate changes on reviewdog results.
+type LabelVote struct {
+	// Label name. e.g. "Verified", "Code-Review".
+	Label string
+	// Value to vote when there are error results. e.g. -1.
+	Fail int
+	// Value to vote when there are no error results. e.g. 0 or +1.
+	Pass int
+}
+
 // NewChangeReviewCommenter returns a new NewChangeReviewCommenter service.
+// runID is used as robot_run_id of robot comments and revisionID is used if
+// it's empty. vote is optional.
 // ChangeReviewCommenter service needs git command in $PATH.
-func NewChangeReviewCommenter(cli *gerrit.Client, changeID, revisionID string) (*ChangeReviewCommenter, error) {
+func NewChangeReviewCommenter(cli *Client, changeID, revisionID, runID string, vote *LabelVote) (*ChangeReviewCommenter, error) {
 	workDir, err := serviceutil.GitRelWorkdir()
 	if err != nil {
 		return nil, fmt.Errorf("ChangeReviewCommenter needs 'git' command: %w", err)
 	}
+	if runID == "" {
+		runID = revisionID
+	}
 
 	return &ChangeReviewCommenter{
 		cli:          cli,
 		changeID:     changeID,
 		revisionID:   revisionID,
+		runID:        runID,
+		vote:         vote,
 		postComments: []*reviewdog.Comment{},
 		wd:           workDir,
 	}, nil
 }
 
+// SetCommentLimits sets limits of robot comments in a run. Comments which
+// exceed them are listed in the review message. Only comments in diff files
+// are counted.
+func (g *ChangeReviewCommenter) SetCommentLimits(limits reviewdog.CommentLimits) {
+	g.limiter = reviewdog.NewCommentLimiter(limits)
+}
+
+// SetTemplate sets the template of robot comment messages.
+func (g *ChangeReviewCommenter) SetTemplate(t *commentutil.Template) {
+	g.tmpl = t
+}
+
 // Post accepts a comment and holds it. Flush method actually posts comments to Gerrit
 func (g *ChangeReviewCommenter) Post(_ context.Context, c *reviewdog.Comment) error {
 	c.Result.Diagnostic.GetLocation().Path = filepath.Join(g.wd, c.Result.Diagnostic.GetLocation().GetPath())
@@ -61,24 +103,183 @@ func (g *ChangeReviewCommenter) Flush(ctx context.Context) error {
 	g.muComments.Lock()
 	defer g.muComments.Unlock()
 
-	return g.postAllComments(ctx)
+	if err := g.postAllComments(ctx); err != nil {
+		return err
+	}
+	g.postComments = []*reviewdog.Comment{}
+	return nil
 }
 
 func (g *ChangeReviewCommenter) postAllComments(ctx context.Context) error {
-	review := gerrit.ReviewInput{
-		Comments: map[string][]gerrit.CommentInput{},
+	review := &ReviewInput{
+		Tag:           reviewTag,
+		RobotComments: map[string][]RobotCommentInput{},
 	}
+	var candidates, overflow []*reviewdog.Comment
+	// The vote reflects only comments of this batch.
+	hasErrors := false
 	for _, c := range g.postComments {
 		if !c.Result.InDiffFile {
 			continue
 		}
-		loc := c.Result.Diagnostic.GetLocation()
-		path := loc.GetPath()
-		review.Comments[path] = append(review.Comments[path], gerrit.CommentInput{
-			Line:    int(loc.GetRange().GetStart().GetLine()),
-			Message: c.Result.Diagnostic.GetMessage(),
-		})
+		if isError(c.Result.Diagnostic) {
+			hasErrors = true
+		}
+		if c.Overflow {
+			// It exceeds limits of comments.
+			overflow = append(overflow, c)
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+	within, over := g.limiter.Limit(candidates)
+	overflow = append(overflow, over...)
+	for _, c := range within {
+		path := c.Result.Diagnostic.GetLocation().GetPath()
+		review.RobotComments[path] = append(review.RobotComments[path], g.buildRobotComment(c))
+	}
+	if g.vote != nil && g.vote.Label != "" {
+		v := g.vote.Pass
+		if hasErrors {
+			v = g.vote.Fail
+		}
+		review.Labels = map[string]int{g.vote.Label: v}
+	}
+	review.Message = overflowMessage(overflow)
+	if len(review.RobotComments) == 0 && len(review.Labels) == 0 && review.Message == "" {
+		return nil
 	}
 
 	return g.cli.SetReview(ctx, g.changeID, g.revisionID, review)
 }
+
+// overflowMessage returns a review message which lists comments exceeding
+// limits of comments. It returns empty string if there are no such comments.
+func overflowMessage(overflow []*reviewdog.Comment) string {
+	if len(overflow) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("Remaining results which are not posted as robot comments because they exceed limits of comments:\n")
+	for _, c := range overflow {
+		d := c.Result.Diagnostic
+		sb.WriteString(fmt.Sprintf("\n- %s:%d [%s] %s", d.GetLocation().GetPath(),
+			d.GetLocation().GetRange().GetStart().GetLine(), toolName(c), d.GetMessage()))
+	}
+	return sb.String()
+}
+
+func (g *ChangeReviewCommenter) buildRobotComment(c *reviewdog.Comment) RobotCommentInput {
+	d := c.Result.Diagnostic
+	msg := d.GetMessage()
+	if len(c.AlsoReportedBy) > 0 {
+		msg += fmt.Sprintf(" (also reported by %s)", strings.Join(c.AlsoReportedBy, ", "))
+	}
+	msg, _ = g.tmpl.Render(c, msg, "")
+	r := RobotCommentInput{
+		Line:       int(d.GetLocation().GetRange().GetStart().GetLine()),
+		Message:    msg,
+		RobotID:    toolName(c),
+		RobotRunID: g.runID,
+		URL:        d.GetCode().GetUrl(),
+	}
+	// Gerrit API: If range is set, line must be equal to the end line of the range.
+	if cr := commentRange(d.GetLocation().GetRange()); cr != nil {
+		r.Line = cr.EndLine
+		r.Range = cr
+	}
+	if r.RobotID == "" {
+		r.RobotID = "reviewdog"
+	}
+	if r.URL == "" {
+		r.URL = d.GetSource().GetUrl()
+	}
+	for _, s := range d.GetSuggestions() {
+		r.FixSuggestions = append(r.FixSuggestions, FixSuggestionInfo{
+			Description: fmt.Sprintf("Fix suggested by %s", r.RobotID),
+			Replacements: []FixReplacementInfo{
+				buildFixReplacement(d.GetLocation().GetPath(), s),
+			},
+		})
+	}
+	return r
+}
+
+// commentRange returns Gerrit comment range of the given range. It returns nil
+// if the range doesn't have columns, so that the comment is posted as a line
+// comment.
+func commentRange(r *rdf.Range) *CommentRange {
+	start := r.GetStart()
+	end := r.GetEnd()
+	if start.GetLine() == 0 || (start.GetColumn() == 0 && end.GetColumn() == 0) {
+		return nil
+	}
+	cr := &CommentRange{
+		StartLine:      int(start.GetLine()),
+		StartCharacter: character(start.GetColumn()),
+		EndLine:        int(end.GetLine()),
+		EndCharacter:   character(end.GetColumn()),
+	}
+	if end.GetLine() == 0 {
+		// Zero-length range.
+		cr.EndLine, cr.EndCharacter = cr.StartLine, cr.StartCharacter
+	}
+	return cr
+}
+
+// buildFixReplacement builds Gerrit fix replacement from the suggestion.
+// Linewise suggestions replace whole lines including the line break of the
+// end line.
+func buildFixReplacement(path string, s *rdf.Suggestion) FixReplacementInfo {
+	start := s.GetRange().GetStart()
+	end := s.GetRange().GetEnd()
+	if start.GetColumn() > 0 || end.GetColumn() > 0 {
+		return FixReplacementInfo{
+			Path:        path,
+			Range:       commentRange(s.GetRange()),
+			Replacement: s.GetText(),
+		}
+	}
+	endLine := int(end.GetLine())
+	if endLine == 0 {
+		endLine = int(start.GetLine())
+	}
+	replacement := s.GetText()
+	if replacement != "" {
+		replacement += "\n"
+	}
+	return FixReplacementInfo{
+		Path: path,
+		Range: &CommentRange{
+			StartLine: int(start.GetLine()),
+			EndLine:   endLine + 1,
+		},
+		Replacement: replacement,
+	}
+}
+
+// character converts 1-based column to 0-based character.
+func character(column int32) int {
+	if column < 1 {
+		return 0
+	}
+	return int(column - 1)
+}
+
+func toolName(c *reviewdog.Comment) string {
+	if name := c.Result.Diagnostic.GetSource().GetName(); name != "" {
+		return name
+	}
+	return c.ToolName
+}
+
+// isError returns true if the diagnostic should fail the label vote.
+// Diagnostics without severity are treated as errors.
+func isError(d *rdf.Diagnostic) bool {
+	switch d.GetSeverity() {
+	case rdf.Severity_WARNING, rdf.Severity_INFO:
+		return false
+	default:
+		return true
+	}
+}
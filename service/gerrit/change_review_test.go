@@ -10,11 +10,11 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
-	"golang.org/x/build/gerrit"
 
 	"github.com/reviewdog/reviewdog"
 	"github.com/reviewdog/reviewdog/filter"
 	"github.com/reviewdog/reviewdog/proto/rdf"
+	"github.com/reviewdog/reviewdog/service/commentutil"
 )
 
 func TestChangeReviewCommenter_Post_Flush(t *testing.T) {
@@ -43,6 +43,7 @@ func TestChangeReviewCommenter_Post_Flush(t *testing.T) {
 			},
 			InDiffFile: true,
 		},
+		ToolName: "tool",
 	}
 	newLnum2 := 15
 	newComment2 := &reviewdog.Comment{
@@ -58,6 +59,42 @@ func TestChangeReviewCommenter_Post_Flush(t *testing.T) {
 			},
 			InDiffFile: true,
 		},
+		ToolName: "tool",
+	}
+	commentWithSuggestion := &reviewdog.Comment{
+		Result: &filter.FilteredDiagnostic{
+			Diagnostic: &rdf.Diagnostic{
+				Location: &rdf.Location{
+					Path: "file2.go",
+					Range: &rdf.Range{
+						Start: &rdf.Position{Line: 20, Column: 5},
+						End:   &rdf.Position{Line: 20, Column: 7},
+					},
+				},
+				Message:  "comment with suggestion",
+				Severity: rdf.Severity_WARNING,
+				Source:   &rdf.Source{Name: "linter"},
+				Code:     &rdf.Code{Value: "R1", Url: "https://example.com/R1"},
+				Suggestions: []*rdf.Suggestion{
+					{
+						Range: &rdf.Range{
+							Start: &rdf.Position{Line: 20, Column: 5},
+							End:   &rdf.Position{Line: 20, Column: 7},
+						},
+						Text: "14",
+					},
+					{
+						Range: &rdf.Range{
+							Start: &rdf.Position{Line: 20},
+							End:   &rdf.Position{Line: 21},
+						},
+						Text: "haya14busa",
+					},
+				},
+			},
+			InDiffFile: true,
+		},
+		ToolName: "tool",
 	}
 	commentOutsideDiff := &reviewdog.Comment{
 		Result: &filter.FilteredDiagnostic{
@@ -74,36 +111,83 @@ func TestChangeReviewCommenter_Post_Flush(t *testing.T) {
 		},
 	}
 
+	overflowComment := &reviewdog.Comment{
+		Result: &filter.FilteredDiagnostic{
+			Diagnostic: &rdf.Diagnostic{
+				Location: &rdf.Location{
+					Path: "file.go",
+					Range: &rdf.Range{Start: &rdf.Position{
+						Line: 20,
+					}},
+				},
+				Message: "overflow comment",
+			},
+			InDiffFile: true,
+		},
+		ToolName: "tool",
+		Overflow: true,
+	}
+
 	comments := []*reviewdog.Comment{
 		newComment1,
 		newComment2,
+		commentWithSuggestion,
 		commentOutsideDiff,
+		overflowComment,
 	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc(`/changes/testChangeID/revisions/testRevisionID/review`, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
-			got := new(gerrit.ReviewInput)
+			got := new(ReviewInput)
 			if err := json.NewDecoder(r.Body).Decode(got); err != nil {
 				t.Error(err)
 			}
 
-			if want := len(comments) - 1; len(got.Comments) != want {
-				t.Errorf("got %d comments, want %d", len(got.Comments), want)
-			}
-
-			line1 := int(newComment1.Result.Diagnostic.GetLocation().GetRange().GetStart().GetLine())
-			want := []gerrit.CommentInput{{
-				Line: line1, Message: newComment1.Result.Diagnostic.GetMessage()}}
-			if diff := cmp.Diff(got.Comments["file.go"], want); diff != "" {
-				t.Error(diff)
+			want := &ReviewInput{
+				Message: "Remaining results which are not posted as robot comments because they exceed limits of comments:\n" +
+					"\n- file.go:20 [tool] overflow comment",
+				Tag:    "autogenerated:reviewdog",
+				Labels: map[string]int{"Verified": -1},
+				RobotComments: map[string][]RobotCommentInput{
+					"file.go": {{
+						Line: 14, Message: "new comment", RobotID: "tool", RobotRunID: "testRevisionID",
+					}},
+					"file2.go": {
+						{
+							Line: 15, Message: "new comment 2", RobotID: "tool", RobotRunID: "testRevisionID",
+						},
+						{
+							Line:       20,
+							Range:      &CommentRange{StartLine: 20, StartCharacter: 4, EndLine: 20, EndCharacter: 6},
+							Message:    "comment with suggestion",
+							RobotID:    "linter",
+							RobotRunID: "testRevisionID",
+							URL:        "https://example.com/R1",
+							FixSuggestions: []FixSuggestionInfo{
+								{
+									Description: "Fix suggested by linter",
+									Replacements: []FixReplacementInfo{{
+										Path:        "file2.go",
+										Range:       &CommentRange{StartLine: 20, StartCharacter: 4, EndLine: 20, EndCharacter: 6},
+										Replacement: "14",
+									}},
+								},
+								{
+									Description: "Fix suggested by linter",
+									Replacements: []FixReplacementInfo{{
+										Path:        "file2.go",
+										Range:       &CommentRange{StartLine: 20, EndLine: 22},
+										Replacement: "haya14busa\n",
+									}},
+								},
+							},
+						},
+					},
+				},
 			}
-
-			line2 := int(newComment2.Result.Diagnostic.GetLocation().GetRange().GetStart().GetLine())
-			want = []gerrit.CommentInput{{
-				Line: line2, Message: newComment2.Result.Diagnostic.GetMessage()}}
-			if diff := cmp.Diff(got.Comments["file2.go"], want); diff != "" {
+			if diff := cmp.Diff(got, want); diff != "" {
 				t.Error(diff)
 			}
 
@@ -116,9 +200,10 @@ func TestChangeReviewCommenter_Post_Flush(t *testing.T) {
 	ts := httptest.NewServer(mux)
 	defer ts.Close()
 
-	cli := gerrit.NewClient(ts.URL, gerrit.NoAuth)
+	cli := NewClient(ts.URL, NoAuth)
 
-	g, err := NewChangeReviewCommenter(cli, "testChangeID", "testRevisionID")
+	g, err := NewChangeReviewCommenter(cli, "testChangeID", "testRevisionID", "",
+		&LabelVote{Label: "Verified", Fail: -1, Pass: 1})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -133,3 +218,113 @@ func TestChangeReviewCommenter_Post_Flush(t *testing.T) {
 		t.Errorf("%v", err)
 	}
 }
+
+func TestChangeReviewCommenter_Flush_voteResetsPerFlush(t *testing.T) {
+	cwd, _ := os.Getwd()
+	defer func(dir string) {
+		if err := os.Chdir(dir); err != nil {
+			t.Error(err)
+		}
+	}(cwd)
+	if err := os.Chdir("../.."); err != nil {
+		t.Error(err)
+	}
+
+	ctx := context.Background()
+	var votes []int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var review ReviewInput
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			t.Error(err)
+		}
+		votes = append(votes, review.Labels["Verified"])
+		fmt.Fprintf(w, ")]}\n{}")
+	}))
+	defer ts.Close()
+
+	g, err := NewChangeReviewCommenter(NewClient(ts.URL, NoAuth), "testChangeID", "testRevisionID", "",
+		&LabelVote{Label: "Verified", Fail: -1, Pass: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	comment := func(severity rdf.Severity) *reviewdog.Comment {
+		return &reviewdog.Comment{
+			Result: &filter.FilteredDiagnostic{
+				Diagnostic: &rdf.Diagnostic{
+					Location: &rdf.Location{Path: "file.go", Range: &rdf.Range{Start: &rdf.Position{Line: 1}}},
+					Message:  "comment",
+					Severity: severity,
+				},
+				InDiffFile: true,
+			},
+			ToolName: "tool",
+		}
+	}
+	for _, severity := range []rdf.Severity{rdf.Severity_ERROR, rdf.Severity_WARNING} {
+		if err := g.Post(ctx, comment(severity)); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.Flush(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff := cmp.Diff([]int{-1, 1}, votes); diff != "" {
+		t.Errorf("votes have diff:\n%s", diff)
+	}
+}
+
+func TestChangeReviewCommenter_SetTemplate(t *testing.T) {
+	cwd, _ := os.Getwd()
+	defer func(dir string) {
+		if err := os.Chdir(dir); err != nil {
+			t.Error(err)
+		}
+	}(cwd)
+	if err := os.Chdir("../.."); err != nil {
+		t.Error(err)
+	}
+
+	ctx := context.Background()
+	var messages []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var review ReviewInput
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			t.Error(err)
+		}
+		for _, comments := range review.RobotComments {
+			for _, c := range comments {
+				messages = append(messages, c.Message)
+			}
+		}
+		fmt.Fprintf(w, ")]}\n{}")
+	}))
+	defer ts.Close()
+
+	g, err := NewChangeReviewCommenter(NewClient(ts.URL, NoAuth), "testChangeID", "testRevisionID", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := commentutil.NewTemplate("[{{.ToolName}}] {{.Message}} - © Example Corp.", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetTemplate(tmpl)
+	if err := g.Post(ctx, &reviewdog.Comment{
+		Result: &filter.FilteredDiagnostic{
+			Diagnostic: &rdf.Diagnostic{
+				Location: &rdf.Location{Path: "file.go", Range: &rdf.Range{Start: &rdf.Position{Line: 1}}},
+				Message:  "comment",
+			},
+			InDiffFile: true,
+		},
+		ToolName: "tool",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"[tool] comment - © Example Corp."}, messages); diff != "" {
+		t.Errorf("messages have diff:\n%s", diff)
+	}
+}
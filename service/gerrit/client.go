@@ -0,0 +1,189 @@
+package gerrit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Client is a Gerrit REST API client to set reviews with robot comments and
+// label votes, which golang.org/x/build/gerrit.ReviewInput doesn't support.
+type Client struct {
+	url  string // URL prefix, e.g. "https://gerrit-review.googlesource.com" (without trailing slash)
+	auth Auth
+
+	// HTTPClient optionally specifies an HTTP client to use
+	// instead of http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// NewClient returns a new Gerrit client with the given URL prefix and
+// authentication mode. If auth is nil, requests are made unauthenticated.
+func NewClient(url string, auth Auth) *Client {
+	if auth == nil {
+		auth = NoAuth
+	}
+	return &Client{
+		url:  strings.TrimSuffix(url, "/"),
+		auth: auth,
+	}
+}
+
+// ReviewInput represents ReviewInput entity of Gerrit.
+// https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#review-input
+type ReviewInput struct {
+	Message       string                          `json:"message,omitempty"`
+	Tag           string                          `json:"tag,omitempty"`
+	Labels        map[string]int                  `json:"labels,omitempty"`
+	RobotComments map[string][]RobotCommentInput `json:"robot_comments,omitempty"`
+}
+
+// RobotCommentInput represents RobotCommentInput entity of Gerrit.
+// https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#robot-comment-input
+type RobotCommentInput struct {
+	Line           int                 `json:"line,omitempty"`
+	Range          *CommentRange       `json:"range,omitempty"`
+	Message        string              `json:"message"`
+	RobotID        string              `json:"robot_id"`
+	RobotRunID     string              `json:"robot_run_id"`
+	URL            string              `json:"url,omitempty"`
+	FixSuggestions []FixSuggestionInfo `json:"fix_suggestions,omitempty"`
+}
+
+// CommentRange represents CommentRange entity of Gerrit. Lines are 1-based and
+// characters are 0-based. The end character is exclusive.
+// https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#comment-range
+type CommentRange struct {
+	StartLine      int `json:"start_line"`
+	StartCharacter int `json:"start_character"`
+	EndLine        int `json:"end_line"`
+	EndCharacter   int `json:"end_character"`
+}
+
+// FixSuggestionInfo represents FixSuggestionInfo entity of Gerrit.
+// https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#fix-suggestion-info
+type FixSuggestionInfo struct {
+	Description  string               `json:"description"`
+	Replacements []FixReplacementInfo `json:"replacements"`
+}
+
+// FixReplacementInfo represents FixReplacementInfo entity of Gerrit.
+// https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#fix-replacement-info
+type FixReplacementInfo struct {
+	Path        string        `json:"path"`
+	Range       *CommentRange `json:"range"`
+	Replacement string        `json:"replacement"`
+}
+
+// SetReview sets a review on a revision of a change.
+// https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#set-review
+func (c *Client) SetReview(ctx context.Context, changeID, revisionID string, review *ReviewInput) error {
+	b, err := json.Marshal(review)
+	if err != nil {
+		return err
+	}
+	// "/a" prefix is required for authenticated requests.
+	// https://gerrit-review.googlesource.com/Documentation/rest-api.html#authentication
+	slashA := "/a"
+	if _, ok := c.auth.(noAuth); ok {
+		slashA = ""
+	}
+	u := fmt.Sprintf("%s%s/changes/%s/revisions/%s/review", c.url, slashA, changeID, revisionID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := c.auth.setAuth(req); err != nil {
+		return err
+	}
+	res, err := c.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("failed to set review: %s: %s", res.Status, body)
+	}
+	return nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// Auth is a Gerrit authentication mode.
+type Auth interface {
+	setAuth(*http.Request) error
+}
+
+// NoAuth makes requests unauthenticated.
+var NoAuth = Auth(noAuth{})
+
+type noAuth struct{}
+
+func (noAuth) setAuth(*http.Request) error { return nil }
+
+// BasicAuth returns an authentication mode using HTTP basic authentication.
+func BasicAuth(username, password string) Auth {
+	return basicAuth{username: username, password: password}
+}
+
+type basicAuth struct {
+	username, password string
+}
+
+func (a basicAuth) setAuth(r *http.Request) error {
+	r.SetBasicAuth(a.username, a.password)
+	return nil
+}
+
+// GitCookieFileAuth returns an authentication mode using cookies in the given
+// Netscape cookie file (e.g. ~/.gitcookies).
+func GitCookieFileAuth(path string) Auth {
+	return &gitCookieFileAuth{path: path}
+}
+
+type gitCookieFileAuth struct {
+	path string
+}
+
+func (a *gitCookieFileAuth) setAuth(r *http.Request) error {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return fmt.Errorf("failed to read git cookie file: %w", err)
+	}
+	defer f.Close()
+	host := r.URL.Hostname()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimPrefix(s.Text(), "#HttpOnly_")
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		// domain, include subdomains, path, secure, expiration, name, value
+		fs := strings.Split(line, "\t")
+		if len(fs) < 7 {
+			continue
+		}
+		domain := strings.TrimPrefix(fs[0], ".")
+		if host != domain && !strings.HasSuffix(host, "."+domain) {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, fs[2]) {
+			continue
+		}
+		r.AddCookie(&http.Cookie{Name: fs[5], Value: fs[6]})
+	}
+	return s.Err()
+}
@@ -0,0 +1,111 @@
+package gerrit
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClient_SetReview_auth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reviewdog-gerrit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cookieFile := filepath.Join(dir, ".gitcookies")
+	cookies := "# comment\n" +
+		".127.0.0.1\tTRUE\t/\tTRUE\t2147483647\to\tgit-user=secret\n" +
+		"example.com\tFALSE\t/\tTRUE\t2147483647\to\tgit-other=secret\n"
+	if err := ioutil.WriteFile(cookieFile, []byte(cookies), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		auth  Auth
+		path  string
+		check func(t *testing.T, r *http.Request)
+	}{
+		{
+			name: "no auth",
+			auth: NoAuth,
+			path: "/changes/c/revisions/r/review",
+			check: func(t *testing.T, r *http.Request) {
+				if _, _, ok := r.BasicAuth(); ok {
+					t.Error("unexpected basic auth")
+				}
+			},
+		},
+		{
+			name: "basic auth",
+			auth: BasicAuth("user", "pass"),
+			path: "/a/changes/c/revisions/r/review",
+			check: func(t *testing.T, r *http.Request) {
+				if u, p, _ := r.BasicAuth(); u != "user" || p != "pass" {
+					t.Errorf("got basic auth %q:%q, want user:pass", u, p)
+				}
+			},
+		},
+		{
+			name: "git cookie file auth",
+			auth: GitCookieFileAuth(cookieFile),
+			path: "/a/changes/c/revisions/r/review",
+			check: func(t *testing.T, r *http.Request) {
+				cs := r.Cookies()
+				if len(cs) != 1 || cs[0].Name != "o" || cs[0].Value != "git-user=secret" {
+					t.Errorf("got cookies %v, want only o=git-user=secret", cs)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				if r.URL.Path != tt.path {
+					t.Errorf("got path %q, want %q", r.URL.Path, tt.path)
+				}
+				tt.check(t, r)
+				fmt.Fprintf(w, ")]}\n{}")
+			}))
+			defer ts.Close()
+			cli := NewClient(ts.URL, tt.auth)
+			if err := cli.SetReview(context.Background(), "c", "r", &ReviewInput{}); err != nil {
+				t.Fatal(err)
+			}
+			if !called {
+				t.Error("review API is not called")
+			}
+		})
+	}
+}
+
+func TestClient_SetReview_error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "label not permitted", http.StatusForbidden)
+	}))
+	defer ts.Close()
+	cli := NewClient(ts.URL, NoAuth)
+	if err := cli.SetReview(context.Background(), "c", "r", &ReviewInput{}); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestClient_SetReview_gitCookieFileNotFound(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request is sent without the cookie")
+	}))
+	defer ts.Close()
+	cli := NewClient(ts.URL, GitCookieFileAuth("testdata/notfound.gitcookies"))
+	err := cli.SetReview(context.Background(), "c", "r", &ReviewInput{})
+	if err == nil || !strings.Contains(err.Error(), "notfound.gitcookies") {
+		t.Errorf("got error %v, want error about the cookie file", err)
+	}
+}
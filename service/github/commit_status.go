@@ -0,0 +1,54 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v37/github"
+
+	"github.com/reviewdog/reviewdog/service/commitstatus"
+)
+
+var _ commitstatus.Setter = &CommitStatus{}
+
+// CommitStatus sets commit statuses of results per runner.
+//
+// API:
+//	https://docs.github.com/en/rest/reference/repos#create-a-commit-status
+//	POST /repos/:owner/:repo/statuses/:sha
+type CommitStatus struct {
+	cli   *github.Client
+	owner string
+	repo  string
+	sha   string
+}
+
+// NewGitHubCommitStatus returns a new CommitStatus service.
+func NewGitHubCommitStatus(cli *github.Client, owner, repo, sha string) *CommitStatus {
+	return &CommitStatus{cli: cli, owner: owner, repo: repo, sha: sha}
+}
+
+// SetStatus sets the commit status. GitHub doesn't have neutral state, so
+// commitstatus.Neutral is reported as "success" and the description tells
+// the number of results.
+func (g *CommitStatus) SetStatus(ctx context.Context, s *commitstatus.Status) error {
+	status := &github.RepoStatus{
+		State:       github.String(githubState(s.State)),
+		Description: github.String(s.Description),
+		Context:     github.String(commitstatus.Context(s.Name)),
+	}
+	if s.TargetURL != "" {
+		status.TargetURL = github.String(s.TargetURL)
+	}
+	if _, _, err := g.cli.Repositories.CreateStatus(ctx, g.owner, g.repo, g.sha, status); err != nil {
+		return fmt.Errorf("failed to create commit status for %s: %w", s.Name, err)
+	}
+	return nil
+}
+
+func githubState(s commitstatus.State) string {
+	if s == commitstatus.Failure {
+		return "failure"
+	}
+	return "success"
+}
@@ -0,0 +1,69 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v37/github"
+
+	"github.com/reviewdog/reviewdog/service/commitstatus"
+)
+
+func TestCommitStatus_SetStatus(t *testing.T) {
+	tests := []struct {
+		in   *commitstatus.Status
+		want *github.RepoStatus
+	}{
+		{
+			in: &commitstatus.Status{Name: "golint", State: commitstatus.Failure, Description: "3 errors in diff", TargetURL: "https://example.com/job"},
+			want: &github.RepoStatus{
+				State:       github.String("failure"),
+				Description: github.String("3 errors in diff"),
+				Context:     github.String("reviewdog/golint"),
+				TargetURL:   github.String("https://example.com/job"),
+			},
+		},
+		{
+			in: &commitstatus.Status{Name: "misspell", State: commitstatus.Neutral, Description: "1 warning in diff"},
+			want: &github.RepoStatus{
+				State:       github.String("success"),
+				Description: github.String("1 warning in diff"),
+				Context:     github.String("reviewdog/misspell"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		called := 0
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/o/r/statuses/sha", func(w http.ResponseWriter, r *http.Request) {
+			called++
+			if r.Method != http.MethodPost {
+				t.Errorf("unexpected access: %v %v", r.Method, r.URL)
+			}
+			got := new(github.RepoStatus)
+			if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+				t.Error(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("status has diff:\n%s", diff)
+			}
+			w.Write([]byte(`{}`))
+		})
+		ts := httptest.NewServer(mux)
+		cli := github.NewClient(nil)
+		cli.BaseURL, _ = url.Parse(ts.URL + "/")
+		s := NewGitHubCommitStatus(cli, "o", "r", "sha")
+		if err := s.SetStatus(context.Background(), tt.in); err != nil {
+			t.Error(err)
+		}
+		if called != 1 {
+			t.Errorf("create status API called %d times, want 1", called)
+		}
+		ts.Close()
+	}
+}
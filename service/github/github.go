@@ -2,7 +2,6 @@ package github
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -45,6 +44,15 @@ type PullRequest struct {
 
 	postedcs commentutil.PostedComments
 
+	// summary is true if it maintains a summary comment per tool.
+	summary bool
+	// summaryTools are names of tools of the run.
+	summaryTools []string
+
+	tmpl *commentutil.Template
+
+	limiter *reviewdog.CommentLimiter
+
 	// wd is working directory relative to root of repository.
 	wd string
 }
@@ -66,6 +74,30 @@ func NewGitHubPullRequest(cli *github.Client, owner, repo string, pr int, sha st
 	}, nil
 }
 
+// EnableSummary makes PullRequest maintain a summary comment per tool on the
+// conversation tab of the Pull Request. Summary comments show counts by
+// severity, results outside diff, and remaining results which cannot be
+// posted as review comments. They are edited in place on each run. tools are
+// names of tools of the run, and existing summary comments of them without
+// results are updated to zero counts.
+func (g *PullRequest) EnableSummary(tools []string) {
+	g.summary = true
+	g.summaryTools = tools
+}
+
+// SetTemplate sets the template of review comment bodies. It's also used for
+// GitHub Actions log of results outside diff.
+func (g *PullRequest) SetTemplate(t *commentutil.Template) {
+	g.tmpl = t
+}
+
+// SetCommentLimits sets limits of review comments in a run. Comments which
+// exceed them are listed in the review body or summary comments. Only new
+// comments in diff are counted.
+func (g *PullRequest) SetCommentLimits(limits reviewdog.CommentLimits) {
+	g.limiter = reviewdog.NewCommentLimiter(limits)
+}
+
 // Post accepts a comment and holds it. Flush method actually posts comments to
 // GitHub in parallel.
 func (g *PullRequest) Post(_ context.Context, c *reviewdog.Comment) error {
@@ -85,25 +117,53 @@ func (g *PullRequest) Flush(ctx context.Context) error {
 	if err := g.setPostedComment(ctx); err != nil {
 		return err
 	}
-	return g.postAsReviewComment(ctx)
+	remaining, err := g.postAsReviewComment(ctx)
+	if err != nil {
+		return err
+	}
+	if g.summary {
+		return g.postSummaries(ctx, remaining)
+	}
+	return nil
 }
 
-func (g *PullRequest) postAsReviewComment(ctx context.Context) error {
+// postAsReviewComment posts comments as a review and returns remaining
+// comments which are not posted to avoid rate limit or because they exceed
+// limits of comments (i.e. Comment.Overflow).
+func (g *PullRequest) postAsReviewComment(ctx context.Context) ([]*reviewdog.Comment, error) {
 	comments := make([]*github.DraftReviewComment, 0, len(g.postComments))
 	remaining := make([]*reviewdog.Comment, 0)
+	var candidates []*reviewdog.Comment
+	bodies := make(map[*reviewdog.Comment]string)
 	for _, c := range g.postComments {
+		if c.SummaryOnly {
+			// It's reported only in the summary comment.
+			continue
+		}
 		if !c.Result.InDiffContext {
 			// GitHub Review API cannot report results outside diff. If it's running
 			// in GitHub Actions, fallback to GitHub Actions log as report .
 			if cienv.IsInGitHubAction() {
-				githubutils.ReportAsGitHubActionsLog(c.ToolName, "warning", c.Result.Diagnostic)
+				githubutils.ReportCommentAsGitHubActionsLog(c, "warning", g.tmpl)
 			}
 			continue
 		}
-		body := buildBody(c)
-		if g.postedcs.IsPosted(c, githubCommentLine(c), body) {
+		body, defaultBody := g.buildBody(c)
+		// Comments posted before the template is set have the default body.
+		if g.postedcs.IsPosted(c, githubCommentLine(c), body) || g.postedcs.IsPosted(c, githubCommentLine(c), defaultBody) {
+			continue
+		}
+		if c.Overflow {
+			// It exceeds limits of comments.
+			remaining = append(remaining, c)
 			continue
 		}
+		candidates = append(candidates, c)
+		bodies[c] = body
+	}
+	within, overflow := g.limiter.Limit(candidates)
+	remaining = append(remaining, overflow...)
+	for _, c := range within {
 		// Only posts maxCommentsPerRequest comments per 1 request to avoid spammy
 		// review comments. An example GitHub error if we don't limit the # of
 		// review comments.
@@ -116,21 +176,25 @@ func (g *PullRequest) postAsReviewComment(ctx context.Context) error {
 			remaining = append(remaining, c)
 			continue
 		}
-		comments = append(comments, buildDraftReviewComment(c, body))
+		comments = append(comments, buildDraftReviewComment(c, bodies[c]))
 	}
 
-	if len(comments) == 0 {
-		return nil
+	body := ""
+	if !g.summary {
+		// Remaining comments are listed in summary comments otherwise.
+		body = g.remainingCommentsSummary(remaining)
+	}
+	if len(comments) == 0 && body == "" {
+		return remaining, nil
 	}
-
 	review := &github.PullRequestReviewRequest{
 		CommitID: &g.sha,
 		Event:    github.String("COMMENT"),
 		Comments: comments,
-		Body:     github.String(g.remainingCommentsSummary(remaining)),
+		Body:     github.String(body),
 	}
 	_, _, err := g.cli.PullRequests.CreateReview(ctx, g.owner, g.repo, g.pr, review)
-	return err
+	return remaining, err
 }
 
 // Document: https://docs.github.com/en/rest/reference/pulls#create-a-review-comment-for-a-pull-request
@@ -190,7 +254,7 @@ func (g *PullRequest) remainingCommentsSummary(remaining []*reviewdog.Comment) s
 		perTool[c.ToolName] = append(perTool[c.ToolName], c)
 	}
 	var sb strings.Builder
-	sb.WriteString("Remaining comments which cannot be posted as a review comment to avoid GitHub Rate Limit\n")
+	sb.WriteString("Remaining comments which are not posted as a review comment to avoid GitHub Rate Limit or too many comments\n")
 	sb.WriteString("\n")
 	for tool, comments := range perTool {
 		sb.WriteString("<details>\n")
@@ -271,12 +335,15 @@ func listAllPullRequestsComments(ctx context.Context, cli *github.Client,
 	return append(comments, restComments...), nil
 }
 
-func buildBody(c *reviewdog.Comment) string {
-	cbody := commentutil.MarkdownComment(c)
-	if suggestion := buildSuggestions(c); suggestion != "" {
-		cbody += "\n" + suggestion
+// buildBody returns the comment body rendered with the template and the body
+// in the default format.
+func (g *PullRequest) buildBody(c *reviewdog.Comment) (body, defaultBody string) {
+	suggestions := buildSuggestions(c)
+	defaultBody = commentutil.MarkdownComment(c)
+	if suggestions != "" {
+		defaultBody += "\n" + suggestions
 	}
-	return cbody
+	return g.tmpl.RenderMarkdown(c, defaultBody, suggestions), defaultBody
 }
 
 func buildSuggestions(c *reviewdog.Comment) string {
@@ -320,40 +387,13 @@ func buildSingleSuggestion(c *reviewdog.Comment, s *rdf.Suggestion) (string, err
 }
 
 func buildNonLineBasedSuggestion(c *reviewdog.Comment, s *rdf.Suggestion) (string, error) {
-	sourceLines := c.Result.SourceLines
-	if len(sourceLines) == 0 {
-		return "", errors.New("source lines are not available")
-	}
-	start := s.GetRange().GetStart()
-	end := s.GetRange().GetEnd()
-	startLineContent, err := getSourceLine(sourceLines, int(start.GetLine()))
-	if err != nil {
-		return "", err
-	}
-	endLineContent, err := getSourceLine(sourceLines, int(end.GetLine()))
+	txt, err := commentutil.NonLineBasedSuggestionText(c, s)
 	if err != nil {
 		return "", err
 	}
 	var sb strings.Builder
 	sb.WriteString("```suggestion\n")
-	sb.WriteString(startLineContent[:max(start.GetColumn()-1, 0)])
-	sb.WriteString(s.GetText())
-	sb.WriteString(endLineContent[max(end.GetColumn()-1, 0):])
+	sb.WriteString(txt)
 	sb.WriteString("\n```")
 	return sb.String(), nil
 }
-
-func getSourceLine(sourceLines map[int]string, line int) (string, error) {
-	lineContent, ok := sourceLines[line]
-	if !ok {
-		return "", fmt.Errorf("source line (L=%d) is not available for this suggestion", line)
-	}
-	return lineContent, nil
-}
-
-func max(x, y int32) int32 {
-	if x < y {
-		return y
-	}
-	return x
-}
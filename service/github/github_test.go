@@ -1031,6 +1031,381 @@ func TestGitHubPullRequest_Post_toomany(t *testing.T) {
 	}
 }
 
+func TestGitHubPullRequest_Post_overflow(t *testing.T) {
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	moveToRootDir()
+	defer setupEnvs()()
+
+	postCommentsAPICalled := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/pulls/14/comments", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewEncoder(w).Encode([]*github.PullRequestComment{}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	mux.HandleFunc("/repos/o/r/pulls/14/reviews", func(w http.ResponseWriter, r *http.Request) {
+		postCommentsAPICalled++
+		var req github.PullRequestReviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if len(req.Comments) != 0 {
+			t.Errorf("got %d review comments, want 0", len(req.Comments))
+		}
+		if want := "[reviewdog.go|14|](http://github.com/o/r/blob/sha/reviewdog.go#L14) overflow"; !strings.Contains(req.GetBody(), want) {
+			t.Errorf("PullRequestReviewRequest.Body doesn't contain %q:\n%s", want, req.GetBody())
+		}
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cli := github.NewClient(nil)
+	cli.BaseURL, _ = url.Parse(ts.URL + "/")
+	g, err := NewGitHubPullRequest(cli, "o", "r", 14, "sha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &reviewdog.Comment{
+		Result: &filter.FilteredDiagnostic{
+			Diagnostic: &rdf.Diagnostic{
+				Location: &rdf.Location{
+					Path:  "reviewdog.go",
+					Range: &rdf.Range{Start: &rdf.Position{Line: 14}},
+				},
+				Message: "overflow",
+			},
+			InDiffContext: true,
+		},
+		ToolName: "tool",
+		Overflow: true,
+	}
+	if err := g.Post(context.Background(), c); err != nil {
+		t.Error(err)
+	}
+	if err := g.Flush(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if postCommentsAPICalled != 1 {
+		t.Errorf("GitHub post PullRequest comments API called %v times, want 1 times", postCommentsAPICalled)
+	}
+}
+
+func TestGitHubPullRequest_Post_commentLimits(t *testing.T) {
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	moveToRootDir()
+	defer setupEnvs()()
+
+	newComment := func(line int32, msg string, inDiff bool) *reviewdog.Comment {
+		return &reviewdog.Comment{
+			Result: &filter.FilteredDiagnostic{
+				Diagnostic: &rdf.Diagnostic{
+					Location: &rdf.Location{
+						Path:  "reviewdog.go",
+						Range: &rdf.Range{Start: &rdf.Position{Line: line}},
+					},
+					Message: msg,
+				},
+				InDiffContext: inDiff,
+			},
+			ToolName: "tool",
+		}
+	}
+	posted := newComment(1, "posted by the previous run", true)
+
+	var gotComments []string
+	var gotBody string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/pulls/14/comments", func(w http.ResponseWriter, r *http.Request) {
+		cs := []*github.PullRequestComment{{
+			Path: github.String("reviewdog.go"),
+			Line: github.Int(1),
+			Body: github.String(commentutil.MarkdownComment(posted)),
+		}}
+		if err := json.NewEncoder(w).Encode(cs); err != nil {
+			t.Fatal(err)
+		}
+	})
+	mux.HandleFunc("/repos/o/r/pulls/14/reviews", func(w http.ResponseWriter, r *http.Request) {
+		var req github.PullRequestReviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		for _, c := range req.Comments {
+			gotComments = append(gotComments, c.GetBody())
+		}
+		gotBody = req.GetBody()
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cli := github.NewClient(nil)
+	cli.BaseURL, _ = url.Parse(ts.URL + "/")
+	g, err := NewGitHubPullRequest(cli, "o", "r", 14, "sha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetCommentLimits(reviewdog.CommentLimits{MaxComments: 1})
+	// Comments are posted in the reverse order of lines, as runners may finish
+	// in any order.
+	for _, c := range []*reviewdog.Comment{
+		newComment(3, "second new comment", true),
+		newComment(2, "first new comment", true),
+		newComment(20, "outside diff", false),
+		posted,
+	} {
+		if err := g.Post(context.Background(), c); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := g.Flush(context.Background()); err != nil {
+		t.Error(err)
+	}
+	// The posted comment and the comment outside diff are not counted.
+	if len(gotComments) != 1 || !strings.Contains(gotComments[0], "first new comment") {
+		t.Errorf("got review comments %q, want only the first new comment", gotComments)
+	}
+	if want := "[reviewdog.go|3|](http://github.com/o/r/blob/sha/reviewdog.go#L3) second new comment"; !strings.Contains(gotBody, want) {
+		t.Errorf("PullRequestReviewRequest.Body doesn't contain %q:\n%s", want, gotBody)
+	}
+}
+
+func TestGitHubPullRequest_Post_template(t *testing.T) {
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	moveToRootDir()
+	defer setupEnvs()()
+
+	newComment := func(message string) *reviewdog.Comment {
+		return &reviewdog.Comment{
+			Result: &filter.FilteredDiagnostic{
+				Diagnostic: &rdf.Diagnostic{
+					Location: &rdf.Location{
+						Path:  "reviewdog.go",
+						Range: &rdf.Range{Start: &rdf.Position{Line: 14}},
+					},
+					Message: message,
+				},
+				InDiffContext: true,
+			},
+			ToolName: "tool",
+		}
+	}
+	// posted before the template is set.
+	posted := newComment("posted")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/pulls/14/comments", func(w http.ResponseWriter, r *http.Request) {
+		cs := []*github.PullRequestComment{
+			{
+				Path: github.String("reviewdog.go"),
+				Line: github.Int(14),
+				Body: github.String(commentutil.MarkdownComment(posted)),
+			},
+		}
+		if err := json.NewEncoder(w).Encode(cs); err != nil {
+			t.Fatal(err)
+		}
+	})
+	postCommentsAPICalled := 0
+	mux.HandleFunc("/repos/o/r/pulls/14/reviews", func(w http.ResponseWriter, r *http.Request) {
+		postCommentsAPICalled++
+		var req github.PullRequestReviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if len(req.Comments) != 1 {
+			t.Fatalf("got %d review comments, want 1", len(req.Comments))
+		}
+		if want := "[tool] new\nLegal footer\n<!-- reviewdog:fingerprint:"; !strings.HasPrefix(req.Comments[0].GetBody(), want) {
+			t.Errorf("review comment body = %q, want prefix %q", req.Comments[0].GetBody(), want)
+		}
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cli := github.NewClient(nil)
+	cli.BaseURL, _ = url.Parse(ts.URL + "/")
+	g, err := NewGitHubPullRequest(cli, "o", "r", 14, "sha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := commentutil.NewTemplate("[{{.ToolName}}] {{.Message}}\nLegal footer", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetTemplate(tmpl)
+	for _, c := range []*reviewdog.Comment{posted, newComment("new")} {
+		if err := g.Post(context.Background(), c); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := g.Flush(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if postCommentsAPICalled != 1 {
+		t.Errorf("GitHub post PullRequest comments API called %v times, want 1 times", postCommentsAPICalled)
+	}
+}
+
+func TestGitHubPullRequest_Post_Flush_summary(t *testing.T) {
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	moveToRootDir()
+	defer setupEnvs()()
+
+	editSummaryAPICalled := 0
+	createSummaryAPICalled := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/pulls/14/comments", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewEncoder(w).Encode([]*github.PullRequestComment{}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	mux.HandleFunc("/repos/o/r/pulls/14/reviews", func(w http.ResponseWriter, r *http.Request) {
+		var req github.PullRequestReviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if len(req.Comments) != maxCommentsPerRequest {
+			t.Errorf("got %d review comments, want %d", len(req.Comments), maxCommentsPerRequest)
+		}
+		if req.GetBody() != "" {
+			t.Errorf("PullRequestReviewRequest.Body = %q, want empty as remaining comments are in summary", req.GetBody())
+		}
+	})
+	mux.HandleFunc("/repos/o/r/issues/14/comments", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			cs := []*github.IssueComment{
+				{ID: github.Int64(1), Body: github.String("unrelated comment")},
+				{ID: github.Int64(2), Body: github.String(commentutil.SummaryMarker("tool") + "\nold summary")},
+			}
+			if err := json.NewEncoder(w).Encode(cs); err != nil {
+				t.Fatal(err)
+			}
+		case http.MethodPost:
+			createSummaryAPICalled++
+			var req github.IssueComment
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Error(err)
+			}
+			if !commentutil.IsSummaryComment(req.GetBody(), "tool2") {
+				t.Errorf("unexpected summary comment: %s", req.GetBody())
+			}
+		default:
+			t.Errorf("unexpected access: %v %v", r.Method, r.URL)
+		}
+	})
+	mux.HandleFunc("/repos/o/r/issues/comments/2", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			t.Errorf("unexpected access: %v %v", r.Method, r.URL)
+		}
+		editSummaryAPICalled++
+		var req github.IssueComment
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		body := req.GetBody()
+		if !commentutil.IsSummaryComment(body, "tool") {
+			t.Errorf("unexpected summary comment: %s", body)
+		}
+		for _, want := range []string{
+			"| 🚫 Error | 36 |",
+			"<summary>Findings outside the diff (1)</summary>",
+			"<summary>Remaining findings which were not posted as inline comments (5)</summary>",
+			"<summary>Findings reported only in this summary (1)</summary>",
+			"- [reviewdog.go|100|](http://github.com/o/r/blob/sha/reviewdog.go#L100) outside diff",
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("summary comment doesn't contain %q:\n%s", want, body)
+			}
+		}
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cli := github.NewClient(nil)
+	cli.BaseURL, _ = url.Parse(ts.URL + "/")
+	g, err := NewGitHubPullRequest(cli, "o", "r", 14, "sha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.EnableSummary(nil)
+	var comments []*reviewdog.Comment
+	for i := 1; i <= maxCommentsPerRequest+5; i++ {
+		comments = append(comments, &reviewdog.Comment{
+			Result: &filter.FilteredDiagnostic{
+				Diagnostic: &rdf.Diagnostic{
+					Location: &rdf.Location{
+						Path:  "reviewdog.go",
+						Range: &rdf.Range{Start: &rdf.Position{Line: int32(i)}},
+					},
+					Message:  "comment",
+					Severity: rdf.Severity_ERROR,
+				},
+				InDiffContext: true,
+			},
+			ToolName: "tool",
+		})
+	}
+	comments = append(comments, &reviewdog.Comment{
+		Result: &filter.FilteredDiagnostic{
+			Diagnostic: &rdf.Diagnostic{
+				Location: &rdf.Location{
+					Path:  "reviewdog.go",
+					Range: &rdf.Range{Start: &rdf.Position{Line: 100}},
+				},
+				Message:  "outside diff",
+				Severity: rdf.Severity_ERROR,
+			},
+		},
+		ToolName: "tool",
+	}, &reviewdog.Comment{
+		Result: &filter.FilteredDiagnostic{
+			Diagnostic: &rdf.Diagnostic{
+				Location: &rdf.Location{
+					Path:  "reviewdog.go",
+					Range: &rdf.Range{Start: &rdf.Position{Line: 101}},
+				},
+				Message: "outside diff 2",
+			},
+		},
+		ToolName: "tool2",
+	}, &reviewdog.Comment{
+		Result: &filter.FilteredDiagnostic{
+			Diagnostic: &rdf.Diagnostic{
+				Location: &rdf.Location{
+					Path:  "reviewdog.go",
+					Range: &rdf.Range{Start: &rdf.Position{Line: 1}},
+				},
+				Message:  "info",
+				Severity: rdf.Severity_INFO,
+			},
+			InDiffContext: true,
+		},
+		ToolName:    "tool",
+		SummaryOnly: true,
+	})
+	for _, c := range comments {
+		if err := g.Post(context.Background(), c); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := g.Flush(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if editSummaryAPICalled != 1 {
+		t.Errorf("GitHub edit issue comment API called %v times, want 1 times", editSummaryAPICalled)
+	}
+	if createSummaryAPICalled != 1 {
+		t.Errorf("GitHub create issue comment API called %v times, want 1 times", createSummaryAPICalled)
+	}
+}
+
 func TestGitHubPullRequest_workdir(t *testing.T) {
 	cwd, _ := os.Getwd()
 	defer os.Chdir(cwd)
@@ -9,6 +9,7 @@ import (
 
 	"github.com/reviewdog/reviewdog"
 	"github.com/reviewdog/reviewdog/proto/rdf"
+	"github.com/reviewdog/reviewdog/service/commentutil"
 )
 
 const MaxLoggingAnnotationsPerStep = 10
@@ -21,6 +22,9 @@ var _ reviewdog.CommentService = &GitHubActionLogWriter{}
 type GitHubActionLogWriter struct {
 	level     string
 	reportNum int
+	// number of comments which exceed limits of comments.
+	overflowNum int
+	tmpl        *commentutil.Template
 }
 
 // NewGitHubActionLogWriter returns new GitHubActionLogWriter.
@@ -28,17 +32,30 @@ func NewGitHubActionLogWriter(level string) *GitHubActionLogWriter {
 	return &GitHubActionLogWriter{level: level}
 }
 
+// SetTemplate sets the template of annotation messages.
+func (lw *GitHubActionLogWriter) SetTemplate(t *commentutil.Template) {
+	lw.tmpl = t
+}
+
 func (lw *GitHubActionLogWriter) Post(_ context.Context, c *reviewdog.Comment) error {
+	if c.Overflow {
+		lw.overflowNum++
+		return nil
+	}
 	lw.reportNum++
 	if lw.reportNum == MaxLoggingAnnotationsPerStep {
 		WarnTooManyAnnotationOnce()
 	}
-	ReportAsGitHubActionsLog(c.ToolName, lw.level, c.Result.Diagnostic)
+	ReportCommentAsGitHubActionsLog(c, lw.level, lw.tmpl)
 	return nil
 }
 
-// Flush checks overall error at last.
+// Flush checks overall error at last. It also reports the number of comments
+// which exceed limits of comments.
 func (lw *GitHubActionLogWriter) Flush(_ context.Context) error {
+	if lw.overflowNum > 0 {
+		core.Warning(fmt.Sprintf("reviewdog: %d results are not reported as annotations because they exceed limits of comments.", lw.overflowNum), nil)
+	}
 	if lw.reportNum > 9 {
 		return fmt.Errorf("GitHubActionLogWriter: reported too many annotation (N=%d)", lw.reportNum)
 	}
@@ -49,8 +66,23 @@ func (lw *GitHubActionLogWriter) Flush(_ context.Context) error {
 // annotations.
 // https://help.github.com/en/actions/automating-your-workflow-with-github-actions/development-tools-for-github-actions#example-5
 func ReportAsGitHubActionsLog(toolName, defaultLevel string, d *rdf.Diagnostic) {
-	mes := fmt.Sprintf("[%s] reported by reviewdog 🐶\n%s\n\nRaw Output:\n%s",
+	reportAsGitHubActionsLog(defaultLevel, d, actionsLogMessage(toolName, d))
+}
+
+// ReportCommentAsGitHubActionsLog is same as ReportAsGitHubActionsLog but
+// renders the message of the comment with the template t if any.
+func ReportCommentAsGitHubActionsLog(c *reviewdog.Comment, defaultLevel string, t *commentutil.Template) {
+	d := c.Result.Diagnostic
+	mes, _ := t.Render(c, actionsLogMessage(c.ToolName, d), "")
+	reportAsGitHubActionsLog(defaultLevel, d, mes)
+}
+
+func actionsLogMessage(toolName string, d *rdf.Diagnostic) string {
+	return fmt.Sprintf("[%s] reported by reviewdog 🐶\n%s\n\nRaw Output:\n%s",
 		toolName, d.GetMessage(), d.GetOriginalOutput())
+}
+
+func reportAsGitHubActionsLog(defaultLevel string, d *rdf.Diagnostic, mes string) {
 	loc := d.GetLocation()
 	start := loc.GetRange().GetStart()
 	opt := &core.LogOption{
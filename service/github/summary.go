@@ -0,0 +1,89 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v37/github"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+	"github.com/reviewdog/reviewdog/service/commentutil"
+	"github.com/reviewdog/reviewdog/service/github/githubutils"
+)
+
+// postSummaries creates or updates a summary issue comment per tool.
+// Document: https://docs.github.com/en/rest/reference/issues#comments
+func (g *PullRequest) postSummaries(ctx context.Context, remaining []*reviewdog.Comment) error {
+	summaries := commentutil.BuildSummaries(g.postComments, func(c *reviewdog.Comment) bool {
+		return !c.Result.InDiffContext
+	}, remaining)
+	empty := commentutil.EmptySummaries(summaries, g.summaryTools)
+	if len(summaries) == 0 && len(empty) == 0 {
+		return nil
+	}
+	opts := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	comments, err := listAllIssueComments(ctx, g.cli, g.owner, g.repo, g.pr, opts)
+	if err != nil {
+		return fmt.Errorf("failed to list issue comments: %w", err)
+	}
+	linked := func(d *rdf.Diagnostic) string {
+		return githubutils.LinkedMarkdownDiagnostic(g.owner, g.repo, g.sha, d)
+	}
+	for _, s := range append(summaries, empty...) {
+		body := g.tmpl.SummaryMarkdown(s, linked)
+		existing := findSummaryComment(comments, s.ToolName)
+		if existing == nil && len(s.Comments) == 0 && len(s.Overflow) == 0 {
+			// Don't create summary comments of tools without results.
+			continue
+		}
+		if existing == nil {
+			if _, _, err := g.cli.Issues.CreateComment(ctx, g.owner, g.repo, g.pr, &github.IssueComment{Body: github.String(body)}); err != nil {
+				return fmt.Errorf("failed to create summary comment: %w", err)
+			}
+			continue
+		}
+		if existing.GetBody() == body {
+			continue
+		}
+		if _, _, err := g.cli.Issues.EditComment(ctx, g.owner, g.repo, existing.GetID(), &github.IssueComment{Body: github.String(body)}); err != nil {
+			return fmt.Errorf("failed to update summary comment: %w", err)
+		}
+	}
+	return nil
+}
+
+func findSummaryComment(comments []*github.IssueComment, toolName string) *github.IssueComment {
+	for _, c := range comments {
+		if commentutil.IsSummaryComment(c.GetBody(), toolName) {
+			return c
+		}
+	}
+	return nil
+}
+
+func listAllIssueComments(ctx context.Context, cli *github.Client,
+	owner, repo string, pr int, opts *github.IssueListCommentsOptions) ([]*github.IssueComment, error) {
+	comments, resp, err := cli.Issues.ListComments(ctx, owner, repo, pr, opts)
+	if err != nil {
+		return nil, err
+	}
+	if resp.NextPage == 0 {
+		return comments, nil
+	}
+	newOpts := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{
+			Page:    resp.NextPage,
+			PerPage: opts.PerPage,
+		},
+	}
+	restComments, err := listAllIssueComments(ctx, cli, owner, repo, pr, newOpts)
+	if err != nil {
+		return nil, err
+	}
+	return append(comments, restComments...), nil
+}
@@ -0,0 +1,53 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/reviewdog/reviewdog/service/commitstatus"
+)
+
+var _ commitstatus.Setter = &CommitStatus{}
+
+// CommitStatus sets commit statuses of results per runner.
+//
+// API:
+//  https://docs.gitlab.com/ee/api/commits.html#post-the-build-status-to-a-commit
+//  POST /projects/:id/statuses/:sha
+type CommitStatus struct {
+	cli      *gitlab.Client
+	sha      string
+	projects string
+}
+
+// NewGitLabCommitStatus returns a new CommitStatus service.
+func NewGitLabCommitStatus(cli *gitlab.Client, owner, repo, sha string) *CommitStatus {
+	return &CommitStatus{cli: cli, sha: sha, projects: owner + "/" + repo}
+}
+
+// SetStatus sets the commit status. GitLab doesn't have neutral state, so
+// commitstatus.Neutral is reported as "success" and the description tells
+// the number of results.
+func (g *CommitStatus) SetStatus(ctx context.Context, s *commitstatus.Status) error {
+	opt := &gitlab.SetCommitStatusOptions{
+		State:       gitlabState(s.State),
+		Name:        gitlab.String(commitstatus.Context(s.Name)),
+		Description: gitlab.String(s.Description),
+	}
+	if s.TargetURL != "" {
+		opt.TargetURL = gitlab.String(s.TargetURL)
+	}
+	if _, _, err := g.cli.Commits.SetCommitStatus(g.projects, g.sha, opt, gitlab.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to set commit status for %s: %w", s.Name, err)
+	}
+	return nil
+}
+
+func gitlabState(s commitstatus.State) gitlab.BuildStateValue {
+	if s == commitstatus.Failure {
+		return gitlab.Failed
+	}
+	return gitlab.Success
+}
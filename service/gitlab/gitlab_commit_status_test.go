@@ -0,0 +1,70 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/reviewdog/reviewdog/service/commitstatus"
+)
+
+func TestCommitStatus_SetStatus(t *testing.T) {
+	tests := []struct {
+		in   *commitstatus.Status
+		want *gitlab.SetCommitStatusOptions
+	}{
+		{
+			in: &commitstatus.Status{Name: "golint", State: commitstatus.Failure, Description: "3 errors in diff", TargetURL: "https://example.com/job"},
+			want: &gitlab.SetCommitStatusOptions{
+				State:       gitlab.Failed,
+				Name:        gitlab.String("reviewdog/golint"),
+				Description: gitlab.String("3 errors in diff"),
+				TargetURL:   gitlab.String("https://example.com/job"),
+			},
+		},
+		{
+			in: &commitstatus.Status{Name: "misspell", State: commitstatus.Success, Description: "No results in diff"},
+			want: &gitlab.SetCommitStatusOptions{
+				State:       gitlab.Success,
+				Name:        gitlab.String("reviewdog/misspell"),
+				Description: gitlab.String("No results in diff"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		called := 0
+		mux := http.NewServeMux()
+		mux.HandleFunc("/api/v4/projects/o/r/statuses/sha", func(w http.ResponseWriter, r *http.Request) {
+			called++
+			if r.Method != http.MethodPost {
+				t.Errorf("unexpected access: %v %v", r.Method, r.URL)
+			}
+			got := new(gitlab.SetCommitStatusOptions)
+			if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+				t.Error(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("status has diff:\n%s", diff)
+			}
+			w.Write([]byte(`{}`))
+		})
+		ts := httptest.NewServer(mux)
+		cli, err := gitlab.NewClient("", gitlab.WithBaseURL(ts.URL+"/api/v4"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := NewGitLabCommitStatus(cli, "o", "r", "sha")
+		if err := s.SetStatus(context.Background(), tt.in); err != nil {
+			t.Error(err)
+		}
+		if called != 1 {
+			t.Errorf("set status API called %d times, want 1", called)
+		}
+		ts.Close()
+	}
+}
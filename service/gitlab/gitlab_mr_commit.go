@@ -32,6 +32,15 @@ type MergeRequestCommitCommenter struct {
 	muComments   sync.Mutex
 	postComments []*reviewdog.Comment
 
+	// summary is true if it maintains a summary note per tool.
+	summary bool
+	// summaryTools are names of tools of the run.
+	summaryTools []string
+
+	tmpl *commentutil.Template
+
+	limiter *reviewdog.CommentLimiter
+
 	postedcs commentutil.PostedComments
 
 	// wd is working directory relative to root of repository.
@@ -54,6 +63,27 @@ func NewGitLabMergeRequestCommitCommenter(cli *gitlab.Client, owner, repo string
 	}, nil
 }
 
+// EnableSummary makes MergeRequestCommitCommenter maintain a summary note per
+// tool on the MergeRequest. Summary notes show counts by severity and results
+// outside diff. They are edited in place on each run. tools are names of
+// tools of the run, and existing summary notes of them without results are
+// updated to zero counts.
+func (g *MergeRequestCommitCommenter) EnableSummary(tools []string) {
+	g.summary = true
+	g.summaryTools = tools
+}
+
+// SetTemplate sets the template of comment bodies.
+func (g *MergeRequestCommitCommenter) SetTemplate(t *commentutil.Template) {
+	g.tmpl = t
+}
+
+// SetCommentLimits sets limits of comments in a run. Comments which exceed them
+// are listed in summary notes. Only new comments in diff files are counted.
+func (g *MergeRequestCommitCommenter) SetCommentLimits(limits reviewdog.CommentLimits) {
+	g.limiter = reviewdog.NewCommentLimiter(limits)
+}
+
 // Post accepts a comment and holds it. Flush method actually posts comments to
 // GitLab in parallel.
 func (g *MergeRequestCommitCommenter) Post(_ context.Context, c *reviewdog.Comment) error {
@@ -74,19 +104,44 @@ func (g *MergeRequestCommitCommenter) Flush(ctx context.Context) error {
 		return err
 	}
 
-	return g.postCommentsForEach(ctx)
+	overflow, err := g.postCommentsForEach(ctx)
+	if err != nil {
+		return err
+	}
+	// Comments which exceed limits of comments are listed in summary notes
+	// even if summary notes are not enabled.
+	return postSummaryNotes(ctx, g.cli, g.projects, g.pr, g.postComments, overflow, g.summaryTools, g.tmpl, !g.summary)
 }
 
-func (g *MergeRequestCommitCommenter) postCommentsForEach(ctx context.Context) error {
-	var eg errgroup.Group
+// postCommentsForEach posts comments which have not been posted yet, and
+// returns comments which are not posted as they exceed limits of comments.
+func (g *MergeRequestCommitCommenter) postCommentsForEach(ctx context.Context) ([]*reviewdog.Comment, error) {
+	var candidates, overflow []*reviewdog.Comment
+	bodies := make(map[*reviewdog.Comment]string)
 	for _, c := range g.postComments {
-		c := c
-		loc := c.Result.Diagnostic.GetLocation()
-		lnum := int(loc.GetRange().GetStart().GetLine())
-		body := commentutil.MarkdownComment(c)
-		if !c.Result.InDiffFile || lnum == 0 || g.postedcs.IsPosted(c, lnum, body) {
+		lnum := int(c.Result.Diagnostic.GetLocation().GetRange().GetStart().GetLine())
+		defaultBody := commentutil.MarkdownComment(c)
+		body := g.tmpl.RenderMarkdown(c, defaultBody, "")
+		// Comments posted before the template is set have the default body.
+		if c.SummaryOnly || !c.Result.InDiffFile || lnum == 0 ||
+			g.postedcs.IsPosted(c, lnum, body) || g.postedcs.IsPosted(c, lnum, defaultBody) {
+			continue
+		}
+		if c.Overflow {
+			overflow = append(overflow, c)
 			continue
 		}
+		candidates = append(candidates, c)
+		bodies[c] = body
+	}
+	within, over := g.limiter.Limit(candidates)
+	overflow = append(overflow, over...)
+
+	var eg errgroup.Group
+	for _, c := range within {
+		loc := c.Result.Diagnostic.GetLocation()
+		lnum := int(loc.GetRange().GetStart().GetLine())
+		body := bodies[c]
 		eg.Go(func() error {
 			commitID, err := g.getLastCommitsID(loc.GetPath(), lnum)
 			if err != nil {
@@ -102,7 +157,7 @@ func (g *MergeRequestCommitCommenter) postCommentsForEach(ctx context.Context) e
 			return err
 		})
 	}
-	return eg.Wait()
+	return overflow, eg.Wait()
 }
 
 func (g *MergeRequestCommitCommenter) getLastCommitsID(path string, line int) (string, error) {
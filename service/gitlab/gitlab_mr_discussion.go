@@ -4,16 +4,23 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/xanzy/go-gitlab"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/proto/rdf"
 	"github.com/reviewdog/reviewdog/service/commentutil"
 	"github.com/reviewdog/reviewdog/service/serviceutil"
 )
 
+const (
+	invalidSuggestionPre  = "<details><summary>reviewdog suggestion error</summary>"
+	invalidSuggestionPost = "</details>"
+)
+
 // MergeRequestDiscussionCommenter is a comment and diff service for GitLab MergeRequest.
 //
 // API:
@@ -28,6 +35,15 @@ type MergeRequestDiscussionCommenter struct {
 	muComments   sync.Mutex
 	postComments []*reviewdog.Comment
 
+	// summary is true if it maintains a summary note per tool.
+	summary bool
+	// summaryTools are names of tools of the run.
+	summaryTools []string
+
+	tmpl *commentutil.Template
+
+	limiter *reviewdog.CommentLimiter
+
 	// wd is working directory relative to root of repository.
 	wd string
 }
@@ -48,6 +64,27 @@ func NewGitLabMergeRequestDiscussionCommenter(cli *gitlab.Client, owner, repo st
 	}, nil
 }
 
+// EnableSummary makes MergeRequestDiscussionCommenter maintain a summary note per tool
+// on the MergeRequest. Summary notes show counts by severity and results
+// outside diff. They are edited in place on each run. tools are names of
+// tools of the run, and existing summary notes of them without results are
+// updated to zero counts.
+func (g *MergeRequestDiscussionCommenter) EnableSummary(tools []string) {
+	g.summary = true
+	g.summaryTools = tools
+}
+
+// SetTemplate sets the template of comment bodies.
+func (g *MergeRequestDiscussionCommenter) SetTemplate(t *commentutil.Template) {
+	g.tmpl = t
+}
+
+// SetCommentLimits sets limits of comments in a run. Comments which exceed them
+// are listed in summary notes. Only new comments in diff files are counted.
+func (g *MergeRequestDiscussionCommenter) SetCommentLimits(limits reviewdog.CommentLimits) {
+	g.limiter = reviewdog.NewCommentLimiter(limits)
+}
+
 // Post accepts a comment and holds it. Flush method actually posts comments to
 // GitLab in parallel.
 func (g *MergeRequestDiscussionCommenter) Post(_ context.Context, c *reviewdog.Comment) error {
@@ -67,7 +104,13 @@ func (g *MergeRequestDiscussionCommenter) Flush(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to create posted comments: %w", err)
 	}
-	return g.postCommentsForEach(ctx, postedcs)
+	overflow, err := g.postCommentsForEach(ctx, postedcs)
+	if err != nil {
+		return err
+	}
+	// Comments which exceed limits of comments are listed in summary notes
+	// even if summary notes are not enabled.
+	return postSummaryNotes(ctx, g.cli, g.projects, g.pr, g.postComments, overflow, g.summaryTools, g.tmpl, !g.summary)
 }
 
 func (g *MergeRequestDiscussionCommenter) createPostedComments() (commentutil.PostedComments, error) {
@@ -88,25 +131,44 @@ func (g *MergeRequestDiscussionCommenter) createPostedComments() (commentutil.Po
 	return postedcs, nil
 }
 
-func (g *MergeRequestDiscussionCommenter) postCommentsForEach(ctx context.Context, postedcs commentutil.PostedComments) error {
+// postCommentsForEach posts comments which have not been posted yet, and
+// returns comments which are not posted as they exceed limits of comments.
+func (g *MergeRequestDiscussionCommenter) postCommentsForEach(ctx context.Context, postedcs commentutil.PostedComments) ([]*reviewdog.Comment, error) {
 	mr, _, err := g.cli.MergeRequests.GetMergeRequest(g.projects, g.pr, nil, gitlab.WithContext(ctx))
 	if err != nil {
-		return fmt.Errorf("failed to get merge request: %w", err)
+		return nil, fmt.Errorf("failed to get merge request: %w", err)
 	}
 	targetBranch, _, err := g.cli.Branches.GetBranch(mr.TargetProjectID, mr.TargetBranch, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var eg errgroup.Group
+	var candidates, overflow []*reviewdog.Comment
+	bodies := make(map[*reviewdog.Comment]string)
 	for _, c := range g.postComments {
+		lnum := int(c.Result.Diagnostic.GetLocation().GetRange().GetStart().GetLine())
+		body, defaultBody := buildBody(c, g.tmpl)
+		// Comments posted before the template is set have the default body.
+		if c.SummaryOnly || !c.Result.InDiffFile || lnum == 0 ||
+			postedcs.IsPosted(c, lnum, body) || postedcs.IsPosted(c, lnum, defaultBody) {
+			continue
+		}
+		if c.Overflow {
+			overflow = append(overflow, c)
+			continue
+		}
+		candidates = append(candidates, c)
+		bodies[c] = body
+	}
+	within, over := g.limiter.Limit(candidates)
+	overflow = append(overflow, over...)
+
+	var eg errgroup.Group
+	for _, c := range within {
 		c := c
 		loc := c.Result.Diagnostic.GetLocation()
 		lnum := int(loc.GetRange().GetStart().GetLine())
-		body := commentutil.MarkdownComment(c)
-		if !c.Result.InDiffFile || lnum == 0 || postedcs.IsPosted(c, lnum, body) {
-			continue
-		}
+		body := bodies[c]
 		eg.Go(func() error {
 			pos := &gitlab.NotePosition{
 				StartSHA:     targetBranch.Commit.ID,
@@ -124,14 +186,73 @@ func (g *MergeRequestDiscussionCommenter) postCommentsForEach(ctx context.Contex
 				Body:     gitlab.String(body),
 				Position: pos,
 			}
-			_, _, err := g.cli.Discussions.CreateMergeRequestDiscussion(g.projects, g.pr, discussion)
+			_, _, err := g.cli.Discussions.CreateMergeRequestDiscussion(g.projects, g.pr, discussion, gitlab.WithContext(ctx))
 			if err != nil {
 				return fmt.Errorf("failed to create merge request discussion: %w", err)
 			}
 			return nil
 		})
 	}
-	return eg.Wait()
+	return overflow, eg.Wait()
+}
+
+// buildBody returns the comment body rendered with the template t and the
+// body in the default format.
+func buildBody(c *reviewdog.Comment, t *commentutil.Template) (body, defaultBody string) {
+	suggestions := buildSuggestions(c)
+	defaultBody = commentutil.MarkdownComment(c)
+	if suggestions != "" {
+		defaultBody += "\n" + suggestions
+	}
+	return t.RenderMarkdown(c, defaultBody, suggestions), defaultBody
+}
+
+func buildSuggestions(c *reviewdog.Comment) string {
+	var sb strings.Builder
+	for _, s := range c.Result.Diagnostic.GetSuggestions() {
+		txt, err := buildSingleSuggestion(c, s)
+		if err != nil {
+			sb.WriteString(invalidSuggestionPre + err.Error() + invalidSuggestionPost + "\n")
+			continue
+		}
+		sb.WriteString(txt)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// buildSingleSuggestion builds a suggestion block for the given suggestion.
+// GitLab anchors a discussion at a single line and suggestion blocks select
+// lines relative to it with `suggestion:-N+M`.
+// Document: https://docs.gitlab.com/ee/user/project/merge_requests/reviews/suggestions.html
+func buildSingleSuggestion(c *reviewdog.Comment, s *rdf.Suggestion) (string, error) {
+	start := s.GetRange().GetStart()
+	startLine := int(start.GetLine())
+	end := s.GetRange().GetEnd()
+	endLine := int(end.GetLine())
+	if endLine == 0 {
+		endLine = startLine
+	}
+	lnum := int(c.Result.Diagnostic.GetLocation().GetRange().GetStart().GetLine())
+	if startLine > lnum || endLine < lnum {
+		return "", fmt.Errorf("GitLab comment line must be in suggestion line range. L%d v.s. L%d-L%d",
+			lnum, startLine, endLine)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("```suggestion:-%d+%d\n", lnum-startLine, endLine-lnum))
+	if start.GetColumn() > 0 || end.GetColumn() > 0 {
+		txt, err := commentutil.NonLineBasedSuggestionText(c, s)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(txt)
+		sb.WriteString("\n")
+	} else if txt := s.GetText(); txt != "" {
+		sb.WriteString(txt)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("```")
+	return sb.String(), nil
 }
 
 func listAllMergeRequestDiscussion(cli *gitlab.Client, projectID string, mergeRequest int, opts *gitlab.ListMergeRequestDiscussionsOptions) ([]*gitlab.Discussion, error) {
@@ -267,3 +267,162 @@ func TestGitLabMergeRequestDiscussionCommenter_Post_Flush_review_api(t *testing.
 		t.Errorf("%d discussions posted, but want %d", postCalled, wantPostCalled)
 	}
 }
+
+func TestBuildSuggestions(t *testing.T) {
+	tests := []struct {
+		name    string
+		comment *reviewdog.Comment
+		want    string
+	}{
+		{
+			name: "no suggestion",
+			comment: &reviewdog.Comment{
+				Result: &filter.FilteredDiagnostic{
+					Diagnostic: &rdf.Diagnostic{
+						Location: &rdf.Location{Path: "file.go", Range: &rdf.Range{Start: &rdf.Position{Line: 14}}},
+					},
+				},
+			},
+			want: "",
+		},
+		{
+			name: "single line",
+			comment: &reviewdog.Comment{
+				Result: &filter.FilteredDiagnostic{
+					Diagnostic: &rdf.Diagnostic{
+						Location: &rdf.Location{Path: "file.go", Range: &rdf.Range{Start: &rdf.Position{Line: 14}}},
+						Suggestions: []*rdf.Suggestion{
+							{
+								Range: &rdf.Range{Start: &rdf.Position{Line: 14}},
+								Text:  "line 14",
+							},
+						},
+					},
+				},
+			},
+			want: "```suggestion:-0+0\nline 14\n```\n",
+		},
+		{
+			name: "multi line",
+			comment: &reviewdog.Comment{
+				Result: &filter.FilteredDiagnostic{
+					Diagnostic: &rdf.Diagnostic{
+						Location: &rdf.Location{Path: "file.go", Range: &rdf.Range{Start: &rdf.Position{Line: 15}}},
+						Suggestions: []*rdf.Suggestion{
+							{
+								Range: &rdf.Range{Start: &rdf.Position{Line: 14}, End: &rdf.Position{Line: 17}},
+								Text:  "line 14\nline 15",
+							},
+						},
+					},
+				},
+			},
+			want: "```suggestion:-1+2\nline 14\nline 15\n```\n",
+		},
+		{
+			name: "remove lines",
+			comment: &reviewdog.Comment{
+				Result: &filter.FilteredDiagnostic{
+					Diagnostic: &rdf.Diagnostic{
+						Location: &rdf.Location{Path: "file.go", Range: &rdf.Range{Start: &rdf.Position{Line: 14}}},
+						Suggestions: []*rdf.Suggestion{
+							{
+								Range: &rdf.Range{Start: &rdf.Position{Line: 14}, End: &rdf.Position{Line: 15}},
+							},
+						},
+					},
+				},
+			},
+			want: "```suggestion:-0+1\n```\n",
+		},
+		{
+			name: "range suggestion (single line)",
+			comment: &reviewdog.Comment{
+				Result: &filter.FilteredDiagnostic{
+					SourceLines: map[int]string{15: "haya15busa"},
+					Diagnostic: &rdf.Diagnostic{
+						Location: &rdf.Location{Path: "file.go", Range: &rdf.Range{Start: &rdf.Position{Line: 15, Column: 5}}},
+						Suggestions: []*rdf.Suggestion{
+							{
+								Range: &rdf.Range{
+									Start: &rdf.Position{Line: 15, Column: 5},
+									End:   &rdf.Position{Line: 15, Column: 7},
+								},
+								Text: "14",
+							},
+						},
+					},
+				},
+			},
+			want: "```suggestion:-0+0\nhaya14busa\n```\n",
+		},
+		{
+			name: "range suggestion (multi-line)",
+			comment: &reviewdog.Comment{
+				Result: &filter.FilteredDiagnostic{
+					SourceLines: map[int]string{
+						15: "haya???",
+						16: "???busa (multi-line)",
+					},
+					Diagnostic: &rdf.Diagnostic{
+						Location: &rdf.Location{Path: "file.go", Range: &rdf.Range{Start: &rdf.Position{Line: 15, Column: 5}}},
+						Suggestions: []*rdf.Suggestion{
+							{
+								Range: &rdf.Range{
+									Start: &rdf.Position{Line: 15, Column: 5},
+									End:   &rdf.Position{Line: 16, Column: 4},
+								},
+								Text: "14",
+							},
+						},
+					},
+				},
+			},
+			want: "```suggestion:-0+1\nhaya14busa (multi-line)\n```\n",
+		},
+		{
+			name: "range suggestion (no source lines)",
+			comment: &reviewdog.Comment{
+				Result: &filter.FilteredDiagnostic{
+					Diagnostic: &rdf.Diagnostic{
+						Location: &rdf.Location{Path: "file.go", Range: &rdf.Range{Start: &rdf.Position{Line: 15, Column: 5}}},
+						Suggestions: []*rdf.Suggestion{
+							{
+								Range: &rdf.Range{
+									Start: &rdf.Position{Line: 15, Column: 5},
+									End:   &rdf.Position{Line: 15, Column: 7},
+								},
+								Text: "14",
+							},
+						},
+					},
+				},
+			},
+			want: invalidSuggestionPre + "source lines are not available" + invalidSuggestionPost + "\n",
+		},
+		{
+			name: "comment line outside suggestion range",
+			comment: &reviewdog.Comment{
+				Result: &filter.FilteredDiagnostic{
+					Diagnostic: &rdf.Diagnostic{
+						Location: &rdf.Location{Path: "file.go", Range: &rdf.Range{Start: &rdf.Position{Line: 14}}},
+						Suggestions: []*rdf.Suggestion{
+							{
+								Range: &rdf.Range{Start: &rdf.Position{Line: 16}, End: &rdf.Position{Line: 17}},
+								Text:  "line 16",
+							},
+						},
+					},
+				},
+			},
+			want: invalidSuggestionPre + "GitLab comment line must be in suggestion line range. L14 v.s. L16-L17" + invalidSuggestionPost + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildSuggestions(tt.comment); got != tt.want {
+				t.Errorf("buildSuggestions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
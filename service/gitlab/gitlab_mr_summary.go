@@ -0,0 +1,101 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/service/commentutil"
+)
+
+// postSummaryNotes creates or updates a summary note per tool on the
+// MergeRequest. Comments which are not in diff files or don't have line
+// number are listed as results outside diff, and overflow comments which exceed
+// limits of comments are listed as remaining results. If
+// overflowOnly is true, it posts summary notes only for tools which have such
+// remaining results. Existing summary notes of tools without results are
+// updated to zero counts. Summary notes are rendered without emoji if tmpl
+// is not nil.
+//
+// API:
+//  https://docs.gitlab.com/ee/api/notes.html#merge-requests
+func postSummaryNotes(ctx context.Context, cli *gitlab.Client, projects string, pr int, comments, overflow []*reviewdog.Comment, tools []string, tmpl *commentutil.Template, overflowOnly bool) error {
+	isOutsideDiff := func(c *reviewdog.Comment) bool {
+		lnum := c.Result.Diagnostic.GetLocation().GetRange().GetStart().GetLine()
+		return !c.Result.InDiffFile || lnum == 0
+	}
+	summaries := commentutil.BuildSummaries(comments, isOutsideDiff, overflow)
+	if overflowOnly {
+		filtered := summaries[:0]
+		for _, s := range summaries {
+			if len(s.Overflow) > 0 {
+				filtered = append(filtered, s)
+			}
+		}
+		summaries = filtered
+	} else {
+		summaries = append(summaries, commentutil.EmptySummaries(summaries, tools)...)
+	}
+	if len(summaries) == 0 {
+		return nil
+	}
+	notes, err := listAllMergeRequestNotes(ctx, cli, projects, pr, &gitlab.ListMergeRequestNotesOptions{ListOptions: gitlab.ListOptions{PerPage: 100}})
+	if err != nil {
+		return fmt.Errorf("failed to list merge request notes: %w", err)
+	}
+	for _, s := range summaries {
+		body := tmpl.SummaryMarkdown(s, nil)
+		existing := findSummaryNote(notes, s.ToolName)
+		if existing == nil && len(s.Comments) == 0 && len(s.Overflow) == 0 {
+			// Don't create summary notes of tools without results.
+			continue
+		}
+		if existing == nil {
+			opt := &gitlab.CreateMergeRequestNoteOptions{Body: gitlab.String(body)}
+			if _, _, err := cli.Notes.CreateMergeRequestNote(projects, pr, opt, gitlab.WithContext(ctx)); err != nil {
+				return fmt.Errorf("failed to create summary note: %w", err)
+			}
+			continue
+		}
+		if existing.Body == body {
+			continue
+		}
+		opt := &gitlab.UpdateMergeRequestNoteOptions{Body: gitlab.String(body)}
+		if _, _, err := cli.Notes.UpdateMergeRequestNote(projects, pr, existing.ID, opt, gitlab.WithContext(ctx)); err != nil {
+			return fmt.Errorf("failed to update summary note: %w", err)
+		}
+	}
+	return nil
+}
+
+func findSummaryNote(notes []*gitlab.Note, toolName string) *gitlab.Note {
+	for _, n := range notes {
+		if commentutil.IsSummaryComment(n.Body, toolName) {
+			return n
+		}
+	}
+	return nil
+}
+
+func listAllMergeRequestNotes(ctx context.Context, cli *gitlab.Client, projectID string, mergeRequest int, opts *gitlab.ListMergeRequestNotesOptions) ([]*gitlab.Note, error) {
+	notes, resp, err := cli.Notes.ListMergeRequestNotes(projectID, mergeRequest, opts, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if resp.NextPage == 0 {
+		return notes, nil
+	}
+	newOpts := &gitlab.ListMergeRequestNotesOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    resp.NextPage,
+			PerPage: opts.PerPage,
+		},
+	}
+	restNotes, err := listAllMergeRequestNotes(ctx, cli, projectID, mergeRequest, newOpts)
+	if err != nil {
+		return nil, err
+	}
+	return append(notes, restNotes...), nil
+}
@@ -0,0 +1,243 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/filter"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+	"github.com/reviewdog/reviewdog/service/commentutil"
+)
+
+func TestPostSummaryNotes(t *testing.T) {
+	comments := []*reviewdog.Comment{
+		{
+			Result: &filter.FilteredDiagnostic{
+				Diagnostic: &rdf.Diagnostic{
+					Location: &rdf.Location{Path: "file.go", Range: &rdf.Range{Start: &rdf.Position{Line: 14}}},
+					Message:  "in diff",
+					Severity: rdf.Severity_WARNING,
+				},
+				InDiffFile: true,
+			},
+			ToolName: "tool",
+		},
+		{
+			Result: &filter.FilteredDiagnostic{
+				Diagnostic: &rdf.Diagnostic{
+					Location: &rdf.Location{Path: "file.go", Range: &rdf.Range{Start: &rdf.Position{Line: 20}}},
+					Message:  "outside diff",
+					Severity: rdf.Severity_ERROR,
+				},
+			},
+			ToolName: "tool",
+		},
+		{
+			Result: &filter.FilteredDiagnostic{
+				Diagnostic: &rdf.Diagnostic{
+					Location: &rdf.Location{Path: "file.go"},
+					Message:  "without lnum",
+				},
+				InDiffFile: true,
+			},
+			ToolName: "tool2",
+		},
+	}
+
+	var updateCalled, createCalled int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/o/r/merge_requests/14/notes", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			switch r.URL.Query().Get("page") {
+			default:
+				w.Header().Add("X-Next-Page", "2")
+				fmt.Fprint(w, `[{"id": 1, "body": "unrelated note"}]`)
+			case "2":
+				notes := []*gitlab.Note{{ID: 2, Body: commentutil.SummaryMarker("tool") + "\nold summary"}}
+				if err := json.NewEncoder(w).Encode(notes); err != nil {
+					t.Fatal(err)
+				}
+			}
+		case http.MethodPost:
+			createCalled++
+			got := new(gitlab.CreateMergeRequestNoteOptions)
+			if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+				t.Error(err)
+			}
+			if !commentutil.IsSummaryComment(*got.Body, "tool2") {
+				t.Errorf("unexpected summary note: %s", *got.Body)
+			}
+			if !strings.Contains(*got.Body, "- `file.go` without lnum") {
+				t.Errorf("summary note doesn't list results outside diff: %s", *got.Body)
+			}
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected access: %v %v", r.Method, r.URL)
+		}
+	})
+	mux.HandleFunc("/api/v4/projects/o/r/merge_requests/14/notes/2", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("unexpected access: %v %v", r.Method, r.URL)
+		}
+		updateCalled++
+		got := new(gitlab.UpdateMergeRequestNoteOptions)
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{
+			"| 🚫 Error | 1 |",
+			"| ⚠️ Warning | 1 |",
+			"<summary>Findings outside the diff (1)</summary>",
+			"- `file.go:20` outside diff",
+		} {
+			if !strings.Contains(*got.Body, want) {
+				t.Errorf("summary note doesn't contain %q:\n%s", want, *got.Body)
+			}
+		}
+		fmt.Fprint(w, `{}`)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cli, err := gitlab.NewClient("", gitlab.WithBaseURL(ts.URL+"/api/v4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := postSummaryNotes(context.Background(), cli, "o/r", 14, comments, nil, nil, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if updateCalled != 1 {
+		t.Errorf("update note API called %d times, want 1", updateCalled)
+	}
+	if createCalled != 1 {
+		t.Errorf("create note API called %d times, want 1", createCalled)
+	}
+}
+
+func TestPostSummaryNotes_overflowOnly(t *testing.T) {
+	newComment := func(tool, msg string, overflow bool) *reviewdog.Comment {
+		return &reviewdog.Comment{
+			Result: &filter.FilteredDiagnostic{
+				Diagnostic: &rdf.Diagnostic{
+					Location: &rdf.Location{Path: "file.go", Range: &rdf.Range{Start: &rdf.Position{Line: 14}}},
+					Message:  msg,
+				},
+				InDiffFile: true,
+			},
+			ToolName: tool,
+			Overflow: overflow,
+		}
+	}
+	comments := []*reviewdog.Comment{
+		newComment("tool", "posted", false),
+		newComment("tool", "overflow", true),
+		newComment("tool2", "posted", false),
+	}
+
+	createCalled := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/o/r/merge_requests/14/notes", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			fmt.Fprint(w, `[]`)
+		case http.MethodPost:
+			createCalled++
+			got := new(gitlab.CreateMergeRequestNoteOptions)
+			if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+				t.Error(err)
+			}
+			if !commentutil.IsSummaryComment(*got.Body, "tool") {
+				t.Errorf("unexpected summary note: %s", *got.Body)
+			}
+			for _, want := range []string{
+				"<summary>Remaining findings which were not posted as inline comments (1)</summary>",
+				"- `file.go:14` overflow",
+			} {
+				if !strings.Contains(*got.Body, want) {
+					t.Errorf("summary note doesn't contain %q:\n%s", want, *got.Body)
+				}
+			}
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected access: %v %v", r.Method, r.URL)
+		}
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cli, err := gitlab.NewClient("", gitlab.WithBaseURL(ts.URL+"/api/v4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := postSummaryNotes(context.Background(), cli, "o/r", 14, comments, comments[1:2], nil, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if createCalled != 1 {
+		t.Errorf("create note API called %d times, want 1", createCalled)
+	}
+	// No API calls without overflow.
+	if err := postSummaryNotes(context.Background(), cli, "o/r", 14, comments[:1], nil, nil, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if createCalled != 1 {
+		t.Errorf("create note API called %d times, want 1", createCalled)
+	}
+}
+
+func TestPostSummaryNotes_fixed(t *testing.T) {
+	var updated []int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/o/r/merge_requests/14/notes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected access: %v %v", r.Method, r.URL)
+		}
+		notes := []*gitlab.Note{
+			{ID: 1, Body: commentutil.SummaryMarker("tool") + "\nold summary"},
+			{ID: 2, Body: commentutil.SummaryMarker("other") + "\nsummary of another reviewdog run"},
+		}
+		if err := json.NewEncoder(w).Encode(notes); err != nil {
+			t.Fatal(err)
+		}
+	})
+	mux.HandleFunc("/api/v4/projects/o/r/merge_requests/14/notes/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("unexpected access: %v %v", r.Method, r.URL)
+		}
+		var id int
+		fmt.Sscanf(r.URL.Path, "/api/v4/projects/o/r/merge_requests/14/notes/%d", &id)
+		updated = append(updated, id)
+		got := new(gitlab.UpdateMergeRequestNoteOptions)
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Error(err)
+		}
+		for _, want := range []string{"Error | 0 |", "Warning | 0 |", "Info | 0 |"} {
+			if !strings.Contains(*got.Body, want) {
+				t.Errorf("summary note doesn't contain %q:\n%s", want, *got.Body)
+			}
+		}
+		fmt.Fprint(w, `{}`)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cli, err := gitlab.NewClient("", gitlab.WithBaseURL(ts.URL+"/api/v4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// All results of tool are fixed. tool2 doesn't have a summary note yet.
+	if err := postSummaryNotes(context.Background(), cli, "o/r", 14, nil, nil, []string{"tool", "tool2"}, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(updated) != 1 || updated[0] != 1 {
+		t.Errorf("updated notes %v, want [1]", updated)
+	}
+}
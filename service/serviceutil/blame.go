@@ -0,0 +1,183 @@
+package serviceutil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// uncommitted is the commit hash of lines which are not committed yet in the
+// output of git blame.
+const uncommitted = "0000000000000000000000000000000000000000"
+
+// GitBlamer tells whether lines are last changed by commits which match all
+// of the given conditions with `git blame`. It implements filter.Blamer.
+// Lines which are not committed yet match any conditions.
+type GitBlamer struct {
+	// commits since the merge-base of the base ref and HEAD. nil if base ref is
+	// not given.
+	commits map[string]bool
+	author  string
+	since   time.Time
+
+	// ctx to run git blame, as Match doesn't take a context.
+	ctx context.Context
+
+	// mu guards files.
+	mu    sync.Mutex
+	files map[string]*blameFile
+}
+
+// blameFile holds the result of git blame of a file, which runs once per file.
+type blameFile struct {
+	once  sync.Once
+	lines []*blameCommit
+}
+
+type blameCommit struct {
+	hash   string
+	author string
+	mail   string
+	time   time.Time
+}
+
+// NewGitBlamer returns a new GitBlamer. Commits match if they are since the
+// merge-base of base (a git ref or DiffBaseAuto) and HEAD, their author name
+// or email is author, and they are authored at or after since. Empty base,
+// empty author and zero since match any commits.
+func NewGitBlamer(ctx context.Context, base, author string, since time.Time) (*GitBlamer, error) {
+	if !hasGit() {
+		return nil, errors.New("git command is required to blame files")
+	}
+	b := &GitBlamer{author: author, since: since, ctx: ctx, files: make(map[string]*blameFile)}
+	if base == "" {
+		return b, nil
+	}
+	if base == DiffBaseAuto {
+		var err error
+		if base, err = GitAutoDiffBase(ctx); err != nil {
+			return nil, err
+		}
+	}
+	mergeBase, err := GitMergeBase(ctx, base, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	out, err := gitOutput(ctx, "rev-list", mergeBase+"..HEAD")
+	if err != nil {
+		return nil, err
+	}
+	b.commits = make(map[string]bool)
+	for _, c := range strings.Fields(out) {
+		b.commits[c] = true
+	}
+	return b, nil
+}
+
+// Match returns true if the line of the file is last changed by a commit which
+// matches the conditions. It runs git blame once for each file and returns
+// false if it fails (e.g. the file is not tracked).
+func (b *GitBlamer) Match(path string, lnum int) bool {
+	lines := b.blame(path)
+	if lnum < 1 || lnum > len(lines) {
+		return false
+	}
+	c := lines[lnum-1]
+	if c.hash == uncommitted {
+		return true
+	}
+	if b.commits != nil && !b.commits[c.hash] {
+		return false
+	}
+	if b.author != "" && !strings.EqualFold(b.author, c.author) && !strings.EqualFold(b.author, c.mail) {
+		return false
+	}
+	return b.since.IsZero() || !c.time.Before(b.since)
+}
+
+// blame returns the commits of lines of the file. Files are blamed
+// concurrently while git blame of each file runs only once.
+func (b *GitBlamer) blame(path string) []*blameCommit {
+	b.mu.Lock()
+	f, ok := b.files[path]
+	if !ok {
+		f = &blameFile{}
+		b.files[path] = f
+	}
+	b.mu.Unlock()
+	f.once.Do(func() {
+		var err error
+		f.lines, err = gitBlame(b.ctx, path)
+		if err != nil {
+			log.Printf("reviewdog: failed to blame %s: %v", path, err)
+		}
+	})
+	return f.lines
+}
+
+// gitBlame returns the commits which last changed each line of the file in
+// the working tree.
+func gitBlame(ctx context.Context, path string) ([]*blameCommit, error) {
+	out, err := exec.CommandContext(ctx, "git", "blame", "--line-porcelain", "--", path).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git blame: %w", gitError(err))
+	}
+	return parseBlamePorcelain(out)
+}
+
+// parseBlamePorcelain parses the output of `git blame --line-porcelain`.
+func parseBlamePorcelain(out []byte) ([]*blameCommit, error) {
+	commits := make(map[string]*blameCommit)
+	var lines []*blameCommit
+	var cur *blameCommit
+	s := bufio.NewScanner(bytes.NewReader(out))
+	s.Buffer(nil, 1024*1024*64)
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "\t") {
+			// The content of the line ends headers of the line.
+			lines = append(lines, cur)
+			cur = nil
+			continue
+		}
+		if cur == nil {
+			// "<hash> <orig line> <final line> [<lines in group>]"
+			hash := strings.SplitN(line, " ", 2)[0]
+			if c, ok := commits[hash]; ok {
+				cur = c
+			} else {
+				cur = &blameCommit{hash: hash}
+				commits[hash] = cur
+			}
+			continue
+		}
+		key, value := line, ""
+		if i := strings.Index(line, " "); i >= 0 {
+			key, value = line[:i], line[i+1:]
+		}
+		switch key {
+		case "author":
+			cur.author = value
+		case "author-mail":
+			cur.mail = strings.TrimSuffix(strings.TrimPrefix(value, "<"), ">")
+		case "author-time":
+			sec, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid author-time %q: %w", value, err)
+			}
+			cur.time = time.Unix(sec, 0)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
@@ -0,0 +1,101 @@
+package serviceutil
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGitBlamer(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	dir, err := ioutil.TempDir("", "reviewdog-blame")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	commit := func(content, author, date string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		git(t, dir, "add", "a.txt")
+		git(t, dir, "commit", "-q", "-m", "update", "--author="+author, "--date="+date)
+	}
+	git(t, dir, "init", "-q")
+	commit("1\n", "Base <base@example.com>", "2020-01-01T00:00:00Z")
+	git(t, dir, "checkout", "-q", "-b", "feature")
+	commit("1\n2\n", "Alice <alice@example.com>", "2020-06-01T00:00:00Z")
+	commit("1\n2\n3\n", "Bob <bob@example.com>", "2021-01-01T00:00:00Z")
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("1\n2\n3\n4\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer chdir(t, dir)()
+
+	tests := []struct {
+		name   string
+		base   string
+		author string
+		since  time.Time
+		want   []bool // lines 1 to 4
+	}{
+		{name: "base", base: "main", want: []bool{false, true, true, true}},
+		{name: "author", author: "alice@example.com", want: []bool{false, true, false, true}},
+		{name: "author name", author: "bob", want: []bool{false, false, true, true}},
+		{name: "since", since: time.Date(2020, 12, 1, 0, 0, 0, 0, time.UTC), want: []bool{false, false, true, true}},
+		{name: "base and author", base: "main", author: "Base", want: []bool{false, false, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewGitBlamer(context.Background(), tt.base, tt.author, tt.since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.want {
+				if got := b.Match("a.txt", i+1); got != want {
+					t.Errorf("Match(a.txt, %d) = %v, want %v", i+1, got, want)
+				}
+			}
+			if b.Match("a.txt", 5) || b.Match("not_found.txt", 1) {
+				t.Error("Match() = true for lines which don't exist")
+			}
+		})
+	}
+
+	t.Run("concurrent", func(t *testing.T) {
+		b, err := NewGitBlamer(context.Background(), "main", "", time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []bool{false, true, true, true}
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j, w := range want {
+					if got := b.Match("a.txt", j+1); got != w {
+						t.Errorf("Match(a.txt, %d) = %v, want %v", j+1, got, w)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		b, err := NewGitBlamer(ctx, "", "", time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		if b.Match("a.txt", 4) {
+			t.Error("Match() = true after the context is canceled")
+		}
+	})
+}
@@ -0,0 +1,276 @@
+package serviceutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/reviewdog/reviewdog/diff"
+	"github.com/reviewdog/reviewdog/gitrepo"
+)
+
+// DiffBaseAuto is a special diff base which picks the upstream branch or the
+// default branch of the remote.
+const DiffBaseAuto = "auto"
+
+const (
+	// deepenBy is the number of commits to deepen a shallow clone by at first.
+	// It doubles on each fetch.
+	deepenBy = 50
+	// maxDeepenFetches is the max number of deepening fetches before fetching
+	// the whole history.
+	maxDeepenFetches = 4
+)
+
+// GitDiffBase is a diff service which runs `git diff --find-renames` from the
+// merge-base of the base ref and HEAD to the working tree. It works in the
+// same way as the diff of Pull Requests, so results on commits which are
+// merged from the base branch are not reported. It computes diff in-process
+// if git command is not available.
+type GitDiffBase struct {
+	base string
+
+	once sync.Once
+	out  []byte
+	err  error
+}
+
+// NewGitDiffBase returns a new GitDiffBase. base is a git ref (e.g.
+// origin/main) or DiffBaseAuto.
+func NewGitDiffBase(base string) *GitDiffBase {
+	return &GitDiffBase{base: base}
+}
+
+// Diff returns diff. It caches the result and can be used more than once.
+func (g *GitDiffBase) Diff(ctx context.Context) ([]byte, error) {
+	g.once.Do(func() {
+		base := g.base
+		if base == DiffBaseAuto {
+			base, g.err = GitAutoDiffBase(ctx)
+			if g.err != nil {
+				return
+			}
+		}
+		g.out, g.err = GitDiffFromMergeBase(ctx, base, "")
+	})
+	return g.out, g.err
+}
+
+// Strip returns 1 as a strip of git diff.
+func (g *GitDiffBase) Strip() int {
+	return 1
+}
+
+// FileDiffs returns parsed diff. It computes diff in-process without git
+// command.
+func (g *GitDiffBase) FileDiffs(ctx context.Context) ([]*diff.FileDiff, error) {
+	if hasGit() {
+		b, err := g.Diff(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return diff.ParseMultiFile(bytes.NewReader(b))
+	}
+	base := g.base
+	if base == DiffBaseAuto {
+		var err error
+		if base, err = GitAutoDiffBase(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return GitFileDiffsFromMergeBase(ctx, base, "")
+}
+
+// hasGit reports whether git command is available. Diff and merge-base are
+// computed in-process by gitrepo package without it.
+var hasGit = func() bool {
+	_, err := exec.LookPath("git")
+	return err == nil
+}
+
+// GitDiffFromMergeBase returns `git diff --find-renames` from the merge-base
+// of base and head to head. It diffs to the working tree if head is empty.
+func GitDiffFromMergeBase(ctx context.Context, base, head string) ([]byte, error) {
+	if !hasGit() {
+		filediffs, err := GitFileDiffsFromMergeBase(ctx, base, head)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := diff.Format(&buf, filediffs); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	target := head
+	if target == "" {
+		target = "HEAD"
+	}
+	mergeBase, err := GitMergeBase(ctx, base, target)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"diff", "--find-renames", mergeBase}
+	if head != "" {
+		args = append(args, head)
+	}
+	b, err := exec.CommandContext(ctx, "git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git diff: %w", gitError(err))
+	}
+	return b, nil
+}
+
+// GitFileDiffsFromMergeBase returns diff from the merge-base of base and head
+// to head in the same way as GitDiffFromMergeBase, but it computes diff
+// in-process without git command. Untracked files are not included in diff
+// to the working tree as git diff does.
+func GitFileDiffsFromMergeBase(ctx context.Context, base, head string) ([]*diff.FileDiff, error) {
+	repo, err := gitrepo.Open(".")
+	if err != nil {
+		return nil, err
+	}
+	target := head
+	if target == "" {
+		target = "HEAD"
+	}
+	mergeBase, err := repoMergeBase(repo, base, target)
+	if err != nil {
+		return nil, err
+	}
+	if head == "" {
+		return repo.DiffWorkTree(mergeBase)
+	}
+	headHash, err := repo.ResolveRevision(head)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Diff(mergeBase, headHash)
+}
+
+// GitMergeBase returns the merge-base commit of a and b. It deepens the
+// history of a shallow clone until the merge-base is found.
+func GitMergeBase(ctx context.Context, a, b string) (string, error) {
+	if !hasGit() {
+		repo, err := gitrepo.Open(".")
+		if err != nil {
+			return "", err
+		}
+		h, err := repoMergeBase(repo, a, b)
+		if err != nil {
+			return "", err
+		}
+		return h.String(), nil
+	}
+	for i := 0; ; i++ {
+		mergeBase, err := gitOutput(ctx, "merge-base", a, b)
+		if err == nil {
+			return mergeBase, nil
+		}
+		if i > maxDeepenFetches || !isShallowRepository(ctx) || !refsExist(ctx, a, b) {
+			return "", fmt.Errorf("failed to get merge-base commit of %s and %s: %w", a, b, err)
+		}
+		args := []string{"fetch", "--deepen=" + strconv.Itoa(deepenBy<<uint(i))}
+		if i == maxDeepenFetches {
+			args = []string{"fetch", "--unshallow"}
+		}
+		if _, err := gitOutput(ctx, args...); err != nil {
+			return "", fmt.Errorf("failed to fetch history of shallow clone: %w", err)
+		}
+	}
+}
+
+// repoMergeBase returns the merge-base commit of a and b without git command.
+// It cannot deepen the history of a shallow clone.
+func repoMergeBase(repo *gitrepo.Repository, a, b string) (gitrepo.Hash, error) {
+	ha, err := repo.ResolveRevision(a)
+	if err != nil {
+		return gitrepo.Hash{}, err
+	}
+	hb, err := repo.ResolveRevision(b)
+	if err != nil {
+		return gitrepo.Hash{}, err
+	}
+	h, err := repo.MergeBase(ha, hb)
+	if err != nil {
+		return gitrepo.Hash{}, fmt.Errorf("failed to get merge-base commit of %s and %s: %w", a, b, err)
+	}
+	return h, nil
+}
+
+// GitAutoDiffBase returns the diff base for DiffBaseAuto. It's the upstream
+// branch if it's not the remote branch of the current branch itself, or the
+// default branch of the remote (e.g. origin/main). The upstream branch is not
+// used if git command is not available.
+func GitAutoDiffBase(ctx context.Context) (string, error) {
+	if !hasGit() {
+		return repoAutoDiffBase()
+	}
+	if upstream, err := gitOutput(ctx, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err == nil {
+		branch, _ := gitOutput(ctx, "rev-parse", "--abbrev-ref", "HEAD")
+		if !strings.HasSuffix(upstream, "/"+branch) {
+			return upstream, nil
+		}
+	}
+	if head, err := gitOutput(ctx, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return head, nil
+	}
+	for _, ref := range []string{"origin/main", "origin/master"} {
+		if _, err := gitOutput(ctx, "rev-parse", "--verify", "--quiet", ref); err == nil {
+			return ref, nil
+		}
+	}
+	return "", errors.New("cannot find diff base. Set upstream branch or pass a git ref to -diff-base")
+}
+
+func repoAutoDiffBase() (string, error) {
+	repo, err := gitrepo.Open(".")
+	if err != nil {
+		return "", err
+	}
+	if head, err := repo.SymbolicRef("refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimPrefix(head, "refs/remotes/"), nil
+	}
+	for _, ref := range []string{"origin/main", "origin/master"} {
+		if _, err := repo.ResolveRevision(ref); err == nil {
+			return ref, nil
+		}
+	}
+	return "", errors.New("cannot find diff base. Set upstream branch or pass a git ref to -diff-base")
+}
+
+func refsExist(ctx context.Context, refs ...string) bool {
+	for _, ref := range refs {
+		if _, err := gitOutput(ctx, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func isShallowRepository(ctx context.Context) bool {
+	out, err := gitOutput(ctx, "rev-parse", "--is-shallow-repository")
+	return err == nil && out == "true"
+}
+
+func gitOutput(ctx context.Context, args ...string) (string, error) {
+	b, err := exec.CommandContext(ctx, "git", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), gitError(err))
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// gitError adds stderr of git command to the error.
+func gitError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
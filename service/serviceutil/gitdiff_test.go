@@ -0,0 +1,179 @@
+package serviceutil
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com",
+		"-c", "init.defaultBranch=main", "-c", "protocol.file.allow=always"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func commitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "add", name)
+	git(t, dir, "commit", "-m", name)
+}
+
+// setupUpstream creates a repository which has main branch and feature
+// branch diverged from main, and returns its path.
+func setupUpstream(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "reviewdog-gitdiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "init", "-q")
+	commitFile(t, dir, "a.txt", "a\n")
+	git(t, dir, "checkout", "-q", "-b", "feature")
+	commitFile(t, dir, "feature1.txt", "feature1\n")
+	commitFile(t, dir, "feature2.txt", "feature2\n")
+	git(t, dir, "checkout", "-q", "main")
+	commitFile(t, dir, "main.txt", "main\n")
+	return dir
+}
+
+func chdir(t *testing.T, dir string) (restore func()) {
+	t.Helper()
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	return func() { os.Chdir(cwd) }
+}
+
+func TestGitDiffBase(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	upstream := setupUpstream(t)
+	defer os.RemoveAll(upstream)
+
+	tests := []struct {
+		name      string
+		cloneArgs []string
+		base      string
+	}{
+		{name: "full clone", base: "origin/main"},
+		{name: "auto", base: DiffBaseAuto},
+		{name: "shallow clone", cloneArgs: []string{"--depth=1", "--no-single-branch"}, base: "origin/main"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp, err := ioutil.TempDir("", "reviewdog-gitdiff-clone")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmp)
+			args := append([]string{"clone", "-q", "--branch", "feature"}, tt.cloneArgs...)
+			git(t, tmp, append(args, "file://"+upstream, "clone")...)
+			clone := filepath.Join(tmp, "clone")
+			defer chdir(t, clone)()
+
+			// Uncommitted changes are included.
+			if err := ioutil.WriteFile("feature2.txt", []byte("feature2\nwip\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			d := NewGitDiffBase(tt.base)
+			b, err := d.Diff(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := string(b)
+			for _, want := range []string{"+++ b/feature1.txt", "+++ b/feature2.txt", "+wip"} {
+				if !strings.Contains(got, want) {
+					t.Errorf("diff doesn't contain %q:\n%s", want, got)
+				}
+			}
+			if strings.Contains(got, "main.txt") {
+				t.Errorf("diff contains changes of the base branch:\n%s", got)
+			}
+			if d.Strip() != 1 {
+				t.Errorf("Strip() = %d, want 1", d.Strip())
+			}
+		})
+	}
+}
+
+func TestGitMergeBase_unknownRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	upstream := setupUpstream(t)
+	defer os.RemoveAll(upstream)
+	defer chdir(t, upstream)()
+	if _, err := GitMergeBase(context.Background(), "unknown-ref", "HEAD"); err == nil {
+		t.Error("got no error, want error")
+	}
+}
+
+func TestGitDiffBase_withoutGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	upstream := setupUpstream(t)
+	defer os.RemoveAll(upstream)
+
+	for _, base := range []string{"origin/main", DiffBaseAuto} {
+		t.Run(base, func(t *testing.T) {
+			tmp, err := ioutil.TempDir("", "reviewdog-gitdiff-clone")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmp)
+			git(t, tmp, "clone", "-q", "--branch", "feature", "file://"+upstream, "clone")
+			clone := filepath.Join(tmp, "clone")
+			defer chdir(t, clone)()
+			if err := ioutil.WriteFile("feature2.txt", []byte("feature2\nwip\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			want, err := NewGitDiffBase(base).Diff(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer func(f func() bool) { hasGit = f }(hasGit)
+			hasGit = func() bool { return false }
+
+			d := NewGitDiffBase(base)
+			got, err := d.Diff(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("diff without git differs from git diff:\ngot:\n%s\nwant:\n%s", got, want)
+			}
+			filediffs, err := d.FileDiffs(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var paths []string
+			for _, fd := range filediffs {
+				paths = append(paths, fd.PathNew)
+			}
+			if got, want := strings.Join(paths, ","), "b/feature1.txt,b/feature2.txt"; got != want {
+				t.Errorf("FileDiffs() paths = %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -4,10 +4,20 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+
+	"github.com/reviewdog/reviewdog/gitrepo"
 )
 
-// GitRelWorkdir returns git relative workdir of current directory.
+// GitRelWorkdir returns git relative workdir of current directory. It reads
+// the repository in-process if git command is not available.
 func GitRelWorkdir() (string, error) {
+	if !hasGit() {
+		repo, err := gitrepo.Open(".")
+		if err != nil {
+			return "", err
+		}
+		return repo.RelWorkdir(".")
+	}
 	b, err := exec.Command("git", "rev-parse", "--show-prefix").Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to run 'git rev-parse --show-prefix': %w", err)
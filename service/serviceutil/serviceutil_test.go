@@ -6,6 +6,15 @@ import (
 )
 
 func TestGitRelWorkdir(t *testing.T) {
+	t.Run("git", testGitRelWorkdir)
+	t.Run("without git", func(t *testing.T) {
+		defer func(f func() bool) { hasGit = f }(hasGit)
+		hasGit = func() bool { return false }
+		testGitRelWorkdir(t)
+	})
+}
+
+func testGitRelWorkdir(t *testing.T) {
 	cwd, _ := os.Getwd()
 	defer os.Chdir(cwd)
 
@@ -0,0 +1,235 @@
+package serviceutil
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is the default max number of retries of Transport.
+	DefaultMaxRetries = 3
+	// DefaultMaxConcurrency is the default max number of in-flight requests
+	// of Transport.
+	DefaultMaxConcurrency = 4
+	// DefaultMaxWait is the default max duration to wait before a retry.
+	DefaultMaxWait = time.Minute
+
+	baseBackoff = time.Second
+)
+
+// Transport is a http.RoundTripper shared by reporters to talk to code
+// hosting services (GitHub, GitLab, Bitbucket and Gerrit) politely.
+//
+// - It caps the number of in-flight requests.
+// - It retries requests rejected by rate limit (429, or 403 with Retry-After
+//   or X-RateLimit-Remaining: 0) after the duration which the service tells
+//   by Retry-After or X-RateLimit-Reset (RateLimit-Reset for GitLab).
+// - It retries idempotent requests on network errors and 502, 503 and 504
+//   with jittered exponential backoff.
+//
+// It gives up retrying and returns the last response if the service asks to
+// wait longer than MaxWait.
+type Transport struct {
+	// Base is the underlying RoundTripper. http.DefaultTransport is used if
+	// it's nil.
+	Base http.RoundTripper
+	// MaxRetries is the max number of retries per request.
+	MaxRetries int
+	// MaxWait is the max duration to wait before a retry.
+	MaxWait time.Duration
+
+	sem chan struct{}
+
+	// Stubbed in tests.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewTransport returns a new Transport with default retry config. It doesn't
+// cap concurrency if maxConcurrency <= 0.
+func NewTransport(base http.RoundTripper, maxConcurrency int) *Transport {
+	t := &Transport{
+		Base:       base,
+		MaxRetries: DefaultMaxRetries,
+		MaxWait:    DefaultMaxWait,
+		now:        time.Now,
+		sleep:      sleepContext,
+	}
+	if maxConcurrency > 0 {
+		t.sem = make(chan struct{}, maxConcurrency)
+	}
+	return t
+}
+
+// WithBase returns a copy of t which sends requests with base. The copy shares
+// the cap of in-flight requests with t (e.g. for a service which needs a
+// different proxy).
+func (t *Transport) WithBase(base http.RoundTripper) *Transport {
+	c := *t
+	c.Base = base
+	return &c
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	getBody, err := rewindableBody(req)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; ; i++ {
+		r := req
+		// Use a copy of the body on retries or if the original body is consumed
+		// to buffer it.
+		if getBody != nil && (i > 0 || req.GetBody == nil) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(ctx)
+			r.Body = body
+		}
+		resp, err := t.roundTrip(r)
+		if i >= t.MaxRetries {
+			return resp, err
+		}
+		wait, retry := t.retryWait(req, resp, err, i)
+		if !retry || wait > t.MaxWait {
+			return resp, err
+		}
+		if resp != nil {
+			// Drain and close the body to reuse the connection.
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := t.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *Transport) roundTrip(req *http.Request) (*http.Response, error) {
+	if t.sem != nil {
+		select {
+		case t.sem <- struct{}{}:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		defer func() { <-t.sem }()
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// retryWait returns the duration to wait before the n-th (0-origin) retry
+// and whether the request should be retried.
+func (t *Transport) retryWait(req *http.Request, resp *http.Response, err error, n int) (time.Duration, bool) {
+	if err != nil {
+		if req.Context().Err() != nil {
+			return 0, false
+		}
+		return backoff(n), isIdempotent(req.Method)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+	case http.StatusForbidden:
+		// GitHub returns 403 for rate limit errors.
+		// https://docs.github.com/en/rest/overview/resources-in-the-rest-api#rate-limiting
+		if resp.Header.Get("Retry-After") == "" && resp.Header.Get("X-RateLimit-Remaining") != "0" {
+			return 0, false
+		}
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if !isIdempotent(req.Method) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	if d, ok := t.rateLimitWait(resp.Header); ok {
+		return d, true
+	}
+	return backoff(n), true
+}
+
+// rateLimitWait returns the duration to wait which is told by the response
+// headers.
+func (t *Transport) rateLimitWait(h http.Header) (time.Duration, bool) {
+	if v := h.Get("Retry-After"); v != "" {
+		if sec, err := strconv.Atoi(v); err == nil {
+			return time.Duration(sec) * time.Second, true
+		}
+		if at, err := http.ParseTime(v); err == nil {
+			return nonNegative(at.Sub(t.now())), true
+		}
+	}
+	for _, key := range []string{"X-RateLimit-Reset", "RateLimit-Reset"} {
+		if v := h.Get(key); v != "" {
+			if epoch, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return nonNegative(time.Unix(epoch, 0).Sub(t.now())), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// backoff returns jittered exponential backoff duration for the n-th
+// (0-origin) retry.
+func backoff(n int) time.Duration {
+	d := baseBackoff << uint(n)
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// rewindableBody returns a function to get a new copy of the request body for
+// retries. It consumes and buffers the body if the request doesn't have
+// GetBody.
+func rewindableBody(req *http.Request) (func() (io.ReadCloser, error), error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		return req.GetBody, nil
+	}
+	b, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	return func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	}, nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
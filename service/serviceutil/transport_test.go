@@ -0,0 +1,235 @@
+package serviceutil
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestTransport() (*Transport, *[]time.Duration) {
+	var waits []time.Duration
+	now := time.Unix(1600000000, 0)
+	tr := NewTransport(nil, 0)
+	tr.now = func() time.Time { return now }
+	tr.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return tr, &waits
+}
+
+func TestTransport_retry(t *testing.T) {
+	type response struct {
+		status int
+		header map[string]string
+	}
+	tests := []struct {
+		name       string
+		method     string
+		responses  []response
+		wantStatus int
+		wantCalls  int
+		// Expected waits. Negative value means jittered backoff.
+		wantWaits []time.Duration
+	}{
+		{
+			name:       "success",
+			method:     http.MethodGet,
+			responses:  []response{{status: 200}},
+			wantStatus: 200,
+			wantCalls:  1,
+		},
+		{
+			name:   "429 with Retry-After",
+			method: http.MethodPost,
+			responses: []response{
+				{status: 429, header: map[string]string{"Retry-After": "3"}},
+				{status: 201},
+			},
+			wantStatus: 201,
+			wantCalls:  2,
+			wantWaits:  []time.Duration{3 * time.Second},
+		},
+		{
+			name:   "GitHub primary rate limit",
+			method: http.MethodPost,
+			responses: []response{
+				{status: 403, header: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1600000010"}},
+				{status: 200},
+			},
+			wantStatus: 200,
+			wantCalls:  2,
+			wantWaits:  []time.Duration{10 * time.Second},
+		},
+		{
+			name:   "GitLab rate limit",
+			method: http.MethodPost,
+			responses: []response{
+				{status: 429, header: map[string]string{"RateLimit-Reset": "1600000005"}},
+				{status: 200},
+			},
+			wantStatus: 200,
+			wantCalls:  2,
+			wantWaits:  []time.Duration{5 * time.Second},
+		},
+		{
+			name:   "403 without rate limit",
+			method: http.MethodGet,
+			responses: []response{
+				{status: 403},
+			},
+			wantStatus: 403,
+			wantCalls:  1,
+		},
+		{
+			name:   "503 for idempotent request",
+			method: http.MethodGet,
+			responses: []response{
+				{status: 503},
+				{status: 502},
+				{status: 200},
+			},
+			wantStatus: 200,
+			wantCalls:  3,
+			wantWaits:  []time.Duration{-1, -1},
+		},
+		{
+			name:   "503 for non-idempotent request",
+			method: http.MethodPost,
+			responses: []response{
+				{status: 503},
+			},
+			wantStatus: 503,
+			wantCalls:  1,
+		},
+		{
+			name:   "exceed max retries",
+			method: http.MethodGet,
+			responses: []response{
+				{status: 429}, {status: 429}, {status: 429}, {status: 429},
+			},
+			wantStatus: 429,
+			wantCalls:  4,
+			wantWaits:  []time.Duration{-1, -1, -1},
+		},
+		{
+			name:   "too long wait",
+			method: http.MethodGet,
+			responses: []response{
+				{status: 429, header: map[string]string{"Retry-After": strconv.Itoa(int(time.Hour / time.Second))}},
+			},
+			wantStatus: 429,
+			wantCalls:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r.Method == http.MethodPost && string(b) != "body" {
+					t.Errorf("got body %q on %d-th call", b, calls)
+				}
+				res := tt.responses[calls]
+				calls++
+				for k, v := range res.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(res.status)
+			}))
+			defer ts.Close()
+
+			tr, waits := newTestTransport()
+			cli := &http.Client{Transport: tr}
+			var body *strings.Reader
+			req, err := http.NewRequest(tt.method, ts.URL, nil)
+			if tt.method == http.MethodPost {
+				body = strings.NewReader("body")
+				req, err = http.NewRequest(tt.method, ts.URL, ioutil.NopCloser(body))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := cli.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("got %d calls, want %d", calls, tt.wantCalls)
+			}
+			if len(*waits) != len(tt.wantWaits) {
+				t.Fatalf("got waits %v, want %v", *waits, tt.wantWaits)
+			}
+			for i, want := range tt.wantWaits {
+				got := (*waits)[i]
+				if want >= 0 {
+					if got != want {
+						t.Errorf("got %d-th wait %v, want %v", i, got, want)
+					}
+					continue
+				}
+				max := baseBackoff << uint(i)
+				if got < max/2 || got > max {
+					t.Errorf("got %d-th wait %v, want jittered backoff in [%v, %v]", i, got, max/2, max)
+				}
+			}
+		})
+	}
+}
+
+func TestTransport_maxConcurrency(t *testing.T) {
+	const maxConcurrency = 2
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	tr := NewTransport(nil, maxConcurrency)
+	// Transports created by WithBase share the cap.
+	clients := []*http.Client{
+		{Transport: tr},
+		{Transport: tr.WithBase(&http.Transport{})},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		cli := clients[i%len(clients)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := cli.Get(ts.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	if maxInFlight > maxConcurrency {
+		t.Errorf("got %d in-flight requests, want <= %d", maxInFlight, maxConcurrency)
+	}
+}
@@ -0,0 +1,246 @@
+// Package webhook provides a reporter which posts reviewdog results to a
+// generic webhook endpoint as a JSON document.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/cienv"
+	"github.com/reviewdog/reviewdog/commands"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+var _ reviewdog.BulkCommentService = &Reporter{}
+
+const (
+	// SignatureHeader is a header which holds HMAC hex digest of the request
+	// body with "sha256=" prefix. It's set only when a secret is given.
+	SignatureHeader = "X-Reviewdog-Signature-256"
+	// EventHeader is a header which holds an event name of the request.
+	EventHeader = "X-Reviewdog-Event"
+
+	event = "results"
+)
+
+// Payload is a JSON document which Reporter posts.
+type Payload struct {
+	// Build information. Fields are empty if reviewdog cannot get build info
+	// (e.g. running locally).
+	Owner       string `json:"owner,omitempty"`
+	Repo        string `json:"repo,omitempty"`
+	SHA         string `json:"sha,omitempty"`
+	PullRequest int    `json:"pull_request,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+
+	// Version of reviewdog.
+	Version string `json:"version"`
+
+	// Results per tool sorted by tool name.
+	Tools []*ToolResult `json:"tools"`
+}
+
+// ToolResult represents results of a tool.
+type ToolResult struct {
+	Name string `json:"name"`
+	// Counts of diagnostics by severity (e.g. "ERROR", "WARNING").
+	Counts map[string]int `json:"counts"`
+	// Diagnostics in the Reviewdog Diagnostic Format (rdjson).
+	Diagnostics []*Diagnostic `json:"diagnostics"`
+}
+
+// Diagnostic represents a reported diagnostic with filtering info.
+type Diagnostic struct {
+	Diagnostic    *rdf.Diagnostic `json:"diagnostic"`
+	InDiffFile    bool            `json:"in_diff_file"`
+	InDiffContext bool            `json:"in_diff_context"`
+	// Overflow is true if it exceeds limits of comments.
+	Overflow bool `json:"overflow,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. Diagnostic field is encoded by
+// protojson so that it's compatible with rdjson.
+func (d *Diagnostic) MarshalJSON() ([]byte, error) {
+	b, err := protojson.Marshal(d.Diagnostic)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		Diagnostic    json.RawMessage `json:"diagnostic"`
+		InDiffFile    bool            `json:"in_diff_file"`
+		InDiffContext bool            `json:"in_diff_context"`
+		Overflow      bool            `json:"overflow,omitempty"`
+	}{Diagnostic: b, InDiffFile: d.InDiffFile, InDiffContext: d.InDiffContext, Overflow: d.Overflow})
+}
+
+// Option represents options of Reporter.
+type Option struct {
+	// Secret to sign request bodies with HMAC-SHA256. Optional.
+	Secret string
+	// Additional request headers. Optional.
+	Header http.Header
+	// Max number of retries for network errors, 429 and 5xx responses.
+	Retries int
+	// Names of tools of the run. Tools without results are included in
+	// payloads with zero counts so that receivers can tell they passed.
+	Tools []string
+}
+
+// Reporter is a comment service which posts results as a JSON document to a
+// webhook URL on Flush.
+type Reporter struct {
+	cli   *http.Client
+	url   string
+	build *cienv.BuildInfo
+	opt   Option
+
+	// retryWait is the initial wait duration between retries, which doubles
+	// on each retry.
+	retryWait time.Duration
+
+	muComments   sync.Mutex
+	postComments []*reviewdog.Comment
+}
+
+// NewReporter returns a new Reporter. build is optional.
+func NewReporter(cli *http.Client, url string, build *cienv.BuildInfo, opt *Option) *Reporter {
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+	r := &Reporter{
+		cli:       cli,
+		url:       url,
+		build:     build,
+		retryWait: time.Second,
+	}
+	if opt != nil {
+		r.opt = *opt
+	}
+	return r
+}
+
+// Post accepts a comment and holds it. Flush method actually posts results.
+func (r *Reporter) Post(_ context.Context, c *reviewdog.Comment) error {
+	r.muComments.Lock()
+	defer r.muComments.Unlock()
+	r.postComments = append(r.postComments, c)
+	return nil
+}
+
+// Flush posts all results accepted so far as a JSON document. It posts the
+// document even if there are no results, so that receivers are notified of
+// runs without any problems.
+func (r *Reporter) Flush(ctx context.Context) error {
+	r.muComments.Lock()
+	defer r.muComments.Unlock()
+	b, err := json.Marshal(r.payload())
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	return r.send(ctx, b)
+}
+
+func (r *Reporter) payload() *Payload {
+	p := &Payload{Version: commands.Version}
+	if r.build != nil {
+		p.Owner = r.build.Owner
+		p.Repo = r.build.Repo
+		p.SHA = r.build.SHA
+		p.PullRequest = r.build.PullRequest
+		p.Branch = r.build.Branch
+	}
+	p.Tools = []*ToolResult{}
+	tools := make(map[string]*ToolResult)
+	tool := func(name string) *ToolResult {
+		t, ok := tools[name]
+		if !ok {
+			t = &ToolResult{Name: name, Counts: make(map[string]int), Diagnostics: []*Diagnostic{}}
+			tools[name] = t
+			p.Tools = append(p.Tools, t)
+		}
+		return t
+	}
+	for _, name := range r.opt.Tools {
+		tool(name)
+	}
+	for _, c := range r.postComments {
+		t := tool(c.ToolName)
+		t.Counts[c.Result.Diagnostic.GetSeverity().String()]++
+		t.Diagnostics = append(t.Diagnostics, &Diagnostic{
+			Diagnostic:    c.Result.Diagnostic,
+			InDiffFile:    c.Result.InDiffFile,
+			InDiffContext: c.Result.InDiffContext,
+			Overflow:      c.Overflow,
+		})
+	}
+	sort.Slice(p.Tools, func(i, j int) bool { return p.Tools[i].Name < p.Tools[j].Name })
+	return p
+}
+
+func (r *Reporter) send(ctx context.Context, body []byte) error {
+	wait := r.retryWait
+	for i := 0; ; i++ {
+		retryable, err := r.sendOnce(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || i >= r.opt.Retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+func (r *Reporter) sendOnce(ctx context.Context, body []byte) (retryable bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for k, vs := range r.opt.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "reviewdog/"+commands.Version)
+	req.Header.Set(EventHeader, event)
+	if r.opt.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(r.opt.Secret, body))
+	}
+	resp, err := r.cli.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	retryable = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, fmt.Errorf("failed to post webhook: %s: %s", resp.Status, b)
+}
+
+// Sign returns HMAC-SHA256 signature of the body with "sha256=" prefix.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
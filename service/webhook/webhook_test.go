@@ -0,0 +1,210 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/reviewdog/reviewdog"
+	"github.com/reviewdog/reviewdog/cienv"
+	"github.com/reviewdog/reviewdog/filter"
+	"github.com/reviewdog/reviewdog/proto/rdf"
+)
+
+func newComment(tool, path string, line int32, severity rdf.Severity, inDiff bool) *reviewdog.Comment {
+	return &reviewdog.Comment{
+		ToolName: tool,
+		Result: &filter.FilteredDiagnostic{
+			Diagnostic: &rdf.Diagnostic{
+				Message:  "msg",
+				Severity: severity,
+				Location: &rdf.Location{
+					Path:  path,
+					Range: &rdf.Range{Start: &rdf.Position{Line: line}},
+				},
+			},
+			InDiffFile:    inDiff,
+			InDiffContext: inDiff,
+		},
+	}
+}
+
+func TestReporter_Flush(t *testing.T) {
+	ctx := context.Background()
+	var got []map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hook", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("unexpected method: %s", r.Method)
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sig, want := r.Header.Get(SignatureHeader), Sign("s3cret", b); sig != want {
+			t.Errorf("%s = %q, want %q", SignatureHeader, sig, want)
+		}
+		if h := r.Header.Get("X-Custom"); h != "v" {
+			t.Errorf("X-Custom = %q, want v", h)
+		}
+		if h := r.Header.Get(EventHeader); h != "results" {
+			t.Errorf("%s = %q, want results", EventHeader, h)
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal(b, &doc); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, doc)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	build := &cienv.BuildInfo{Owner: "o", Repo: "r", SHA: "sha", PullRequest: 14, Branch: "b"}
+	header := make(http.Header)
+	header.Set("X-Custom", "v")
+	r := NewReporter(ts.Client(), ts.URL+"/hook", build, &Option{Secret: "s3cret", Header: header, Tools: []string{"tool-a", "tool-c"}})
+	comments := []*reviewdog.Comment{
+		newComment("tool-b", "b.go", 1, rdf.Severity_WARNING, false),
+		newComment("tool-a", "a.go", 2, rdf.Severity_ERROR, true),
+		newComment("tool-a", "a.go", 3, rdf.Severity_ERROR, true),
+	}
+	for _, c := range comments {
+		if err := r.Post(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// All results are posted again on subsequent flushes.
+	if err := r.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d requests, want 2", len(got))
+	}
+	if diff := cmp.Diff(got[1], got[0]); diff != "" {
+		t.Errorf("second payload differs from the first one:\n%s", diff)
+	}
+	doc := got[0]
+	delete(doc, "version")
+	diag := func(path string, line float64, severity string, inDiff bool) map[string]interface{} {
+		return map[string]interface{}{
+			"diagnostic": map[string]interface{}{
+				"message":  "msg",
+				"severity": severity,
+				"location": map[string]interface{}{
+					"path":  path,
+					"range": map[string]interface{}{"start": map[string]interface{}{"line": line}},
+				},
+			},
+			"in_diff_file":    inDiff,
+			"in_diff_context": inDiff,
+		}
+	}
+	want := map[string]interface{}{
+		"owner":        "o",
+		"repo":         "r",
+		"sha":          "sha",
+		"pull_request": float64(14),
+		"branch":       "b",
+		"tools": []interface{}{
+			map[string]interface{}{
+				"name":   "tool-a",
+				"counts": map[string]interface{}{"ERROR": float64(2)},
+				"diagnostics": []interface{}{
+					diag("a.go", 2, "ERROR", true),
+					diag("a.go", 3, "ERROR", true),
+				},
+			},
+			map[string]interface{}{
+				"name":   "tool-b",
+				"counts": map[string]interface{}{"WARNING": float64(1)},
+				"diagnostics": []interface{}{
+					diag("b.go", 1, "WARNING", false),
+				},
+			},
+			map[string]interface{}{
+				"name":        "tool-c",
+				"counts":      map[string]interface{}{},
+				"diagnostics": []interface{}{},
+			},
+		},
+	}
+	if diff := cmp.Diff(doc, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestReporter_Flush_noResults(t *testing.T) {
+	var got []*Payload
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := new(Payload)
+		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, p)
+	}))
+	defer ts.Close()
+	r := NewReporter(ts.Client(), ts.URL, nil, &Option{Tools: []string{"tool"}})
+	if err := r.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d requests, want 1", len(got))
+	}
+	want := []*Payload{{
+		Version: got[0].Version,
+		Tools:   []*ToolResult{{Name: "tool", Counts: map[string]int{}, Diagnostics: []*Diagnostic{}}},
+	}}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestReporter_Flush_retry(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		retries   int
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "success", statuses: []int{200}, retries: 3, wantCalls: 1},
+		{name: "retry 5xx", statuses: []int{502, 503, 200}, retries: 3, wantCalls: 3},
+		{name: "retry 429", statuses: []int{429, 204}, retries: 3, wantCalls: 2},
+		{name: "exceed retries", statuses: []int{500, 500, 500}, retries: 2, wantCalls: 3, wantErr: true},
+		{name: "no retry on 4xx", statuses: []int{400, 200}, retries: 3, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			calls := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(SignatureHeader) != "" {
+					t.Errorf("%s should not be set without secret", SignatureHeader)
+				}
+				w.WriteHeader(tt.statuses[calls])
+				calls++
+			}))
+			defer ts.Close()
+			r := NewReporter(ts.Client(), ts.URL, nil, &Option{Retries: tt.retries})
+			r.retryWait = 0
+			if err := r.Post(ctx, newComment("tool", "a.go", 1, rdf.Severity_ERROR, true)); err != nil {
+				t.Fatal(err)
+			}
+			err := r.Flush(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Flush() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("got %d calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package watch
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// holder holds changes made while the watcher is paused (e.g. while runners
+// are running) and releases them after resumed. Files which were also changed
+// while the previous pause are regarded as files written by runners (e.g.
+// caches and reports) and they are dropped, so that runners don't run
+// forever while changes of other files (e.g. files edited while runners are
+// running) are still sent.
+type holder struct {
+	// settle is the duration to keep holding changes after resumed because
+	// changes made while pausing may be notified late.
+	settle time.Duration
+	// notify receives a value when held changes are released.
+	notify chan struct{}
+
+	mu       sync.Mutex
+	paused   bool
+	until    time.Time
+	gen      int
+	held     map[string]bool
+	prev     map[string]bool
+	released []string
+}
+
+func newHolder(settle time.Duration) *holder {
+	return &holder{settle: settle, notify: make(chan struct{}, 1)}
+}
+
+func (h *holder) pause() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.paused = true
+	// Cancel the release of the previous pause. Its changes are released
+	// along with changes of this pause.
+	h.gen++
+	if h.held == nil {
+		h.held = make(map[string]bool)
+	}
+}
+
+func (h *holder) resume() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.paused {
+		return
+	}
+	h.paused = false
+	h.until = time.Now().Add(h.settle)
+	gen := h.gen
+	time.AfterFunc(h.settle, func() { h.release(gen) })
+}
+
+// hold returns true if the changed file is held.
+func (h *holder) hold(file string) bool {
+	if h == nil {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.paused && !time.Now().Before(h.until) {
+		return false
+	}
+	h.held[file] = true
+	return true
+}
+
+// release releases held changes except for files changed while the previous
+// pause.
+func (h *holder) release(gen int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if gen != h.gen || h.paused {
+		return
+	}
+	for f := range h.held {
+		if !h.prev[f] {
+			h.released = append(h.released, f)
+		}
+	}
+	sort.Strings(h.released)
+	h.prev = h.held
+	h.held = nil
+	select {
+	case h.notify <- struct{}{}:
+	default:
+	}
+}
+
+// take returns released changes.
+func (h *holder) take() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	files := h.released
+	h.released = nil
+	return files
+}
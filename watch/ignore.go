@@ -0,0 +1,81 @@
+package watch
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// ignorer reports whether paths are ignored by git (e.g. .gitignore), so that
+// directories such as node_modules are not watched and outputs of tools don't
+// trigger runs. It asks `git check-ignore` running along with the watcher.
+// A nil *ignorer doesn't ignore any paths.
+type ignorer struct {
+	ctx context.Context
+	mu  sync.Mutex
+	w   io.Writer
+	r   *bufio.Reader
+	err error
+}
+
+// newIgnorer starts `git check-ignore` in root until ctx is done. It returns
+// nil if root is not in a git repository.
+func newIgnorer(ctx context.Context, root string) (*ignorer, error) {
+	check := exec.Command("git", "rev-parse", "--is-inside-work-tree")
+	check.Dir = root
+	if out, err := check.Output(); err != nil || strings.TrimSpace(string(out)) != "true" {
+		return nil, nil
+	}
+	cmd := exec.CommandContext(ctx, "git", "check-ignore", "--stdin", "-z", "--verbose", "--non-matching")
+	cmd.Dir = root
+	// Output is flushed for each path.
+	cmd.Env = append(os.Environ(), "GIT_FLUSH=1")
+	w, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	r, pw := io.Pipe()
+	cmd.Stdout = pw
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run git check-ignore: %w", err)
+	}
+	go func() {
+		pw.CloseWithError(cmd.Wait())
+	}()
+	return &ignorer{ctx: ctx, w: w, r: bufio.NewReader(r)}, nil
+}
+
+// ignored returns true if the slash-separated path relative to the root is
+// ignored. Paths are not ignored once git check-ignore fails.
+func (i *ignorer) ignored(rel string) bool {
+	if i == nil || rel == "." {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.err != nil {
+		return false
+	}
+	// Output of each path is "<source> NUL <linenum> NUL <pattern> NUL
+	// <pathname> NUL" and source is empty for paths which are not ignored.
+	var fields [4]string
+	_, err := io.WriteString(i.w, rel+"\x00")
+	for j := 0; err == nil && j < len(fields); j++ {
+		fields[j], err = i.r.ReadString(0)
+	}
+	if err != nil {
+		if i.ctx.Err() == nil {
+			log.Printf("reviewdog: watch: failed to check ignored files: %v", err)
+		}
+		i.err = err
+		return false
+	}
+	// Patterns with "!" (e.g. "!keep.log") match files which are not ignored.
+	return fields[0] != "\x00" && !strings.HasPrefix(fields[2], "!")
+}
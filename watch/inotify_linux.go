@@ -0,0 +1,131 @@
+//go:build linux
+// +build linux
+
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE | syscall.IN_ATTRIB |
+	syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// inotify watches every directory of the tree with inotify(7).
+type inotify struct {
+	root string
+	ig   *ignorer
+	fd   int
+	f    *os.File
+	// dirs are slash-separated paths of watched directories relative to the
+	// root by watch descriptors.
+	dirs map[int32]string
+}
+
+func newNotifier(root string, ig *ignorer) (notifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	// The file of the non-blocking fd is pollable and closing it unblocks
+	// Read.
+	n := &inotify{root: root, ig: ig, fd: fd, f: os.NewFile(uintptr(fd), "inotify"), dirs: make(map[int32]string)}
+	if _, err := n.addTree("."); err != nil {
+		n.f.Close()
+		return nil, err
+	}
+	return n, nil
+}
+
+// addTree watches directories in the tree of dir and returns files in it.
+func (n *inotify) addTree(dir string) ([]string, error) {
+	var files []string
+	err := walk(n.root, dir, n.ig, func(rel string, info os.FileInfo) error {
+		if !info.IsDir() {
+			files = append(files, rel)
+			return nil
+		}
+		wd, err := syscall.InotifyAddWatch(n.fd, filepath.Join(n.root, filepath.FromSlash(rel)), inotifyMask)
+		if err != nil {
+			if err == syscall.ENOENT {
+				return nil
+			}
+			return fmt.Errorf("failed to watch %s: %w", rel, os.NewSyscallError("inotify_add_watch", err))
+		}
+		n.dirs[int32(wd)] = rel
+		return nil
+	})
+	return files, err
+}
+
+func (n *inotify) run(ctx context.Context, events chan<- string) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		n.f.Close()
+	}()
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		nr, err := n.f.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to read inotify events: %w", err)
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= nr; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			name := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+int(ev.Len)]
+			off += syscall.SizeofInotifyEvent + int(ev.Len)
+			changed, err := n.handle(ev.Wd, ev.Mask, strings.TrimRight(string(name), "\x00"))
+			if err != nil {
+				return err
+			}
+			for _, f := range changed {
+				if !send(ctx, events, f) {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// handle returns changed files of the event.
+func (n *inotify) handle(wd int32, mask uint32, name string) ([]string, error) {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		// Events are lost. Watch new directories and report all files.
+		return n.addTree(".")
+	}
+	dir, ok := n.dirs[wd]
+	if !ok {
+		return nil, nil
+	}
+	if mask&syscall.IN_IGNORED != 0 {
+		// The directory is removed.
+		delete(n.dirs, wd)
+		return nil, nil
+	}
+	if name == "" || skipDirs[name] {
+		return nil, nil
+	}
+	rel := path.Join(dir, name)
+	if n.ig.ignored(rel) {
+		return nil, nil
+	}
+	if mask&syscall.IN_ISDIR != 0 && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		// Files may be created before the directory is watched.
+		files, err := n.addTree(rel)
+		return append([]string{rel}, files...), err
+	}
+	return []string{rel}, nil
+}
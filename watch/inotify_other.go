@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package watch
+
+import "errors"
+
+func newNotifier(root string, ig *ignorer) (notifier, error) {
+	return nil, errors.New("file system notification is not supported on this platform")
+}
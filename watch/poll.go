@@ -0,0 +1,77 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"sort"
+	"time"
+)
+
+// poller finds changed files by comparing snapshots of the directory tree.
+type poller struct {
+	root     string
+	interval time.Duration
+	ig       *ignorer
+	files    map[string]fileState
+}
+
+type fileState struct {
+	modTime int64
+	size    int64
+	mode    os.FileMode
+}
+
+func newPoller(root string, interval time.Duration, ig *ignorer) (*poller, error) {
+	p := &poller{root: root, interval: interval, ig: ig}
+	files, err := p.scan()
+	if err != nil {
+		return nil, err
+	}
+	p.files = files
+	return p, nil
+}
+
+func (p *poller) scan() (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := walk(p.root, "", p.ig, func(rel string, info os.FileInfo) error {
+		if !info.IsDir() {
+			files[rel] = fileState{modTime: info.ModTime().UnixNano(), size: info.Size(), mode: info.Mode()}
+		}
+		return nil
+	})
+	return files, err
+}
+
+func (p *poller) run(ctx context.Context, events chan<- string) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		files, err := p.scan()
+		if err != nil {
+			return err
+		}
+		var changed []string
+		for f, s := range files {
+			if old, ok := p.files[f]; !ok || old != s {
+				changed = append(changed, f)
+			}
+		}
+		for f := range p.files {
+			if _, ok := files[f]; !ok {
+				changed = append(changed, f)
+			}
+		}
+		p.files = files
+		sort.Strings(changed)
+		for _, f := range changed {
+			if !send(ctx, events, f) {
+				return nil
+			}
+		}
+	}
+}
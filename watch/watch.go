@@ -0,0 +1,229 @@
+// Package watch provides a watcher of changed files in a directory tree. It
+// uses inotify on Linux and falls back to polling on other platforms or when
+// inotify is unavailable (e.g. the limit of watches is reached).
+package watch
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Option is an option of Watcher.
+type Option struct {
+	// Delay to wait for subsequent changes before sending a batch of changes.
+	// Default is 200ms.
+	Delay time.Duration
+	// Poll watches files by polling even if inotify is available.
+	Poll bool
+	// PollInterval is the interval of polling. Default is 1s.
+	PollInterval time.Duration
+}
+
+// Watcher watches changed files in a directory tree. Files and directories
+// ignored by git (e.g. .gitignore) are not watched.
+type Watcher struct {
+	changes chan []string
+	err     error
+	holder  *holder
+}
+
+// notifier sends paths of changed files relative to the root to events until
+// ctx is done.
+type notifier interface {
+	run(ctx context.Context, events chan<- string) error
+}
+
+// skipDirs are directories which are not watched.
+var skipDirs = map[string]bool{".git": true, ".hg": true, ".svn": true}
+
+// New starts watching the directory tree of root until ctx is done. Changes
+// made after New returns are sent to Changes().
+func New(ctx context.Context, root string, opt *Option) (*Watcher, error) {
+	if opt == nil {
+		opt = &Option{}
+	}
+	delay := opt.Delay
+	if delay == 0 {
+		delay = 200 * time.Millisecond
+	}
+	interval := opt.PollInterval
+	if interval == 0 {
+		interval = time.Second
+	}
+	ig, err := newIgnorer(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{changes: make(chan []string)}
+	settle := delay
+	var n notifier
+	if !opt.Poll {
+		if n, err = newNotifier(root, ig); err != nil {
+			log.Printf("reviewdog: watch: fall back to polling: %v", err)
+		}
+	}
+	if n == nil {
+		p, err := newPoller(root, interval, ig)
+		if err != nil {
+			return nil, err
+		}
+		n = p
+		// Changes are found by the next scan.
+		settle += interval
+	}
+	w.holder = newHolder(settle)
+	events := make(chan string)
+	go func() {
+		w.err = n.run(ctx, events)
+		close(events)
+	}()
+	go func() {
+		defer close(w.changes)
+		batch(ctx, events, w.changes, delay, w.holder)
+		// Wait for the notifier to set the error.
+		for range events {
+		}
+	}()
+	return w, nil
+}
+
+// Changes returns a channel of batches of changed files. Paths of files are
+// slash-separated, relative to the root and sorted. The channel is closed
+// when watching stops.
+func (w *Watcher) Changes() <-chan []string {
+	return w.changes
+}
+
+// Err returns the error which stopped watching. It's valid after the channel
+// of Changes() is closed.
+func (w *Watcher) Err() error {
+	return w.err
+}
+
+// Pause holds changes until Resume is called (e.g. while runners write files
+// in the directory tree).
+func (w *Watcher) Pause() {
+	w.holder.pause()
+}
+
+// Resume sends changes held while pausing, except for files which were also
+// changed while the previous pause (i.e. files which runners write on each
+// run). Changes notified shortly after Resume are held as well because changes
+// made while pausing may be notified late.
+func (w *Watcher) Resume() {
+	w.holder.resume()
+}
+
+// batch sends changed files in events to out in batches. A batch is ready
+// when no change happens for delay, and changes keep being merged into the
+// ready batch until out receives it. Changes held by h are added when h
+// releases them. h can be nil.
+func batch(ctx context.Context, events <-chan string, out chan<- []string, delay time.Duration, h *holder) {
+	pending := make(map[string]bool)
+	ready := make(map[string]bool)
+	timer := time.NewTimer(delay)
+	timer.Stop()
+	resetTimer := func() {
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(delay)
+	}
+	var released <-chan struct{}
+	if h != nil {
+		released = h.notify
+	}
+	sorted := func() []string {
+		files := make([]string, 0, len(ready))
+		for f := range ready {
+			files = append(files, f)
+		}
+		sort.Strings(files)
+		return files
+	}
+	for {
+		var send chan<- []string
+		var files []string
+		if len(ready) > 0 {
+			send = out
+			files = sorted()
+		}
+		select {
+		case f, ok := <-events:
+			if !ok {
+				for f := range pending {
+					ready[f] = true
+				}
+				if len(ready) > 0 {
+					select {
+					case out <- sorted():
+					case <-ctx.Done():
+					}
+				}
+				return
+			}
+			if h.hold(f) {
+				continue
+			}
+			pending[f] = true
+			resetTimer()
+		case <-released:
+			for _, f := range h.take() {
+				pending[f] = true
+			}
+			resetTimer()
+		case <-timer.C:
+			for f := range pending {
+				ready[f] = true
+			}
+			pending = make(map[string]bool)
+		case send <- files:
+			ready = make(map[string]bool)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// walk calls fn with slash-separated paths relative to root of directories
+// and files in the directory tree of dir. Directories in skipDirs and paths
+// ignored by ig are skipped. Files removed while walking are ignored.
+func walk(root, dir string, ig *ignorer, fn func(rel string, info os.FileInfo) error) error {
+	return filepath.Walk(filepath.Join(root, filepath.FromSlash(dir)), func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != "." && ((info.IsDir() && skipDirs[info.Name()]) || ig.ignored(rel)) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(rel, info)
+	})
+}
+
+// send sends the path to events. It returns false if ctx is done.
+func send(ctx context.Context, events chan<- string, rel string) bool {
+	select {
+	case events <- rel:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
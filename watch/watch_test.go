@@ -0,0 +1,236 @@
+package watch
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWatcher(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  *Option
+	}{
+		{name: "notify", opt: &Option{Delay: 100 * time.Millisecond}},
+		{name: "poll", opt: &Option{Delay: 100 * time.Millisecond, Poll: true, PollInterval: 50 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "reviewdog-watch")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			writeFile := func(name, content string) {
+				p := filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(p, []byte(content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			writeFile("a.go", "package a")
+			writeFile("sub/b.go", "package sub")
+			writeFile(".git/HEAD", "ref: refs/heads/main")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			w, err := New(ctx, dir, tt.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			next := func() []string {
+				select {
+				case files := <-w.Changes():
+					return files
+				case <-time.After(5 * time.Second):
+					t.Fatal("timed out waiting for changes")
+					return nil
+				}
+			}
+
+			writeFile("sub/b.go", "package sub // changed")
+			writeFile(".git/index", "ignored")
+			writeFile("new/dir/c.go", "package dir")
+			if err := os.Remove(filepath.Join(dir, "a.go")); err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]bool{}
+			want := map[string]bool{"a.go": true, "sub/b.go": true, "new/dir/c.go": true}
+			for !cmp.Equal(want, filterFiles(got, want)) {
+				for _, f := range next() {
+					got[f] = true
+				}
+			}
+			for f := range got {
+				if filepath.Base(filepath.Dir(f)) == ".git" {
+					t.Errorf("got change of ignored directory: %s", f)
+				}
+			}
+
+			cancel()
+			for range w.Changes() {
+			}
+			if err := w.Err(); err != nil {
+				t.Errorf("Err() = %v", err)
+			}
+		})
+	}
+}
+
+// filterFiles returns files of got which are in want. Directories may be
+// reported along with files.
+func filterFiles(got, want map[string]bool) map[string]bool {
+	m := map[string]bool{}
+	for f := range got {
+		if want[f] {
+			m[f] = true
+		}
+	}
+	return m
+}
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+	events := make(chan string)
+	out := make(chan []string)
+	go func() {
+		defer close(out)
+		batch(ctx, events, out, 50*time.Millisecond, nil)
+	}()
+	events <- "b.go"
+	events <- "a.go"
+	events <- "b.go"
+	if diff := cmp.Diff([]string{"a.go", "b.go"}, <-out); diff != "" {
+		t.Errorf("batch has diff:\n%s", diff)
+	}
+	events <- "c.go"
+	close(events)
+	if diff := cmp.Diff([]string{"c.go"}, <-out); diff != "" {
+		t.Errorf("batch has diff:\n%s", diff)
+	}
+	if files, ok := <-out; ok {
+		t.Errorf("got %v after events are closed", files)
+	}
+}
+
+func TestWatcher_gitIgnored(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  *Option
+	}{
+		{name: "notify", opt: &Option{Delay: 100 * time.Millisecond}},
+		{name: "poll", opt: &Option{Delay: 100 * time.Millisecond, Poll: true, PollInterval: 50 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "reviewdog-watch")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+				t.Fatalf("git init failed: %v\n%s", err, out)
+			}
+			writeFile := func(name, content string) {
+				p := filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(p, []byte(content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			writeFile(".gitignore", "node_modules/\n*.log\n!keep.log\n")
+			writeFile("node_modules/x/a.js", "a")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			w, err := New(ctx, dir, tt.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeFile("node_modules/x/a.js", "changed")
+			writeFile("node_modules/y/b.js", "b")
+			writeFile("out.log", "log")
+			writeFile("keep.log", "keep")
+			writeFile("a.go", "package a")
+			got := map[string]bool{}
+			want := map[string]bool{"keep.log": true, "a.go": true}
+			for !cmp.Equal(want, filterFiles(got, want)) {
+				select {
+				case files := <-w.Changes():
+					for _, f := range files {
+						got[f] = true
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("timed out waiting for changes: got %v", got)
+				}
+			}
+			for f := range got {
+				if strings.HasPrefix(f, "node_modules") || f == "out.log" {
+					t.Errorf("got change of ignored file: %s", f)
+				}
+			}
+		})
+	}
+}
+
+func TestWatcher_Pause(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reviewdog-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFile := func(name string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(time.Now().String()), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w, err := New(ctx, dir, &Option{Delay: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func() []string {
+		select {
+		case files := <-w.Changes():
+			return files
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for changes")
+			return nil
+		}
+	}
+	pause := func(files ...string) {
+		w.Pause()
+		for _, f := range files {
+			writeFile(f)
+		}
+		time.Sleep(50 * time.Millisecond)
+		w.Resume()
+	}
+
+	// Changes while pausing are sent after Resume.
+	pause("report.txt", "a.go")
+	if diff := cmp.Diff([]string{"a.go", "report.txt"}, next()); diff != "" {
+		t.Errorf("changes have diff:\n%s", diff)
+	}
+	// Files changed while the previous pause as well are dropped.
+	pause("report.txt", "b.go")
+	if diff := cmp.Diff([]string{"b.go"}, next()); diff != "" {
+		t.Errorf("changes have diff:\n%s", diff)
+	}
+	pause("report.txt")
+	writeFile("c.go")
+	if diff := cmp.Diff([]string{"c.go"}, next()); diff != "" {
+		t.Errorf("changes have diff:\n%s", diff)
+	}
+}